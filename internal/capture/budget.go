@@ -0,0 +1,78 @@
+package capture
+
+import (
+	"encoding/json"
+
+	"github.com/utrack/otellens/internal/model"
+)
+
+// batchSizer estimates the encoded payload size of the envelopes built from one batch, for
+// sessions that measure it. It runs before emit, so nothing is encoded under the session lock.
+type batchSizer struct {
+	records int
+	// otlpSize returns the OTLP protobuf size of the whole batch; it is called at most once.
+	otlpSize func() int
+	size     int
+}
+
+func newBatchSizer(records int, otlpSize func() int) *batchSizer {
+	return &batchSizer{records: records, otlpSize: otlpSize, size: -1}
+}
+
+// estimate returns the size of a payload holding records of the batch, or 0 if the session does
+// not measure sizes. Binary and OTLP/JSON payloads count their length; projections count their
+// records' share of the batch's OTLP size.
+func (b *batchSizer) estimate(session *Session, payload interface{}, records int) int {
+	if !session.measuresSize() {
+		return 0
+	}
+	if size, ok := encodedSize(payload); ok {
+		return size
+	}
+	if b.records == 0 {
+		return 0
+	}
+	if b.size < 0 {
+		b.size = b.otlpSize()
+	}
+	return int(int64(b.size) * int64(records) / int64(b.records))
+}
+
+// encodedSize returns the length of an already encoded payload.
+func encodedSize(payload interface{}) (int, bool) {
+	switch payload := payload.(type) {
+	case []byte:
+		return len(payload), true
+	case json.RawMessage:
+		return len(payload), true
+	}
+	return 0, false
+}
+
+// measuresSize reports whether emit needs the payload size, for max_bytes or a byte rate limit.
+func (s *Session) measuresSize() bool {
+	return s.maxBytes > 0 || s.limiter != nil && s.limiter.bytes != nil
+}
+
+// budgetExhausted names the first budget the session's totals reached, or returns "".
+func (s *Session) budgetExhausted(batches, records, bytes uint64) string {
+	switch {
+	case s.maxBatches > 0 && batches >= s.maxBatches:
+		return model.LimitMaxBatches
+	case s.maxRecords > 0 && records >= s.maxRecords:
+		return model.LimitMaxRecords
+	case s.maxBytes > 0 && bytes >= s.maxBytes:
+		return model.LimitMaxBytes
+	}
+	return ""
+}
+
+// SentRecords returns the number of records in the streamed batches.
+func (s *Session) SentRecords() uint64 { return s.sentRecords.Load() }
+
+// SentBytes returns the estimated payload size of the streamed batches; it is only measured for
+// sessions with max_bytes or a byte rate limit.
+func (s *Session) SentBytes() uint64 { return s.sentBytes.Load() }
+
+// LimitReached names the budget that ended the session, see model.StreamEnd.LimitReached.
+func (s *Session) LimitReached() string { return budgetReason(s.EndReason()) }
@@ -0,0 +1,84 @@
+package capture
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/utrack/otellens/internal/model"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+)
+
+func TestSessionEndsWhenRecordBudgetIsExhausted(t *testing.T) {
+	registry := NewRegistry(10)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	session, err := registry.Register(ctx, RegisterRequest{MaxRecords: 4, MaxBatches: 10, BufferSize: 10})
+	if err != nil {
+		t.Fatalf("register failed: %v", err)
+	}
+	registry.PublishMetrics(newMetricsBatch("A", "B", "C"))
+	registry.PublishMetrics(newMetricsBatch("D", "E"))
+	registry.PublishMetrics(newMetricsBatch("F"))
+
+	select {
+	case <-session.Done():
+	case <-ctx.Done():
+		t.Fatal("expected the session to end on max_records")
+	}
+	if session.SentBatches() != 2 || session.SentRecords() != 5 || session.LimitReached() != model.LimitMaxRecords || session.EndReason() != model.LimitMaxRecords {
+		t.Fatalf("expected 2 batches with 5 records and max_records reached, got %d, %d, %q",
+			session.SentBatches(), session.SentRecords(), session.LimitReached())
+	}
+}
+
+func TestSessionEndsWhenByteBudgetIsExhausted(t *testing.T) {
+	registry := NewRegistry(10)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	session, err := registry.Register(ctx, RegisterRequest{Format: model.FormatOTLPProto, MaxBytes: 1, MaxBatches: 10, BufferSize: 10})
+	if err != nil {
+		t.Fatalf("register failed: %v", err)
+	}
+	registry.PublishMetrics(newMetricsBatch("A"))
+
+	envelope := <-session.Events()
+	if session.SentBytes() != uint64(len(envelope.Payload.([]byte))) || session.LimitReached() != model.LimitMaxBytes {
+		t.Fatalf("expected the payload size to exhaust max_bytes, got %d bytes and %q", session.SentBytes(), session.LimitReached())
+	}
+
+	unlimited, err := registry.Register(ctx, RegisterRequest{MaxBatches: 1, BufferSize: 1})
+	if err != nil {
+		t.Fatalf("register failed: %v", err)
+	}
+	registry.PublishMetrics(newMetricsBatch("A"))
+	if unlimited.SentBytes() != 0 || unlimited.LimitReached() != model.LimitMaxBatches {
+		t.Fatalf("expected no size measured and max_batches reached, got %d bytes and %q", unlimited.SentBytes(), unlimited.LimitReached())
+	}
+}
+
+func TestByteBudgetEstimatesProjectionsFromTheOTLPSize(t *testing.T) {
+	registry := NewRegistry(10)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	session, err := registry.Register(ctx, RegisterRequest{
+		Filter:     Filter{MetricNames: map[string]struct{}{"A": {}}},
+		MaxBytes:   1 << 20,
+		MaxBatches: 10,
+		BufferSize: 10,
+	})
+	if err != nil {
+		t.Fatalf("register failed: %v", err)
+	}
+	md := newMetricsBatch("A", "B")
+	registry.PublishMetrics(md)
+
+	want := (&pmetric.ProtoMarshaler{}).MetricsSize(md) / 2
+	if session.SentRecords() != 1 || session.SentBytes() != uint64(want) {
+		t.Fatalf("expected one record estimated at half the batch size %d, got %d records and %d bytes",
+			want, session.SentRecords(), session.SentBytes())
+	}
+}
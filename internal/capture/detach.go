@@ -0,0 +1,154 @@
+package capture
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/utrack/otellens/internal/model"
+)
+
+var (
+	ErrSessionNotFound      = errors.New("session not found")
+	ErrSessionNotDetachable = errors.New("session is bound to its creating stream")
+	ErrSessionAttached      = errors.New("session already has an attached stream")
+	ErrResumeUnavailable    = errors.New("requested batches are no longer buffered")
+)
+
+// Detachable reports whether the session outlives its streams for a grace period.
+func (s *Session) Detachable() bool { return s.detachGrace > 0 }
+
+// DetachGrace returns how long a detachable session survives without an attached stream.
+func (s *Session) DetachGrace() time.Duration { return s.detachGrace }
+
+// Attached reports whether a stream is currently attached to a detachable session.
+func (s *Session) Attached() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.attached
+}
+
+// Replay returns retained envelopes with BatchIndex greater than after, oldest first.
+// It fails with ErrResumeUnavailable when some of those envelopes were already evicted.
+func (s *Session) Replay(after uint64) ([]model.Envelope, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.history == nil {
+		return nil, ErrSessionNotDetachable
+	}
+	if after > s.lastIndex {
+		return nil, fmt.Errorf("%w: session is at batch %d", ErrResumeUnavailable, s.lastIndex)
+	}
+	if after == s.lastIndex {
+		return nil, nil
+	}
+	oldest, ok := s.history.oldestIndex()
+	if !ok || oldest > after+1 {
+		return nil, fmt.Errorf("%w: oldest buffered batch is %d", ErrResumeUnavailable, oldest)
+	}
+	return s.history.after(after), nil
+}
+
+func (s *Session) attach() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.Detachable() {
+		return ErrSessionNotDetachable
+	}
+	if s.attached {
+		return ErrSessionAttached
+	}
+	s.attached = true
+	if s.graceTimer != nil {
+		s.graceTimer.Stop()
+		s.graceTimer = nil
+	}
+	return nil
+}
+
+func (s *Session) detach(expire func()) {
+	s.mu.Lock()
+	s.attached = false
+	s.mu.Unlock()
+	s.startGrace(expire)
+}
+
+// startGrace schedules expire unless a stream attaches within the grace period.
+func (s *Session) startGrace(expire func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed || s.attached {
+		return
+	}
+	if s.graceTimer != nil {
+		s.graceTimer.Stop()
+	}
+	s.graceTimer = time.AfterFunc(s.detachGrace, func() {
+		s.mu.Lock()
+		attached := s.attached
+		s.mu.Unlock()
+		if !attached {
+			expire()
+		}
+	})
+}
+
+// Attach binds a stream to a detachable session and stops its grace timer.
+// Only one stream may be attached at a time.
+func (r *Registry) Attach(sessionID string) (*Session, error) {
+	session, ok := r.Session(sessionID)
+	if !ok {
+		return nil, ErrSessionNotFound
+	}
+	if err := session.attach(); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// Detach releases the attached stream; the session is removed if no stream attaches within its grace period.
+func (r *Registry) Detach(session *Session) {
+	session.detach(func() { r.End(session.ID(), model.EndReasonDetachExpired) })
+}
+
+// envelopeRing retains the most recent envelopes of a detachable session for resume.
+type envelopeRing struct {
+	items []model.Envelope
+	start int
+	size  int
+}
+
+func newEnvelopeRing(capacity int) *envelopeRing {
+	return &envelopeRing{items: make([]model.Envelope, capacity)}
+}
+
+func (r *envelopeRing) push(envelope model.Envelope) {
+	if r.size < len(r.items) {
+		r.items[(r.start+r.size)%len(r.items)] = envelope
+		r.size++
+		return
+	}
+	r.items[r.start] = envelope
+	r.start = (r.start + 1) % len(r.items)
+}
+
+func (r *envelopeRing) oldestIndex() (uint64, bool) {
+	if r.size == 0 {
+		return 0, false
+	}
+	return r.items[r.start].BatchIndex, true
+}
+
+func (r *envelopeRing) after(batchIndex uint64) []model.Envelope {
+	out := make([]model.Envelope, 0, r.size)
+	for i := 0; i < r.size; i++ {
+		envelope := r.items[(r.start+i)%len(r.items)]
+		if envelope.BatchIndex > batchIndex {
+			out = append(out, envelope)
+		}
+	}
+	return out
+}
@@ -0,0 +1,82 @@
+package capture
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/utrack/otellens/internal/model"
+)
+
+func TestRegistryDetachableSessionExpiresAfterGrace(t *testing.T) {
+	registry := NewRegistry(2)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	session, err := registry.Register(ctx, RegisterRequest{
+		Filter:      Filter{Signals: map[model.SignalType]struct{}{model.SignalMetrics: {}}},
+		MaxBatches:  4,
+		BufferSize:  4,
+		DetachGrace: 50 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("register failed: %v", err)
+	}
+
+	attached, err := registry.Attach(session.ID())
+	if err != nil {
+		t.Fatalf("attach failed: %v", err)
+	}
+	if _, err := registry.Attach(session.ID()); !errors.Is(err, ErrSessionAttached) {
+		t.Fatalf("expected ErrSessionAttached for a second stream, got %v", err)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	if _, ok := registry.Session(session.ID()); !ok {
+		t.Fatal("expected attached session to outlive the grace period")
+	}
+
+	registry.Detach(attached)
+	select {
+	case <-session.Done():
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected detached session to expire after the grace period")
+	}
+	if _, err := registry.Attach(session.ID()); !errors.Is(err, ErrSessionNotFound) {
+		t.Fatalf("expected ErrSessionNotFound after expiry, got %v", err)
+	}
+}
+
+func TestSessionReplayFromBatchIndex(t *testing.T) {
+	session := newSession("s", RegisterRequest{MaxBatches: 10, BufferSize: 2, DetachGrace: time.Minute})
+	defer session.Close()
+
+	for i := 0; i < 2; i++ {
+		if streamed, _ := session.Emit(model.Envelope{Signal: model.SignalLogs}); !streamed {
+			t.Fatalf("emit %d was not streamed", i)
+		}
+	}
+	<-session.Events()
+	<-session.Events()
+	session.Emit(model.Envelope{Signal: model.SignalLogs})
+
+	replay, err := session.Replay(1)
+	if err != nil {
+		t.Fatalf("replay failed: %v", err)
+	}
+	if len(replay) != 2 || replay[0].BatchIndex != 2 || replay[1].BatchIndex != 3 {
+		t.Fatalf("expected batches 2 and 3, got %+v", replay)
+	}
+	if _, err := session.Replay(0); !errors.Is(err, ErrResumeUnavailable) {
+		t.Fatalf("expected ErrResumeUnavailable for an evicted batch, got %v", err)
+	}
+	if replay, err := session.Replay(3); err != nil || len(replay) != 0 {
+		t.Fatalf("expected empty replay when caught up, got %v / %v", replay, err)
+	}
+
+	bound := newSession("b", RegisterRequest{MaxBatches: 1})
+	if _, err := bound.Replay(0); !errors.Is(err, ErrSessionNotDetachable) {
+		t.Fatalf("expected ErrSessionNotDetachable, got %v", err)
+	}
+}
@@ -0,0 +1,155 @@
+package capture
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/utrack/otellens/internal/model"
+)
+
+// signalCounters counts one signal's batches of a session, see model.SignalStats.
+type signalCounters struct {
+	matched atomic.Uint64
+	sent    atomic.Uint64
+	dropped atomic.Uint64
+}
+
+func newSignalCounters() map[model.SignalType]*signalCounters {
+	return map[model.SignalType]*signalCounters{
+		model.SignalMetrics: {},
+		model.SignalTraces:  {},
+		model.SignalLogs:    {},
+	}
+}
+
+// countMatched counts a telemetry envelope offered to the queue; markers are not counted.
+func (s *Session) countMatched(envelope model.Envelope) {
+	if counters := s.signals[envelope.Signal]; counters != nil && envelope.Type == "" {
+		counters.matched.Add(1)
+	}
+}
+
+// countDropped counts an envelope dropped by backpressure.
+func (s *Session) countDropped(envelope model.Envelope) {
+	s.droppedBatches.Add(1)
+	if counters := s.signals[envelope.Signal]; counters != nil && envelope.Type == "" {
+		counters.dropped.Add(1)
+	}
+}
+
+// countSentLocked counts a queued telemetry envelope and keeps the first and last capture times.
+func (s *Session) countSentLocked(envelope model.Envelope) {
+	if envelope.Type != "" {
+		return
+	}
+	if counters := s.signals[envelope.Signal]; counters != nil {
+		counters.sent.Add(1)
+	}
+	if s.firstCapturedAt.IsZero() {
+		s.firstCapturedAt = envelope.CapturedAt
+	}
+	s.lastCapturedAt = envelope.CapturedAt
+}
+
+// ContextEndReason tells why a session bound to ctx ends once ctx is done: model.EndReasonTimeout
+// if its deadline passed, model.EndReasonClientDisconnect otherwise.
+func ContextEndReason(ctx context.Context) string {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return model.EndReasonTimeout
+	}
+	return model.EndReasonClientDisconnect
+}
+
+// EndReason returns why the session ended, or "" while it runs.
+func (s *Session) EndReason() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.endReason
+}
+
+// AddBytesWritten counts bytes a transport wrote to one of the session's streams.
+func (s *Session) AddBytesWritten(n int) { s.bytesWritten.Add(uint64(n)) }
+
+// BytesWritten returns the bytes written to the session's streams so far.
+func (s *Session) BytesWritten() uint64 { return s.bytesWritten.Load() }
+
+// StreamEnd builds the terminal event of the session's stream.
+func (s *Session) StreamEnd() model.StreamEnd {
+	s.mu.Lock()
+	reason := s.endReason
+	endedAt := s.endedAt
+	first, last := s.firstCapturedAt, s.lastCapturedAt
+	s.mu.Unlock()
+	if endedAt.IsZero() {
+		endedAt = time.Now()
+	}
+
+	end := model.StreamEnd{
+		Type:               "end",
+		SessionID:          s.id,
+		Reason:             reason,
+		Sent:               s.SentBatches(),
+		Dropped:            s.DroppedBatches(),
+		DurationSeconds:    endedAt.Sub(s.startedAt).Seconds(),
+		BytesWritten:       s.BytesWritten(),
+		PausedSeconds:      s.PausedDuration().Seconds(),
+		RateLimited:        s.RateLimitedBatches(),
+		RateLimitedRecords: s.RateLimitedRecords(),
+		LimitReached:       budgetReason(reason),
+	}
+	for signal, counters := range s.signals {
+		stats := model.SignalStats{Matched: counters.matched.Load(), Sent: counters.sent.Load(), Dropped: counters.dropped.Load()}
+		if stats.Matched == 0 {
+			continue
+		}
+		if end.Signals == nil {
+			end.Signals = make(map[model.SignalType]model.SignalStats, len(s.signals))
+		}
+		end.Signals[signal] = stats
+	}
+	if !first.IsZero() {
+		end.FirstCapturedAt = &first
+		end.LastCapturedAt = &last
+	}
+	return end
+}
+
+// budgetReason returns reason if it names a budget, or "".
+func budgetReason(reason string) string {
+	switch reason {
+	case model.LimitMaxBatches, model.LimitMaxRecords, model.LimitMaxBytes:
+		return reason
+	}
+	return ""
+}
+
+// End closes the session for reason; it keeps the first reason if the session already ended.
+func (s *Session) End(reason string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closeLocked(reason)
+}
+
+// End closes and removes a session, see Session.End.
+func (r *Registry) End(sessionID, reason string) {
+	r.mu.Lock()
+	session, ok := r.sessions[sessionID]
+	if ok {
+		delete(r.sessions, sessionID)
+	}
+	r.hasActive.Store(len(r.sessions) > 0)
+	r.mu.Unlock()
+
+	if ok {
+		session.End(reason)
+	}
+}
+
+// Shutdown ends all sessions with model.EndReasonShutdown, so their streams terminate with a StreamEnd.
+func (r *Registry) Shutdown() {
+	for _, session := range r.snapshotSessions() {
+		r.End(session.ID(), model.EndReasonShutdown)
+	}
+}
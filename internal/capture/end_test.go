@@ -0,0 +1,79 @@
+package capture
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/utrack/otellens/internal/model"
+	"go.opentelemetry.io/collector/pdata/plog"
+)
+
+func TestStreamEndCountsPerSignal(t *testing.T) {
+	registry := NewRegistry(10)
+	session, err := registry.Register(context.Background(), RegisterRequest{MaxBatches: 10, BufferSize: 2})
+	if err != nil {
+		t.Fatalf("register failed: %v", err)
+	}
+	registry.PublishMetrics(newMetricsBatch("A"))
+	registry.PublishLogs(newLogsBatch(plog.SeverityNumberInfo))
+	registry.PublishLogs(newLogsBatch(plog.SeverityNumberInfo))
+	registry.Deregister(session.ID())
+
+	end := session.StreamEnd()
+	if end.Reason != model.EndReasonCancelled || end.LimitReached != "" {
+		t.Fatalf("expected a cancelled session, got reason %q", end.Reason)
+	}
+	want := map[model.SignalType]model.SignalStats{
+		model.SignalMetrics: {Matched: 1, Sent: 1},
+		model.SignalLogs:    {Matched: 2, Sent: 1, Dropped: 1},
+	}
+	if len(end.Signals) != len(want) || end.Signals[model.SignalMetrics] != want[model.SignalMetrics] || end.Signals[model.SignalLogs] != want[model.SignalLogs] {
+		t.Fatalf("expected %+v, got %+v", want, end.Signals)
+	}
+	if end.FirstCapturedAt == nil || end.LastCapturedAt.Before(*end.FirstCapturedAt) || end.DurationSeconds <= 0 {
+		t.Fatalf("expected capture times and duration, got %+v", end)
+	}
+}
+
+func TestStreamEndReasons(t *testing.T) {
+	registry := NewRegistry(10)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	timedOut, err := registry.Register(ctx, RegisterRequest{MaxBatches: 1})
+	if err != nil {
+		t.Fatalf("register failed: %v", err)
+	}
+	<-timedOut.Done()
+
+	disconnected, cancelDisconnected := context.WithCancel(context.Background())
+	gone, err := registry.Register(disconnected, RegisterRequest{MaxBatches: 1})
+	if err != nil {
+		t.Fatalf("register failed: %v", err)
+	}
+	cancelDisconnected()
+	<-gone.Done()
+
+	running, err := registry.Register(context.Background(), RegisterRequest{MaxBatches: 1})
+	if err != nil {
+		t.Fatalf("register failed: %v", err)
+	}
+	if running.EndReason() != "" || running.StreamEnd().FirstCapturedAt != nil {
+		t.Fatal("expected a running session to have no end reason and no capture times")
+	}
+	registry.Shutdown()
+
+	for session, want := range map[*Session]string{
+		timedOut: model.EndReasonTimeout,
+		gone:     model.EndReasonClientDisconnect,
+		running:  model.EndReasonShutdown,
+	} {
+		if got := session.StreamEnd().Reason; got != want {
+			t.Fatalf("expected reason %q, got %q", want, got)
+		}
+	}
+	if registry.HasActiveSessions() {
+		t.Fatal("expected shutdown to remove all sessions")
+	}
+}
@@ -0,0 +1,819 @@
+package capture
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/utrack/otellens/internal/model"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// Expr is a parsed boolean filter expression evaluated once per record
+// (metric datapoint, span or log record).
+//
+// Grammar:
+//
+//	expr       := or
+//	or         := and (("or" | "||") and)*
+//	and        := unary (("and" | "&&") unary)*
+//	unary      := ("not" | "!") unary | primary
+//	primary    := "(" expr ")" | operand [compare]
+//	compare    := ("==" | "=" | "!=" | "<" | "<=" | ">" | ">=") operand
+//	            | ("=~" | "!~") string
+//	            | ["not"] "in" "[" literal ("," literal)* "]"
+//	            | "contains" literal
+//	operand    := field | literal
+//	field      := builtin | namespace ("." path | "[" string "]")+
+//	namespace  := "resource" | "scope" | "attributes" | "attr"
+//	literal    := string | number | "true" | "false"
+//
+// A bare field is true when it is present (and not boolean false).
+// Comparisons against a missing field are false.
+type Expr struct {
+	source string
+	root   exprNode
+}
+
+// ExprError reports a parse failure at a 1-based character position of the source.
+type ExprError struct {
+	Position int
+	Message  string
+}
+
+func (e *ExprError) Error() string {
+	return fmt.Sprintf("position %d: %s", e.Position, e.Message)
+}
+
+// ParseExpr parses and type-checks a filter expression. Regular expressions are compiled here, once.
+func ParseExpr(source string) (*Expr, error) {
+	tokens, err := lexExpr(source)
+	if err != nil {
+		return nil, err
+	}
+	p := &exprParser{source: source, tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, p.errorAt(tok, fmt.Sprintf("unexpected %s", tok.describe()))
+	}
+	return &Expr{source: source, root: root}, nil
+}
+
+// String returns the original expression source.
+func (e *Expr) String() string { return e.source }
+
+func (e *Expr) matchDataPoint(resource pcommon.Map, scope pcommon.InstrumentationScope, metric pmetric.Metric, attrs pcommon.Map) bool {
+	return e.root.eval(&exprContext{
+		signal:   model.SignalMetrics,
+		resource: resource,
+		scope:    scope,
+		attrs:    attrs,
+		metric:   metric,
+	})
+}
+
+func (e *Expr) matchSpan(resource pcommon.Map, scope pcommon.InstrumentationScope, span ptrace.Span) bool {
+	return e.root.eval(&exprContext{
+		signal:   model.SignalTraces,
+		resource: resource,
+		scope:    scope,
+		attrs:    span.Attributes(),
+		span:     span,
+	})
+}
+
+func (e *Expr) matchLog(resource pcommon.Map, scope pcommon.InstrumentationScope, record plog.LogRecord) bool {
+	return e.root.eval(&exprContext{
+		signal:   model.SignalLogs,
+		resource: resource,
+		scope:    scope,
+		attrs:    record.Attributes(),
+		log:      record,
+	})
+}
+
+// exprContext is the record an expression is evaluated against.
+// Only the field matching signal is valid among metric, span and log.
+type exprContext struct {
+	signal   model.SignalType
+	resource pcommon.Map
+	scope    pcommon.InstrumentationScope
+	attrs    pcommon.Map
+	metric   pmetric.Metric
+	span     ptrace.Span
+	log      plog.LogRecord
+}
+
+type exprNode interface {
+	eval(ctx *exprContext) bool
+}
+
+type andNode struct{ left, right exprNode }
+
+func (n andNode) eval(ctx *exprContext) bool { return n.left.eval(ctx) && n.right.eval(ctx) }
+
+type orNode struct{ left, right exprNode }
+
+func (n orNode) eval(ctx *exprContext) bool { return n.left.eval(ctx) || n.right.eval(ctx) }
+
+type notNode struct{ inner exprNode }
+
+func (n notNode) eval(ctx *exprContext) bool { return !n.inner.eval(ctx) }
+
+// truthyNode evaluates a bare operand: present and not boolean false.
+type truthyNode struct{ operand exprOperand }
+
+func (n truthyNode) eval(ctx *exprContext) bool {
+	value, ok := n.operand.resolve(ctx)
+	if !ok {
+		return false
+	}
+	return value.kind != exprBool || value.b
+}
+
+type compareOp int
+
+const (
+	opEq compareOp = iota
+	opNeq
+	opLt
+	opLte
+	opGt
+	opGte
+	opMatch
+	opNotMatch
+	opIn
+	opNotIn
+	opContains
+)
+
+type compareNode struct {
+	left    exprOperand
+	op      compareOp
+	right   exprOperand
+	pattern *regexp.Regexp
+	list    []exprValue
+}
+
+func (n compareNode) eval(ctx *exprContext) bool {
+	left, ok := n.left.resolve(ctx)
+	if !ok {
+		return false
+	}
+
+	switch n.op {
+	case opMatch:
+		return n.pattern.MatchString(left.String())
+	case opNotMatch:
+		return !n.pattern.MatchString(left.String())
+	case opIn, opNotIn:
+		found := false
+		for _, item := range n.list {
+			if left.equal(item) {
+				found = true
+				break
+			}
+		}
+		return found == (n.op == opIn)
+	}
+
+	right, ok := n.right.resolve(ctx)
+	if !ok {
+		return false
+	}
+
+	switch n.op {
+	case opEq:
+		return left.equal(right)
+	case opNeq:
+		return !left.equal(right)
+	case opContains:
+		return left.contains(right)
+	default:
+		cmp, ok := left.compare(right)
+		if !ok {
+			return false
+		}
+		switch n.op {
+		case opLt:
+			return cmp < 0
+		case opLte:
+			return cmp <= 0
+		case opGt:
+			return cmp > 0
+		default:
+			return cmp >= 0
+		}
+	}
+}
+
+type exprValueKind int
+
+const (
+	exprString exprValueKind = iota
+	exprNumber
+	exprBool
+	// exprComplex wraps slice, map and bytes attributes.
+	exprComplex
+)
+
+type exprValue struct {
+	kind exprValueKind
+	str  string
+	num  float64
+	b    bool
+	attr pcommon.Value
+}
+
+func stringValue(s string) exprValue  { return exprValue{kind: exprString, str: s} }
+func numberValue(n float64) exprValue { return exprValue{kind: exprNumber, num: n} }
+
+func attrValue(v pcommon.Value) exprValue {
+	switch v.Type() {
+	case pcommon.ValueTypeStr:
+		return stringValue(v.Str())
+	case pcommon.ValueTypeInt:
+		return numberValue(float64(v.Int()))
+	case pcommon.ValueTypeDouble:
+		return numberValue(v.Double())
+	case pcommon.ValueTypeBool:
+		return exprValue{kind: exprBool, b: v.Bool()}
+	default:
+		return exprValue{kind: exprComplex, attr: v}
+	}
+}
+
+func (v exprValue) String() string {
+	switch v.kind {
+	case exprNumber:
+		return strconv.FormatFloat(v.num, 'f', -1, 64)
+	case exprBool:
+		return strconv.FormatBool(v.b)
+	case exprComplex:
+		return v.attr.AsString()
+	default:
+		return v.str
+	}
+}
+
+func (v exprValue) number() (float64, bool) {
+	switch v.kind {
+	case exprNumber:
+		return v.num, true
+	case exprString:
+		n, err := strconv.ParseFloat(v.str, 64)
+		return n, err == nil
+	default:
+		return 0, false
+	}
+}
+
+func (v exprValue) equal(other exprValue) bool {
+	if v.kind == exprNumber && other.kind == exprNumber {
+		return v.num == other.num
+	}
+	if v.kind == exprBool && other.kind == exprBool {
+		return v.b == other.b
+	}
+	return v.String() == other.String()
+}
+
+// compare orders values numerically when both sides are numbers (or numeric strings),
+// lexically when both are strings, and reports ok=false otherwise.
+func (v exprValue) compare(other exprValue) (int, bool) {
+	if a, ok := v.number(); ok {
+		if b, ok := other.number(); ok {
+			switch {
+			case a < b:
+				return -1, true
+			case a > b:
+				return 1, true
+			default:
+				return 0, true
+			}
+		}
+	}
+	if v.kind == exprString && other.kind == exprString {
+		return strings.Compare(v.str, other.str), true
+	}
+	return 0, false
+}
+
+func (v exprValue) contains(needle exprValue) bool {
+	if v.kind == exprComplex && v.attr.Type() == pcommon.ValueTypeSlice {
+		slice := v.attr.Slice()
+		for i := 0; i < slice.Len(); i++ {
+			if attrValue(slice.At(i)).equal(needle) {
+				return true
+			}
+		}
+		return false
+	}
+	return v.kind == exprString && strings.Contains(v.str, needle.String())
+}
+
+type exprOperand interface {
+	resolve(ctx *exprContext) (exprValue, bool)
+}
+
+type literalOperand struct{ value exprValue }
+
+func (o literalOperand) resolve(*exprContext) (exprValue, bool) { return o.value, true }
+
+type attrNamespace int
+
+const (
+	nsResource attrNamespace = iota
+	nsScope
+	nsRecord
+)
+
+type attributeOperand struct {
+	namespace attrNamespace
+	key       string
+}
+
+func (o attributeOperand) resolve(ctx *exprContext) (exprValue, bool) {
+	var attrs pcommon.Map
+	switch o.namespace {
+	case nsResource:
+		attrs = ctx.resource
+	case nsScope:
+		attrs = ctx.scope.Attributes()
+	default:
+		attrs = ctx.attrs
+	}
+	value, ok := lookupAttribute(attrs, o.key)
+	if !ok {
+		return exprValue{}, false
+	}
+	return attrValue(value), true
+}
+
+// builtinOperand resolves a named record or scope property.
+type builtinOperand struct {
+	resolveFn func(ctx *exprContext) (exprValue, bool)
+}
+
+func (o builtinOperand) resolve(ctx *exprContext) (exprValue, bool) { return o.resolveFn(ctx) }
+
+func onSignal(signal model.SignalType, fn func(ctx *exprContext) (exprValue, bool)) func(ctx *exprContext) (exprValue, bool) {
+	return func(ctx *exprContext) (exprValue, bool) {
+		if ctx.signal != signal {
+			return exprValue{}, false
+		}
+		return fn(ctx)
+	}
+}
+
+func nonEmpty(s string) (exprValue, bool) {
+	if s == "" {
+		return exprValue{}, false
+	}
+	return stringValue(s), true
+}
+
+var exprBuiltins = map[string]func(ctx *exprContext) (exprValue, bool){
+	"signal": func(ctx *exprContext) (exprValue, bool) { return stringValue(string(ctx.signal)), true },
+	"scope_name": func(ctx *exprContext) (exprValue, bool) {
+		return nonEmpty(ctx.scope.Name())
+	},
+	"scope_version": func(ctx *exprContext) (exprValue, bool) {
+		return nonEmpty(ctx.scope.Version())
+	},
+	"name": func(ctx *exprContext) (exprValue, bool) {
+		switch ctx.signal {
+		case model.SignalMetrics:
+			return stringValue(ctx.metric.Name()), true
+		case model.SignalTraces:
+			return stringValue(ctx.span.Name()), true
+		default:
+			return nonEmpty(ctx.log.EventName())
+		}
+	},
+	"type": onSignal(model.SignalMetrics, func(ctx *exprContext) (exprValue, bool) {
+		return stringValue(strings.ToLower(ctx.metric.Type().String())), true
+	}),
+	"unit": onSignal(model.SignalMetrics, func(ctx *exprContext) (exprValue, bool) {
+		return nonEmpty(ctx.metric.Unit())
+	}),
+	"description": onSignal(model.SignalMetrics, func(ctx *exprContext) (exprValue, bool) {
+		return nonEmpty(ctx.metric.Description())
+	}),
+	"kind": onSignal(model.SignalTraces, func(ctx *exprContext) (exprValue, bool) {
+		return stringValue(strings.ToLower(ctx.span.Kind().String())), true
+	}),
+	"status": onSignal(model.SignalTraces, func(ctx *exprContext) (exprValue, bool) {
+		return stringValue(strings.ToLower(ctx.span.Status().Code().String())), true
+	}),
+	"status_message": onSignal(model.SignalTraces, func(ctx *exprContext) (exprValue, bool) {
+		return nonEmpty(ctx.span.Status().Message())
+	}),
+	"duration_ms": onSignal(model.SignalTraces, func(ctx *exprContext) (exprValue, bool) {
+		duration, ok := spanDuration(ctx.span)
+		if !ok {
+			return exprValue{}, false
+		}
+		return numberValue(float64(duration) / float64(time.Millisecond)), true
+	}),
+	"parent_span_id": onSignal(model.SignalTraces, func(ctx *exprContext) (exprValue, bool) {
+		if ctx.span.ParentSpanID().IsEmpty() {
+			return exprValue{}, false
+		}
+		return stringValue(ctx.span.ParentSpanID().String()), true
+	}),
+	"trace_id": func(ctx *exprContext) (exprValue, bool) {
+		var id pcommon.TraceID
+		switch ctx.signal {
+		case model.SignalTraces:
+			id = ctx.span.TraceID()
+		case model.SignalLogs:
+			id = ctx.log.TraceID()
+		}
+		if id.IsEmpty() {
+			return exprValue{}, false
+		}
+		return stringValue(id.String()), true
+	},
+	"span_id": func(ctx *exprContext) (exprValue, bool) {
+		var id pcommon.SpanID
+		switch ctx.signal {
+		case model.SignalTraces:
+			id = ctx.span.SpanID()
+		case model.SignalLogs:
+			id = ctx.log.SpanID()
+		}
+		if id.IsEmpty() {
+			return exprValue{}, false
+		}
+		return stringValue(id.String()), true
+	},
+	"body": onSignal(model.SignalLogs, func(ctx *exprContext) (exprValue, bool) {
+		if ctx.log.Body().Type() == pcommon.ValueTypeEmpty {
+			return exprValue{}, false
+		}
+		return attrValue(ctx.log.Body()), true
+	}),
+	"severity_number": onSignal(model.SignalLogs, func(ctx *exprContext) (exprValue, bool) {
+		return numberValue(float64(ctx.log.SeverityNumber())), true
+	}),
+	"severity_text": onSignal(model.SignalLogs, func(ctx *exprContext) (exprValue, bool) {
+		return nonEmpty(ctx.log.SeverityText())
+	}),
+	"event_name": onSignal(model.SignalLogs, func(ctx *exprContext) (exprValue, bool) {
+		return nonEmpty(ctx.log.EventName())
+	}),
+}
+
+type exprParser struct {
+	source string
+	tokens []exprToken
+	pos    int
+}
+
+func (p *exprParser) peek() exprToken { return p.tokens[p.pos] }
+
+func (p *exprParser) next() exprToken {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *exprParser) errorAt(tok exprToken, msg string) error {
+	return &ExprError{Position: utf8.RuneCountInString(p.source[:tok.offset]) + 1, Message: msg}
+}
+
+func (p *exprParser) parseOr() (exprNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().isKeyword("or") || p.peek().isSymbol("||") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orNode{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseAnd() (exprNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().isKeyword("and") || p.peek().isSymbol("&&") {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = andNode{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseUnary() (exprNode, error) {
+	if p.peek().isKeyword("not") || p.peek().isSymbol("!") {
+		p.next()
+		inner, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{inner: inner}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *exprParser) parsePrimary() (exprNode, error) {
+	if p.peek().isSymbol("(") {
+		p.next()
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if tok := p.next(); !tok.isSymbol(")") {
+			return nil, p.errorAt(tok, fmt.Sprintf("expected ')', got %s", tok.describe()))
+		}
+		return inner, nil
+	}
+
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	return p.parseComparison(left)
+}
+
+func (p *exprParser) parseComparison(left exprOperand) (exprNode, error) {
+	tok := p.peek()
+	switch {
+	case tok.isSymbol("==") || tok.isSymbol("="):
+		return p.parseBinary(left, opEq)
+	case tok.isSymbol("!="):
+		return p.parseBinary(left, opNeq)
+	case tok.isSymbol("<"):
+		return p.parseBinary(left, opLt)
+	case tok.isSymbol("<="):
+		return p.parseBinary(left, opLte)
+	case tok.isSymbol(">"):
+		return p.parseBinary(left, opGt)
+	case tok.isSymbol(">="):
+		return p.parseBinary(left, opGte)
+	case tok.isKeyword("contains"):
+		return p.parseBinary(left, opContains)
+	case tok.isSymbol("=~") || tok.isSymbol("!~"):
+		p.next()
+		op := opMatch
+		if tok.isSymbol("!~") {
+			op = opNotMatch
+		}
+		patternTok := p.next()
+		if patternTok.kind != tokString {
+			return nil, p.errorAt(patternTok, fmt.Sprintf("expected regular expression string, got %s", patternTok.describe()))
+		}
+		re, err := regexp.Compile(patternTok.text)
+		if err != nil {
+			return nil, p.errorAt(patternTok, fmt.Sprintf("invalid regular expression: %v", err))
+		}
+		return compareNode{left: left, op: op, pattern: re}, nil
+	case tok.isKeyword("in"):
+		p.next()
+		return p.parseList(left, opIn)
+	case tok.isKeyword("not") && p.tokens[p.pos+1].isKeyword("in"):
+		p.next()
+		p.next()
+		return p.parseList(left, opNotIn)
+	default:
+		return truthyNode{operand: left}, nil
+	}
+}
+
+func (p *exprParser) parseBinary(left exprOperand, op compareOp) (exprNode, error) {
+	p.next()
+	right, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	return compareNode{left: left, op: op, right: right}, nil
+}
+
+func (p *exprParser) parseList(left exprOperand, op compareOp) (exprNode, error) {
+	if tok := p.next(); !tok.isSymbol("[") {
+		return nil, p.errorAt(tok, fmt.Sprintf("expected '[', got %s", tok.describe()))
+	}
+	list := make([]exprValue, 0)
+	for {
+		tok := p.next()
+		value, ok := tok.literal()
+		if !ok {
+			return nil, p.errorAt(tok, fmt.Sprintf("expected literal in list, got %s", tok.describe()))
+		}
+		list = append(list, value)
+
+		sep := p.next()
+		if sep.isSymbol("]") {
+			break
+		}
+		if !sep.isSymbol(",") {
+			return nil, p.errorAt(sep, fmt.Sprintf("expected ',' or ']', got %s", sep.describe()))
+		}
+	}
+	return compareNode{left: left, op: op, list: list}, nil
+}
+
+func (p *exprParser) parseOperand() (exprOperand, error) {
+	tok := p.next()
+	if value, ok := tok.literal(); ok {
+		return literalOperand{value: value}, nil
+	}
+	if tok.kind != tokIdent {
+		return nil, p.errorAt(tok, fmt.Sprintf("expected field or literal, got %s", tok.describe()))
+	}
+
+	head, path, _ := strings.Cut(tok.text, ".")
+	var namespace attrNamespace
+	switch head {
+	case "resource":
+		namespace = nsResource
+	case "scope":
+		namespace = nsScope
+	case "attributes", "attr":
+		namespace = nsRecord
+	default:
+		if path != "" {
+			return nil, p.errorAt(tok, fmt.Sprintf("unknown namespace %q (expected resource, scope or attributes)", head))
+		}
+		resolve, ok := exprBuiltins[head]
+		if !ok {
+			return nil, p.errorAt(tok, fmt.Sprintf("unknown field %q", head))
+		}
+		return builtinOperand{resolveFn: resolve}, nil
+	}
+
+	for p.peek().isSymbol("[") {
+		p.next()
+		keyTok := p.next()
+		if keyTok.kind != tokString {
+			return nil, p.errorAt(keyTok, fmt.Sprintf("expected attribute key string, got %s", keyTok.describe()))
+		}
+		if closeTok := p.next(); !closeTok.isSymbol("]") {
+			return nil, p.errorAt(closeTok, fmt.Sprintf("expected ']', got %s", closeTok.describe()))
+		}
+		if path != "" {
+			path += "."
+		}
+		path += keyTok.text
+	}
+	if path == "" {
+		return nil, p.errorAt(tok, fmt.Sprintf("%s requires an attribute key", head))
+	}
+
+	return attributeOperand{namespace: namespace, key: path}, nil
+}
+
+type exprTokenKind int
+
+const (
+	tokEOF exprTokenKind = iota
+	tokIdent
+	tokString
+	tokNumber
+	tokSymbol
+)
+
+type exprToken struct {
+	kind   exprTokenKind
+	text   string
+	number float64
+	offset int
+}
+
+func (t exprToken) isSymbol(s string) bool { return t.kind == tokSymbol && t.text == s }
+
+func (t exprToken) isKeyword(s string) bool {
+	return t.kind == tokIdent && strings.EqualFold(t.text, s)
+}
+
+func (t exprToken) literal() (exprValue, bool) {
+	switch {
+	case t.kind == tokString:
+		return stringValue(t.text), true
+	case t.kind == tokNumber:
+		return numberValue(t.number), true
+	case t.isKeyword("true"):
+		return exprValue{kind: exprBool, b: true}, true
+	case t.isKeyword("false"):
+		return exprValue{kind: exprBool, b: false}, true
+	}
+	return exprValue{}, false
+}
+
+func (t exprToken) describe() string {
+	switch t.kind {
+	case tokEOF:
+		return "end of expression"
+	case tokString:
+		return strconv.Quote(t.text)
+	default:
+		return fmt.Sprintf("%q", t.text)
+	}
+}
+
+var exprSymbols = []string{"==", "!=", "<=", ">=", "=~", "!~", "&&", "||", "(", ")", "[", "]", ",", "<", ">", "=", "!"}
+
+// lexExpr splits source into tokens. Double-quoted strings support backslash escapes;
+// single-quoted strings are raw, which keeps regular expressions readable.
+func lexExpr(source string) ([]exprToken, error) {
+	tokens := make([]exprToken, 0)
+	errAt := func(offset int, msg string) error {
+		return &ExprError{Position: utf8.RuneCountInString(source[:offset]) + 1, Message: msg}
+	}
+
+	for i := 0; i < len(source); {
+		r, size := utf8.DecodeRuneInString(source[i:])
+		switch {
+		case unicode.IsSpace(r):
+			i += size
+		case r == '"':
+			end := i + 1
+			for end < len(source) && source[end] != '"' {
+				if source[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(source) {
+				return nil, errAt(i, "unterminated string")
+			}
+			text, err := strconv.Unquote(source[i : end+1])
+			if err != nil {
+				return nil, errAt(i, "invalid string escape")
+			}
+			tokens = append(tokens, exprToken{kind: tokString, text: text, offset: i})
+			i = end + 1
+		case r == '\'':
+			end := strings.IndexByte(source[i+1:], '\'')
+			if end < 0 {
+				return nil, errAt(i, "unterminated string")
+			}
+			tokens = append(tokens, exprToken{kind: tokString, text: source[i+1 : i+1+end], offset: i})
+			i += end + 2
+		case r == '-' || (r >= '0' && r <= '9'):
+			end := i + 1
+			for end < len(source) && strings.IndexByte("0123456789.eE+-", source[end]) >= 0 {
+				if (source[end] == '+' || source[end] == '-') && source[end-1] != 'e' && source[end-1] != 'E' {
+					break
+				}
+				end++
+			}
+			number, err := strconv.ParseFloat(source[i:end], 64)
+			if err != nil {
+				return nil, errAt(i, fmt.Sprintf("invalid number %q", source[i:end]))
+			}
+			tokens = append(tokens, exprToken{kind: tokNumber, text: source[i:end], number: number, offset: i})
+			i = end
+		case r == '_' || unicode.IsLetter(r):
+			end := i + size
+			for end < len(source) {
+				next, nextSize := utf8.DecodeRuneInString(source[end:])
+				if next != '_' && next != '.' && next != '-' && !unicode.IsLetter(next) && !unicode.IsDigit(next) {
+					break
+				}
+				end += nextSize
+			}
+			tokens = append(tokens, exprToken{kind: tokIdent, text: source[i:end], offset: i})
+			i = end
+		default:
+			matched := false
+			for _, symbol := range exprSymbols {
+				if strings.HasPrefix(source[i:], symbol) {
+					tokens = append(tokens, exprToken{kind: tokSymbol, text: symbol, offset: i})
+					i += len(symbol)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, errAt(i, fmt.Sprintf("unexpected character %q", r))
+			}
+		}
+	}
+
+	tokens = append(tokens, exprToken{kind: tokEOF, offset: len(source)})
+	return tokens, nil
+}
@@ -0,0 +1,130 @@
+package capture
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/utrack/otellens/internal/model"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+func TestParseExprReportsPosition(t *testing.T) {
+	testCases := []struct {
+		source   string
+		position int
+	}{
+		{source: `resource.service.name == "a" and`, position: 33},
+		{source: `(name == "a"`, position: 13},
+		{source: `unknown == 1`, position: 1},
+		{source: `name =~ '('`, position: 9},
+		{source: `name == "a" @`, position: 13},
+		{source: `kind in ["server" "client"]`, position: 19},
+	}
+
+	for _, tc := range testCases {
+		_, err := ParseExpr(tc.source)
+		var exprErr *ExprError
+		if !errors.As(err, &exprErr) {
+			t.Fatalf("%q: expected ExprError, got %v", tc.source, err)
+		}
+		if exprErr.Position != tc.position {
+			t.Fatalf("%q: expected position %d, got %d (%s)", tc.source, tc.position, exprErr.Position, exprErr.Message)
+		}
+	}
+}
+
+func TestExprMatchTraces_BooleanCombination(t *testing.T) {
+	expr, err := ParseExpr(`(resource["service.name"] == "a" and attributes.http.route == "/x")
+		or (resource.service.name == 'b' && status == "error")`)
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	f := Filter{Signals: map[model.SignalType]struct{}{model.SignalTraces: {}}, Where: expr}
+
+	testCases := []struct {
+		name    string
+		service string
+		route   string
+		status  ptrace.StatusCode
+		match   bool
+	}{
+		{name: "service a on route", service: "a", route: "/x", match: true},
+		{name: "service a other route", service: "a", route: "/y", match: false},
+		{name: "service b error", service: "b", route: "/y", status: ptrace.StatusCodeError, match: true},
+		{name: "service b ok", service: "b", route: "/x", status: ptrace.StatusCodeOk, match: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			td := ptrace.NewTraces()
+			rs := td.ResourceSpans().AppendEmpty()
+			rs.Resource().Attributes().PutStr("service.name", tc.service)
+			span := rs.ScopeSpans().AppendEmpty().Spans().AppendEmpty()
+			span.Attributes().PutStr("http.route", tc.route)
+			span.Status().SetCode(tc.status)
+
+			if got := f.MatchTraces(td); got != tc.match {
+				t.Fatalf("expected match=%v, got %v", tc.match, got)
+			}
+		})
+	}
+}
+
+func TestExprMatchLogsAndMetrics(t *testing.T) {
+	expr, err := ParseExpr(`not (severity_number < 17) and body contains "timeout" and attributes.retry not in [1, 2]`)
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+
+	ld := plog.NewLogs()
+	record := ld.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords().AppendEmpty()
+	record.SetSeverityNumber(plog.SeverityNumberError)
+	record.Body().SetStr("upstream timeout")
+	record.Attributes().PutInt("retry", 3)
+
+	f := Filter{Where: expr}
+	if !f.MatchLogs(ld) {
+		t.Fatal("expected log match")
+	}
+	record.Attributes().PutInt("retry", 2)
+	if f.MatchLogs(ld) {
+		t.Fatal("expected log miss for excluded retry value")
+	}
+
+	metricExpr, err := ParseExpr(`name =~ '^http\.' and attributes["http.response.status_code"] >= 500 and scope_name == "lib"`)
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	md := pmetric.NewMetrics()
+	rm := md.ResourceMetrics().AppendEmpty()
+	sm := rm.ScopeMetrics().AppendEmpty()
+	sm.Scope().SetName("lib")
+	metric := sm.Metrics().AppendEmpty()
+	metric.SetName("http.server.request.duration")
+	dps := metric.SetEmptyGauge().DataPoints()
+	dps.AppendEmpty().Attributes().PutInt("http.response.status_code", 200)
+
+	f = Filter{Where: metricExpr}
+	if f.MatchMetric(rm.Resource().Attributes(), sm.Scope(), metric) {
+		t.Fatal("expected metric miss without a 5xx datapoint")
+	}
+	dps.AppendEmpty().Attributes().PutInt("http.response.status_code", 503)
+	if !f.MatchMetric(rm.Resource().Attributes(), sm.Scope(), metric) {
+		t.Fatal("expected metric match when one datapoint satisfies the expression")
+	}
+}
+
+func TestExprMissingFieldsAndSignalBuiltins(t *testing.T) {
+	expr, err := ParseExpr(`kind == "server" or attributes.missing != "x"`)
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+
+	ctx := &exprContext{signal: model.SignalLogs, resource: pcommon.NewMap(), scope: pcommon.NewInstrumentationScope(), attrs: pcommon.NewMap(), log: plog.NewLogRecord()}
+	if expr.root.eval(ctx) {
+		t.Fatal("expected span-only builtin and missing attribute to evaluate false for logs")
+	}
+}
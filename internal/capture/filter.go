@@ -1,7 +1,9 @@
 package capture
 
 import (
+	"regexp"
 	"strings"
+	"time"
 
 	"github.com/utrack/otellens/internal/model"
 	"go.opentelemetry.io/collector/pdata/pcommon"
@@ -11,19 +13,45 @@ import (
 )
 
 // Filter defines matching conditions for live capture sessions.
+// Name and attribute key fields keep exact values in maps for O(1) lookup;
+// glob and regex values are compiled once into the matching *Patterns fields.
 type Filter struct {
-	Signals             map[model.SignalType]struct{}
-	MetricNames         map[string]struct{}
-	MetricNamesExclude  map[string]struct{}
-	SpanNames           map[string]struct{}
-	SpanNamesExclude    map[string]struct{}
-	AttributeNames      map[string]struct{}
-	AttributeExclude    map[string]struct{}
-	BucketCountsCount   *int
-	ExplicitBoundsCount *int
-	LogBodyContains     string
-	MinSeverityNumber   plog.SeverityNumber
-	ResourceAttributes  map[string]string
+	Signals                   map[model.SignalType]struct{}
+	MetricNames               map[string]struct{}
+	MetricNamesExclude        map[string]struct{}
+	MetricNamePatterns        []*regexp.Regexp
+	MetricNameExcludePatterns []*regexp.Regexp
+	SpanNames                 map[string]struct{}
+	SpanNamesExclude          map[string]struct{}
+	SpanNamePatterns          []*regexp.Regexp
+	SpanNameExcludePatterns   []*regexp.Regexp
+	AttributeNames            map[string]struct{}
+	AttributeExclude          map[string]struct{}
+	AttributePatterns         []*regexp.Regexp
+	AttributeExcludePatterns  []*regexp.Regexp
+	BucketCountsCount         *int
+	ExplicitBoundsCount       *int
+	ExponentialScale          *int
+	PositiveBucketCountsCount *int
+	NegativeBucketCountsCount *int
+	LogBodyContains           string
+	MinSeverityNumber         plog.SeverityNumber
+	ResourceAttributes        map[string]string
+	AttributePredicates       []AttributePredicate
+	SpanKinds                 map[ptrace.SpanKind]struct{}
+	SpanStatusCodes           map[ptrace.StatusCode]struct{}
+	SpanStatusMessageContains string
+	MinSpanDuration           time.Duration
+	MaxSpanDuration           time.Duration
+	RootSpansOnly             bool
+	TraceIDs                  map[pcommon.TraceID]struct{}
+	SpanIDs                   map[pcommon.SpanID]struct{}
+	// HasExemplars keeps only metrics with at least one datapoint exemplar.
+	HasExemplars bool
+	// ExemplarTraceIDs keeps only metrics with an exemplar referencing one of these traces; unlike TraceIDs it does not affect spans or logs.
+	ExemplarTraceIDs map[pcommon.TraceID]struct{}
+	// Where is an optional boolean expression evaluated per record after all other fields matched.
+	Where *Expr
 }
 
 // MatchMetrics checks whether at least one metric in a batch matches this filter.
@@ -36,7 +64,7 @@ func (f Filter) MatchMetrics(md pmetric.Metrics) bool {
 			sm := sms.At(j)
 			metrics := sm.Metrics()
 			for k := 0; k < metrics.Len(); k++ {
-				if f.MatchMetric(rm.Resource().Attributes(), sm.Scope().Attributes(), metrics.At(k)) {
+				if f.MatchMetric(rm.Resource().Attributes(), sm.Scope(), metrics.At(k)) {
 					return true
 				}
 			}
@@ -47,19 +75,15 @@ func (f Filter) MatchMetrics(md pmetric.Metrics) bool {
 }
 
 // MatchMetric checks whether one metric candidate with resource/scope context matches this filter.
-func (f Filter) MatchMetric(resourceAttrs pcommon.Map, scopeAttrs pcommon.Map, metric pmetric.Metric) bool {
+func (f Filter) MatchMetric(resourceAttrs pcommon.Map, scope pcommon.InstrumentationScope, metric pmetric.Metric) bool {
 	if !f.acceptsSignal(model.SignalMetrics) {
 		return false
 	}
+	scopeAttrs := scope.Attributes()
 	if !f.matchResourceAttrs(resourceAttrs) {
 		return false
 	}
-	if len(f.MetricNames) > 0 {
-		if _, ok := f.MetricNames[metric.Name()]; !ok {
-			return false
-		}
-	}
-	if _, excluded := f.MetricNamesExclude[metric.Name()]; excluded {
+	if !matchName(metric.Name(), f.MetricNames, f.MetricNamesExclude, f.MetricNamePatterns, f.MetricNameExcludePatterns) {
 		return false
 	}
 	if !f.matchMetricAttributeNames(resourceAttrs, scopeAttrs, metric) {
@@ -68,14 +92,70 @@ func (f Filter) MatchMetric(resourceAttrs pcommon.Map, scopeAttrs pcommon.Map, m
 	if !f.matchMetricDataPointCounts(metric) {
 		return false
 	}
+	if !f.matchMetricAttributePredicates(resourceAttrs, scopeAttrs, metric) {
+		return false
+	}
+	if f.hasTraceContextFilter() && !metricExemplarsAny(metric, func(ex pmetric.Exemplar) bool {
+		return f.matchTraceContext(ex.TraceID(), ex.SpanID())
+	}) {
+		return false
+	}
+	if !f.matchMetricExemplars(metric) {
+		return false
+	}
+	if f.Where != nil && !metricDataPointsAny(metric, func(dpAttrs pcommon.Map) bool {
+		return f.Where.matchDataPoint(resourceAttrs, scope, metric, dpAttrs)
+	}) {
+		return false
+	}
 
 	return true
 }
 
-func (f Filter) matchMetricDataPointCounts(metric pmetric.Metric) bool {
-	if f.BucketCountsCount == nil && f.ExplicitBoundsCount == nil {
+func (f Filter) matchMetricExemplars(metric pmetric.Metric) bool {
+	if !f.HasExemplars && len(f.ExemplarTraceIDs) == 0 {
 		return true
 	}
+	return metricExemplarsAny(metric, func(ex pmetric.Exemplar) bool {
+		if len(f.ExemplarTraceIDs) == 0 {
+			return true
+		}
+		_, ok := f.ExemplarTraceIDs[ex.TraceID()]
+		return ok
+	})
+}
+
+// matchMetricAttributePredicates requires one datapoint to satisfy all predicates together,
+// so several datapoint-level predicates never combine values from different series.
+func (f Filter) matchMetricAttributePredicates(resourceAttrs pcommon.Map, scopeAttrs pcommon.Map, metric pmetric.Metric) bool {
+	if len(f.AttributePredicates) == 0 {
+		return true
+	}
+	return metricDataPointsAny(metric, func(dpAttrs pcommon.Map) bool {
+		return matchAttributePredicates(f.AttributePredicates, recordAttributes{
+			resource: resourceAttrs,
+			scope:    scopeAttrs,
+			level:    AttributeLevelDataPoint,
+			record:   dpAttrs,
+		})
+	})
+}
+
+func (f Filter) matchMetricDataPointCounts(metric pmetric.Metric) bool {
+	if f.BucketCountsCount != nil || f.ExplicitBoundsCount != nil {
+		if !f.matchHistogramDataPointCounts(metric) {
+			return false
+		}
+	}
+	if f.ExponentialScale != nil || f.PositiveBucketCountsCount != nil || f.NegativeBucketCountsCount != nil {
+		if !f.matchExponentialHistogramDataPoints(metric) {
+			return false
+		}
+	}
+	return true
+}
+
+func (f Filter) matchHistogramDataPointCounts(metric pmetric.Metric) bool {
 	if metric.Type() != pmetric.MetricTypeHistogram {
 		return false
 	}
@@ -95,7 +175,30 @@ func (f Filter) matchMetricDataPointCounts(metric pmetric.Metric) bool {
 	return false
 }
 
-// MatchTraces checks whether a traces batch matches this filter.
+func (f Filter) matchExponentialHistogramDataPoints(metric pmetric.Metric) bool {
+	if metric.Type() != pmetric.MetricTypeExponentialHistogram {
+		return false
+	}
+
+	dps := metric.ExponentialHistogram().DataPoints()
+	for i := 0; i < dps.Len(); i++ {
+		dp := dps.At(i)
+		if f.ExponentialScale != nil && int(dp.Scale()) != *f.ExponentialScale {
+			continue
+		}
+		if f.PositiveBucketCountsCount != nil && dp.Positive().BucketCounts().Len() != *f.PositiveBucketCountsCount {
+			continue
+		}
+		if f.NegativeBucketCountsCount != nil && dp.Negative().BucketCounts().Len() != *f.NegativeBucketCountsCount {
+			continue
+		}
+		return true
+	}
+
+	return false
+}
+
+// MatchTraces checks whether at least one span in a batch matches this filter.
 func (f Filter) MatchTraces(td ptrace.Traces) bool {
 	if !f.acceptsSignal(model.SignalTraces) {
 		return false
@@ -104,28 +207,14 @@ func (f Filter) MatchTraces(td ptrace.Traces) bool {
 	rss := td.ResourceSpans()
 	for i := 0; i < rss.Len(); i++ {
 		rs := rss.At(i)
-		if !f.matchResourceAttrs(rs.Resource().Attributes()) {
-			continue
-		}
-
 		ilss := rs.ScopeSpans()
 		for j := 0; j < ilss.Len(); j++ {
 			ss := ilss.At(j)
 			spans := ss.Spans()
 			for k := 0; k < spans.Len(); k++ {
-				span := spans.At(k)
-				if len(f.SpanNames) > 0 {
-					if _, ok := f.SpanNames[span.Name()]; !ok {
-						continue
-					}
-				}
-				if _, excluded := f.SpanNamesExclude[span.Name()]; excluded {
-					continue
-				}
-				if !f.matchTraceAttributeNames(rs.Resource().Attributes(), ss.Scope().Attributes(), span) {
-					continue
+				if f.MatchSpan(rs.Resource().Attributes(), ss.Scope(), spans.At(k)) {
+					return true
 				}
-				return true
 			}
 		}
 	}
@@ -133,7 +222,44 @@ func (f Filter) MatchTraces(td ptrace.Traces) bool {
 	return false
 }
 
-// MatchLogs checks whether a logs batch matches this filter.
+// MatchSpan checks whether one span candidate with resource/scope context matches this filter.
+func (f Filter) MatchSpan(resourceAttrs pcommon.Map, scope pcommon.InstrumentationScope, span ptrace.Span) bool {
+	if !f.acceptsSignal(model.SignalTraces) {
+		return false
+	}
+	if !f.matchResourceAttrs(resourceAttrs) {
+		return false
+	}
+	if !f.matchTraceContext(span.TraceID(), span.SpanID()) {
+		return false
+	}
+	if !matchName(span.Name(), f.SpanNames, f.SpanNamesExclude, f.SpanNamePatterns, f.SpanNameExcludePatterns) {
+		return false
+	}
+	if !f.matchSpanShape(span) {
+		return false
+	}
+	if !f.matchTraceAttributeNames(resourceAttrs, scope.Attributes(), span) {
+		return false
+	}
+	if len(f.AttributePredicates) > 0 && !matchAttributePredicates(f.AttributePredicates, recordAttributes{
+		resource:  resourceAttrs,
+		scope:     scope.Attributes(),
+		level:     AttributeLevelSpan,
+		record:    span.Attributes(),
+		events:    span.Events(),
+		hasEvents: true,
+	}) {
+		return false
+	}
+	if f.Where != nil && !f.Where.matchSpan(resourceAttrs, scope, span) {
+		return false
+	}
+
+	return true
+}
+
+// MatchLogs checks whether at least one log record in a batch matches this filter.
 func (f Filter) MatchLogs(ld plog.Logs) bool {
 	if !f.acceptsSignal(model.SignalLogs) {
 		return false
@@ -142,25 +268,113 @@ func (f Filter) MatchLogs(ld plog.Logs) bool {
 	rls := ld.ResourceLogs()
 	for i := 0; i < rls.Len(); i++ {
 		rl := rls.At(i)
-		if !f.matchResourceAttrs(rl.Resource().Attributes()) {
-			continue
-		}
-
 		sls := rl.ScopeLogs()
 		for j := 0; j < sls.Len(); j++ {
 			sl := sls.At(j)
 			logs := sl.LogRecords()
 			for k := 0; k < logs.Len(); k++ {
-				record := logs.At(k)
-				if !f.matchLogAttributeNames(rl.Resource().Attributes(), sl.Scope().Attributes(), record.Attributes()) {
-					continue
-				}
-				if f.MinSeverityNumber > 0 && record.SeverityNumber() < f.MinSeverityNumber {
-					continue
-				}
-				if f.LogBodyContains != "" && !strings.Contains(record.Body().AsString(), f.LogBodyContains) {
-					continue
+				if f.MatchLogRecord(rl.Resource().Attributes(), sl.Scope(), logs.At(k)) {
+					return true
 				}
+			}
+		}
+	}
+
+	return false
+}
+
+// MatchLogRecord checks whether one log record candidate with resource/scope context matches this filter.
+func (f Filter) MatchLogRecord(resourceAttrs pcommon.Map, scope pcommon.InstrumentationScope, record plog.LogRecord) bool {
+	if !f.acceptsSignal(model.SignalLogs) {
+		return false
+	}
+	if !f.matchResourceAttrs(resourceAttrs) {
+		return false
+	}
+	if !f.matchTraceContext(record.TraceID(), record.SpanID()) {
+		return false
+	}
+	if !f.matchLogAttributeNames(resourceAttrs, scope.Attributes(), record.Attributes()) {
+		return false
+	}
+	if f.MinSeverityNumber > 0 && record.SeverityNumber() < f.MinSeverityNumber {
+		return false
+	}
+	if f.LogBodyContains != "" && !strings.Contains(record.Body().AsString(), f.LogBodyContains) {
+		return false
+	}
+	if len(f.AttributePredicates) > 0 && !matchAttributePredicates(f.AttributePredicates, recordAttributes{
+		resource: resourceAttrs,
+		scope:    scope.Attributes(),
+		level:    AttributeLevelLog,
+		record:   record.Attributes(),
+	}) {
+		return false
+	}
+	if f.Where != nil && !f.Where.matchLog(resourceAttrs, scope, record) {
+		return false
+	}
+
+	return true
+}
+
+func (f Filter) hasTraceContextFilter() bool {
+	return len(f.TraceIDs) > 0 || len(f.SpanIDs) > 0
+}
+
+// matchTraceContext checks a span, log record or exemplar against trace_ids and span_ids.
+func (f Filter) matchTraceContext(traceID pcommon.TraceID, spanID pcommon.SpanID) bool {
+	if len(f.TraceIDs) > 0 {
+		if _, ok := f.TraceIDs[traceID]; !ok {
+			return false
+		}
+	}
+	if len(f.SpanIDs) > 0 {
+		if _, ok := f.SpanIDs[spanID]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// metricExemplarsAny reports whether match accepts at least one exemplar of any datapoint of metric.
+// Summaries carry no exemplars and never match.
+func metricExemplarsAny(metric pmetric.Metric, match func(pmetric.Exemplar) bool) bool {
+	anyOf := func(exemplars pmetric.ExemplarSlice) bool {
+		for i := 0; i < exemplars.Len(); i++ {
+			if match(exemplars.At(i)) {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch metric.Type() {
+	case pmetric.MetricTypeGauge:
+		dps := metric.Gauge().DataPoints()
+		for i := 0; i < dps.Len(); i++ {
+			if anyOf(dps.At(i).Exemplars()) {
+				return true
+			}
+		}
+	case pmetric.MetricTypeSum:
+		dps := metric.Sum().DataPoints()
+		for i := 0; i < dps.Len(); i++ {
+			if anyOf(dps.At(i).Exemplars()) {
+				return true
+			}
+		}
+	case pmetric.MetricTypeHistogram:
+		dps := metric.Histogram().DataPoints()
+		for i := 0; i < dps.Len(); i++ {
+			if anyOf(dps.At(i).Exemplars()) {
+				return true
+			}
+		}
+	case pmetric.MetricTypeExponentialHistogram:
+		dps := metric.ExponentialHistogram().DataPoints()
+		for i := 0; i < dps.Len(); i++ {
+			if anyOf(dps.At(i).Exemplars()) {
 				return true
 			}
 		}
@@ -169,6 +383,48 @@ func (f Filter) MatchLogs(ld plog.Logs) bool {
 	return false
 }
 
+// matchSpanShape checks span kind, status, duration and root-span conditions.
+func (f Filter) matchSpanShape(span ptrace.Span) bool {
+	if f.RootSpansOnly && !span.ParentSpanID().IsEmpty() {
+		return false
+	}
+	if len(f.SpanKinds) > 0 {
+		if _, ok := f.SpanKinds[span.Kind()]; !ok {
+			return false
+		}
+	}
+	if len(f.SpanStatusCodes) > 0 {
+		if _, ok := f.SpanStatusCodes[span.Status().Code()]; !ok {
+			return false
+		}
+	}
+	if f.SpanStatusMessageContains != "" && !strings.Contains(span.Status().Message(), f.SpanStatusMessageContains) {
+		return false
+	}
+	if f.MinSpanDuration > 0 || f.MaxSpanDuration > 0 {
+		duration, ok := spanDuration(span)
+		if !ok {
+			return false
+		}
+		if f.MinSpanDuration > 0 && duration < f.MinSpanDuration {
+			return false
+		}
+		if f.MaxSpanDuration > 0 && duration > f.MaxSpanDuration {
+			return false
+		}
+	}
+	return true
+}
+
+// spanDuration computes end-start; spans with missing or inverted timestamps have no duration.
+func spanDuration(span ptrace.Span) (time.Duration, bool) {
+	start, end := span.StartTimestamp(), span.EndTimestamp()
+	if start == 0 || end < start {
+		return 0, false
+	}
+	return time.Duration(end - start), true
+}
+
 func (f Filter) acceptsSignal(signal model.SignalType) bool {
 	if len(f.Signals) == 0 {
 		return true
@@ -190,61 +446,74 @@ func (f Filter) matchResourceAttrs(attrs pcommon.Map) bool {
 	return true
 }
 
+// matchName applies include/exclude name rules. Exact values are checked before patterns,
+// so filters without patterns never touch the regexp engine.
+func matchName(name string, include, exclude map[string]struct{}, includePatterns, excludePatterns []*regexp.Regexp) bool {
+	if len(include) > 0 || len(includePatterns) > 0 {
+		if _, ok := include[name]; !ok && !matchAnyPattern(name, includePatterns) {
+			return false
+		}
+	}
+	if _, excluded := exclude[name]; excluded {
+		return false
+	}
+	return !matchAnyPattern(name, excludePatterns)
+}
+
 func (f Filter) matchMetricAttributeNames(resourceAttrs pcommon.Map, scopeAttrs pcommon.Map, metric pmetric.Metric) bool {
-	if f.containsAnyKey(resourceAttrs, f.AttributeExclude) ||
-		f.containsAnyKey(scopeAttrs, f.AttributeExclude) ||
-		f.metricDataPointsContainAnyKey(metric, f.AttributeExclude) {
+	if f.hasAttributeExcludeFilter() &&
+		(f.containsExcludedKey(resourceAttrs) ||
+			f.containsExcludedKey(scopeAttrs) ||
+			metricDataPointsAny(metric, f.containsExcludedKey)) {
 		return false
 	}
-	if len(f.AttributeNames) == 0 {
+	if !f.hasAttributeNameFilter() {
 		return true
 	}
-	if f.containsAnyKey(resourceAttrs, f.AttributeNames) {
+	if f.containsIncludedKey(resourceAttrs) {
 		return true
 	}
-	if f.containsAnyKey(scopeAttrs, f.AttributeNames) {
+	if f.containsIncludedKey(scopeAttrs) {
 		return true
 	}
-	return f.metricDataPointsContainAnyKey(metric, f.AttributeNames)
+	return metricDataPointsAny(metric, f.containsIncludedKey)
 }
 
-func (f Filter) metricDataPointsContainAnyKey(metric pmetric.Metric, keys map[string]struct{}) bool {
-	if len(keys) == 0 {
-		return false
-	}
+// metricDataPointsAny reports whether match accepts the attributes of at least one datapoint of metric.
+func metricDataPointsAny(metric pmetric.Metric, match func(pcommon.Map) bool) bool {
 	switch metric.Type() {
 	case pmetric.MetricTypeGauge:
 		dps := metric.Gauge().DataPoints()
 		for i := 0; i < dps.Len(); i++ {
-			if f.containsAnyKey(dps.At(i).Attributes(), keys) {
+			if match(dps.At(i).Attributes()) {
 				return true
 			}
 		}
 	case pmetric.MetricTypeSum:
 		dps := metric.Sum().DataPoints()
 		for i := 0; i < dps.Len(); i++ {
-			if f.containsAnyKey(dps.At(i).Attributes(), keys) {
+			if match(dps.At(i).Attributes()) {
 				return true
 			}
 		}
 	case pmetric.MetricTypeHistogram:
 		dps := metric.Histogram().DataPoints()
 		for i := 0; i < dps.Len(); i++ {
-			if f.containsAnyKey(dps.At(i).Attributes(), keys) {
+			if match(dps.At(i).Attributes()) {
 				return true
 			}
 		}
 	case pmetric.MetricTypeSummary:
 		dps := metric.Summary().DataPoints()
 		for i := 0; i < dps.Len(); i++ {
-			if f.containsAnyKey(dps.At(i).Attributes(), keys) {
+			if match(dps.At(i).Attributes()) {
 				return true
 			}
 		}
 	case pmetric.MetricTypeExponentialHistogram:
 		dps := metric.ExponentialHistogram().DataPoints()
 		for i := 0; i < dps.Len(); i++ {
-			if f.containsAnyKey(dps.At(i).Attributes(), keys) {
+			if match(dps.At(i).Attributes()) {
 				return true
 			}
 		}
@@ -254,31 +523,31 @@ func (f Filter) metricDataPointsContainAnyKey(metric pmetric.Metric, keys map[st
 }
 
 func (f Filter) matchTraceAttributeNames(resourceAttrs pcommon.Map, scopeAttrs pcommon.Map, span ptrace.Span) bool {
-	if f.containsAnyKey(resourceAttrs, f.AttributeExclude) ||
-		f.containsAnyKey(scopeAttrs, f.AttributeExclude) ||
-		f.containsAnyKey(span.Attributes(), f.AttributeExclude) {
+	if f.containsExcludedKey(resourceAttrs) ||
+		f.containsExcludedKey(scopeAttrs) ||
+		f.containsExcludedKey(span.Attributes()) {
 		return false
 	}
 	events := span.Events()
-	for i := 0; i < events.Len(); i++ {
-		if f.containsAnyKey(events.At(i).Attributes(), f.AttributeExclude) {
+	for i := 0; f.hasAttributeExcludeFilter() && i < events.Len(); i++ {
+		if f.containsExcludedKey(events.At(i).Attributes()) {
 			return false
 		}
 	}
-	if len(f.AttributeNames) == 0 {
+	if !f.hasAttributeNameFilter() {
 		return true
 	}
-	if f.containsAnyKey(resourceAttrs, f.AttributeNames) {
+	if f.containsIncludedKey(resourceAttrs) {
 		return true
 	}
-	if f.containsAnyKey(scopeAttrs, f.AttributeNames) {
+	if f.containsIncludedKey(scopeAttrs) {
 		return true
 	}
-	if f.containsAnyKey(span.Attributes(), f.AttributeNames) {
+	if f.containsIncludedKey(span.Attributes()) {
 		return true
 	}
 	for i := 0; i < events.Len(); i++ {
-		if f.containsAnyKey(events.At(i).Attributes(), f.AttributeNames) {
+		if f.containsIncludedKey(events.At(i).Attributes()) {
 			return true
 		}
 	}
@@ -287,31 +556,53 @@ func (f Filter) matchTraceAttributeNames(resourceAttrs pcommon.Map, scopeAttrs p
 }
 
 func (f Filter) matchLogAttributeNames(resourceAttrs pcommon.Map, scopeAttrs pcommon.Map, logAttrs pcommon.Map) bool {
-	if f.containsAnyKey(resourceAttrs, f.AttributeExclude) ||
-		f.containsAnyKey(scopeAttrs, f.AttributeExclude) ||
-		f.containsAnyKey(logAttrs, f.AttributeExclude) {
+	if f.containsExcludedKey(resourceAttrs) ||
+		f.containsExcludedKey(scopeAttrs) ||
+		f.containsExcludedKey(logAttrs) {
 		return false
 	}
-	if len(f.AttributeNames) == 0 {
+	if !f.hasAttributeNameFilter() {
 		return true
 	}
-	if f.containsAnyKey(resourceAttrs, f.AttributeNames) {
+	if f.containsIncludedKey(resourceAttrs) {
 		return true
 	}
-	if f.containsAnyKey(scopeAttrs, f.AttributeNames) {
+	if f.containsIncludedKey(scopeAttrs) {
 		return true
 	}
-	return f.containsAnyKey(logAttrs, f.AttributeNames)
+	return f.containsIncludedKey(logAttrs)
 }
 
-func (f Filter) containsAnyKey(attrs pcommon.Map, keys map[string]struct{}) bool {
-	if len(keys) == 0 {
-		return false
-	}
+func (f Filter) hasAttributeNameFilter() bool {
+	return len(f.AttributeNames) > 0 || len(f.AttributePatterns) > 0
+}
+
+func (f Filter) hasAttributeExcludeFilter() bool {
+	return len(f.AttributeExclude) > 0 || len(f.AttributeExcludePatterns) > 0
+}
+
+func (f Filter) containsIncludedKey(attrs pcommon.Map) bool {
+	return containsAnyKey(attrs, f.AttributeNames, f.AttributePatterns)
+}
+
+func (f Filter) containsExcludedKey(attrs pcommon.Map) bool {
+	return containsAnyKey(attrs, f.AttributeExclude, f.AttributeExcludePatterns)
+}
+
+func containsAnyKey(attrs pcommon.Map, keys map[string]struct{}, patterns []*regexp.Regexp) bool {
 	for key := range keys {
 		if _, ok := attrs.Get(key); ok {
 			return true
 		}
 	}
-	return false
+	if len(patterns) == 0 {
+		return false
+	}
+
+	found := false
+	attrs.Range(func(key string, _ pcommon.Value) bool {
+		found = matchAnyPattern(key, patterns)
+		return !found
+	})
+	return found
 }
@@ -1,9 +1,12 @@
 package capture
 
 import (
+	"regexp"
 	"testing"
+	"time"
 
 	"github.com/utrack/otellens/internal/model"
+	"go.opentelemetry.io/collector/pdata/pcommon"
 	"go.opentelemetry.io/collector/pdata/plog"
 	"go.opentelemetry.io/collector/pdata/pmetric"
 	"go.opentelemetry.io/collector/pdata/ptrace"
@@ -170,7 +173,7 @@ func TestFilterMatchMetrics_HistogramDataPointCountFilters(t *testing.T) {
 	gauge := sm.Metrics().AppendEmpty()
 	gauge.SetName("cpu.usage")
 	gauge.SetEmptyGauge().DataPoints().AppendEmpty().SetDoubleValue(1)
-	if f.MatchMetric(rm.Resource().Attributes(), sm.Scope().Attributes(), gauge) {
+	if f.MatchMetric(rm.Resource().Attributes(), sm.Scope(), gauge) {
 		t.Fatal("expected non-histogram metric miss when histogram count filters are set")
 	}
 }
@@ -200,6 +203,89 @@ func TestFilterMatchMetrics_HistogramCountFiltersAnyDatapoint(t *testing.T) {
 	}
 }
 
+func TestFilterMatchMetrics_ExponentialHistogramShapeFilters(t *testing.T) {
+	md := pmetric.NewMetrics()
+	rm := md.ResourceMetrics().AppendEmpty()
+	sm := rm.ScopeMetrics().AppendEmpty()
+
+	exp := sm.Metrics().AppendEmpty()
+	exp.SetName("rpc.server.duration")
+	dps := exp.SetEmptyExponentialHistogram().DataPoints()
+	dp0 := dps.AppendEmpty()
+	dp0.SetScale(20)
+	dp0.Positive().BucketCounts().FromRaw([]uint64{1})
+	dp1 := dps.AppendEmpty()
+	dp1.SetScale(3)
+	dp1.Positive().BucketCounts().FromRaw([]uint64{1, 2, 3})
+
+	scale := 3
+	positive := 3
+	negative := 0
+	f := Filter{
+		ExponentialScale:          &scale,
+		PositiveBucketCountsCount: &positive,
+		NegativeBucketCountsCount: &negative,
+	}
+	if !f.MatchMetric(rm.Resource().Attributes(), sm.Scope(), exp) {
+		t.Fatal("expected match when one datapoint satisfies scale and bucket lengths")
+	}
+
+	positive = 1
+	if f.MatchMetric(rm.Resource().Attributes(), sm.Scope(), exp) {
+		t.Fatal("expected miss when no single datapoint satisfies scale and bucket lengths")
+	}
+
+	zero := 0
+	f = Filter{ExponentialScale: &zero}
+	hist := sm.Metrics().AppendEmpty()
+	hist.SetEmptyHistogram().DataPoints().AppendEmpty()
+	if f.MatchMetric(rm.Resource().Attributes(), sm.Scope(), hist) {
+		t.Fatal("expected regular histogram miss when exponential shape filters are set")
+	}
+}
+
+func TestFilterMatchMetrics_ExemplarFilters(t *testing.T) {
+	traceID := pcommon.TraceID([16]byte{0xaa})
+	otherTraceID := pcommon.TraceID([16]byte{0xbb})
+
+	md := pmetric.NewMetrics()
+	rm := md.ResourceMetrics().AppendEmpty()
+	sm := rm.ScopeMetrics().AppendEmpty()
+	withExemplar := sm.Metrics().AppendEmpty()
+	dps := withExemplar.SetEmptySum().DataPoints()
+	dps.AppendEmpty()
+	dps.AppendEmpty().Exemplars().AppendEmpty().SetTraceID(traceID)
+	without := sm.Metrics().AppendEmpty()
+	without.SetEmptySum().DataPoints().AppendEmpty()
+
+	testCases := []struct {
+		name   string
+		filter Filter
+		metric pmetric.Metric
+		match  bool
+	}{
+		{name: "has exemplars", filter: Filter{HasExemplars: true}, metric: withExemplar, match: true},
+		{name: "has exemplars miss", filter: Filter{HasExemplars: true}, metric: without, match: false},
+		{name: "exemplar trace id", filter: Filter{ExemplarTraceIDs: map[pcommon.TraceID]struct{}{traceID: {}}}, metric: withExemplar, match: true},
+		{name: "exemplar trace id miss", filter: Filter{ExemplarTraceIDs: map[pcommon.TraceID]struct{}{otherTraceID: {}}}, metric: withExemplar, match: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.filter.MatchMetric(rm.Resource().Attributes(), sm.Scope(), tc.metric); got != tc.match {
+				t.Fatalf("expected match=%v, got %v", tc.match, got)
+			}
+		})
+	}
+
+	td := ptrace.NewTraces()
+	td.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans().AppendEmpty().SetTraceID(otherTraceID)
+	f := Filter{ExemplarTraceIDs: map[pcommon.TraceID]struct{}{traceID: {}}}
+	if !f.MatchTraces(td) {
+		t.Fatal("expected exemplar_trace_ids to leave traces unfiltered")
+	}
+}
+
 func TestFilterMatchTracesByAttributeNameAcrossLevels(t *testing.T) {
 	td := ptrace.NewTraces()
 	rs := td.ResourceSpans().AppendEmpty()
@@ -294,3 +380,331 @@ func TestFilterMatchTraces_FieldANDValueOR(t *testing.T) {
 		t.Fatal("expected match when span name matches and any attribute_names value matches")
 	}
 }
+
+func TestFilterMatchMetrics_NamePatterns(t *testing.T) {
+	md := pmetric.NewMetrics()
+	rm := md.ResourceMetrics().AppendEmpty()
+	sm := rm.ScopeMetrics().AppendEmpty()
+	metric := sm.Metrics().AppendEmpty()
+	metric.SetName("kafka.consumer.records.lag")
+	metric.SetEmptyGauge().DataPoints().AppendEmpty().SetDoubleValue(1)
+
+	glob, err := CompilePattern("glob:http.server.*")
+	if err != nil {
+		t.Fatalf("compile glob: %v", err)
+	}
+	re, err := CompilePattern(`~^kafka\.consumer\..*lag$`)
+	if err != nil {
+		t.Fatalf("compile regex: %v", err)
+	}
+
+	f := Filter{
+		Signals:            map[model.SignalType]struct{}{model.SignalMetrics: {}},
+		MetricNamePatterns: []*regexp.Regexp{glob},
+	}
+	if f.MatchMetrics(md) {
+		t.Fatal("expected miss for metric outside glob")
+	}
+
+	f.MetricNamePatterns = append(f.MetricNamePatterns, re)
+	if !f.MatchMetrics(md) {
+		t.Fatal("expected match when any include pattern matches")
+	}
+
+	f.MetricNameExcludePatterns = []*regexp.Regexp{re}
+	if f.MatchMetrics(md) {
+		t.Fatal("expected miss when exclude pattern matches")
+	}
+}
+
+func TestFilterMatchTraces_AttributeKeyPatterns(t *testing.T) {
+	td := ptrace.NewTraces()
+	rs := td.ResourceSpans().AppendEmpty()
+	span := rs.ScopeSpans().AppendEmpty().Spans().AppendEmpty()
+	span.SetName("GET /v1/orders")
+	span.Attributes().PutStr("http.request.header.x-tenant", "a")
+
+	header, err := CompilePattern("glob:http.request.header.*")
+	if err != nil {
+		t.Fatalf("compile glob: %v", err)
+	}
+	route, err := CompilePattern("glob:GET /v1/*")
+	if err != nil {
+		t.Fatalf("compile glob: %v", err)
+	}
+
+	f := Filter{
+		Signals:           map[model.SignalType]struct{}{model.SignalTraces: {}},
+		SpanNamePatterns:  []*regexp.Regexp{route},
+		AttributePatterns: []*regexp.Regexp{header},
+	}
+	if !f.MatchTraces(td) {
+		t.Fatal("expected match by span name glob and attribute key glob")
+	}
+
+	f.AttributeExcludePatterns = []*regexp.Regexp{header}
+	if f.MatchTraces(td) {
+		t.Fatal("expected miss when attribute key matches exclude pattern")
+	}
+}
+
+func TestCompilePatternGlobSyntax(t *testing.T) {
+	testCases := []struct {
+		pattern string
+		value   string
+		match   bool
+	}{
+		{pattern: "glob:http.*", value: "http.server.duration", match: true},
+		{pattern: "glob:http.*", value: "httpx", match: false},
+		{pattern: "glob:rpc.?.calls", value: "rpc.a.calls", match: true},
+		{pattern: "glob:db.[ab]", value: "db.c", match: false},
+		{pattern: "glob:db.[!ab]", value: "db.c", match: true},
+		{pattern: `glob:literal\*`, value: "literal*", match: true},
+		{pattern: "~lag$", value: "consumer.lag", match: true},
+	}
+
+	for _, tc := range testCases {
+		re, err := CompilePattern(tc.pattern)
+		if err != nil {
+			t.Fatalf("compile %q: %v", tc.pattern, err)
+		}
+		if got := re.MatchString(tc.value); got != tc.match {
+			t.Fatalf("pattern %q on %q: expected %v, got %v", tc.pattern, tc.value, tc.match, got)
+		}
+	}
+
+	if _, err := CompilePattern("glob:db.[ab"); err == nil {
+		t.Fatal("expected error for unterminated character class")
+	}
+	if _, err := CompilePattern("~(unclosed"); err == nil {
+		t.Fatal("expected error for invalid regular expression")
+	}
+	if IsPattern("SELECT * FROM t WHERE id = ?") {
+		t.Fatal("expected values without a prefix to stay exact")
+	}
+}
+
+func TestFilterMatchTraces_AttributePredicates(t *testing.T) {
+	td := ptrace.NewTraces()
+	rs := td.ResourceSpans().AppendEmpty()
+	rs.Resource().Attributes().PutStr("service.name", "checkout")
+	ss := rs.ScopeSpans().AppendEmpty()
+
+	ok := ss.Spans().AppendEmpty()
+	ok.Attributes().PutStr("http.route", "/v1/orders")
+	ok.Attributes().PutInt("http.response.status_code", 200)
+
+	failed := ss.Spans().AppendEmpty()
+	failed.Attributes().PutStr("http.route", "/v1/users")
+	failed.Attributes().PutInt("http.response.status_code", 503)
+
+	status, err := NewAttributePredicate("http.response.status_code", AttributeLevelSpan, AttributeOpGte, float64(500), nil)
+	if err != nil {
+		t.Fatalf("build predicate: %v", err)
+	}
+	route, err := NewAttributePredicate("http.route", AttributeLevelSpan, AttributeOpEq, "/v1/orders", nil)
+	if err != nil {
+		t.Fatalf("build predicate: %v", err)
+	}
+
+	f := Filter{
+		Signals:             map[model.SignalType]struct{}{model.SignalTraces: {}},
+		AttributePredicates: []AttributePredicate{status, route},
+	}
+	if f.MatchTraces(td) {
+		t.Fatal("expected no match: status and route predicates must hold on the same span")
+	}
+
+	ok.Attributes().PutInt("http.response.status_code", 500)
+	if !f.MatchTraces(td) {
+		t.Fatal("expected match for 5xx span on the selected route")
+	}
+
+	service, err := NewAttributePredicate("service.name", AttributeLevelResource, AttributeOpIn, nil, []interface{}{"cart", "payments"})
+	if err != nil {
+		t.Fatalf("build predicate: %v", err)
+	}
+	f.AttributePredicates = append(f.AttributePredicates, service)
+	if f.MatchTraces(td) {
+		t.Fatal("expected miss when resource predicate does not hold")
+	}
+}
+
+func TestFilterMatchLogs_AttributePredicateDottedPathAndContains(t *testing.T) {
+	ld := plog.NewLogs()
+	rl := ld.ResourceLogs().AppendEmpty()
+	record := rl.ScopeLogs().AppendEmpty().LogRecords().AppendEmpty()
+	user := record.Attributes().PutEmptyMap("payload").PutEmptyMap("user")
+	user.PutStr("id", "42")
+	record.Attributes().PutEmptySlice("tags").FromRaw([]any{"beta", "eu"})
+
+	userID, err := NewAttributePredicate("payload.user.id", AttributeLevelLog, AttributeOpEq, float64(42), nil)
+	if err != nil {
+		t.Fatalf("build predicate: %v", err)
+	}
+	tags, err := NewAttributePredicate("tags", AttributeLevelAny, AttributeOpContains, "beta", nil)
+	if err != nil {
+		t.Fatalf("build predicate: %v", err)
+	}
+	spanOnly, err := NewAttributePredicate("http.route", AttributeLevelSpan, AttributeOpExists, nil, nil)
+	if err != nil {
+		t.Fatalf("build predicate: %v", err)
+	}
+
+	f := Filter{
+		Signals:             map[model.SignalType]struct{}{model.SignalLogs: {}},
+		AttributePredicates: []AttributePredicate{userID, tags, spanOnly},
+	}
+	if !f.MatchLogs(ld) {
+		t.Fatal("expected match by nested map path and slice contains; span-level predicate must be ignored for logs")
+	}
+
+	user.PutStr("id", "43")
+	if f.MatchLogs(ld) {
+		t.Fatal("expected miss for different nested value")
+	}
+}
+
+func TestFilterMatchMetrics_AttributePredicatesSameDataPoint(t *testing.T) {
+	md := pmetric.NewMetrics()
+	sm := md.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty()
+	metric := sm.Metrics().AppendEmpty()
+	metric.SetName("http.server.request.duration")
+	dps := metric.SetEmptyGauge().DataPoints()
+	first := dps.AppendEmpty()
+	first.Attributes().PutStr("http.route", "/a")
+	first.Attributes().PutStr("http.request.method", "GET")
+	second := dps.AppendEmpty()
+	second.Attributes().PutStr("http.route", "/b")
+	second.Attributes().PutStr("http.request.method", "POST")
+
+	route, err := NewAttributePredicate("http.route", AttributeLevelDataPoint, AttributeOpRegex, "^/a", nil)
+	if err != nil {
+		t.Fatalf("build predicate: %v", err)
+	}
+	method, err := NewAttributePredicate("http.request.method", AttributeLevelDataPoint, AttributeOpNeq, "GET", nil)
+	if err != nil {
+		t.Fatalf("build predicate: %v", err)
+	}
+
+	f := Filter{
+		Signals:             map[model.SignalType]struct{}{model.SignalMetrics: {}},
+		AttributePredicates: []AttributePredicate{route, method},
+	}
+	if f.MatchMetrics(md) {
+		t.Fatal("expected miss: predicates are satisfied only by different datapoints")
+	}
+}
+
+func TestNewAttributePredicateValidates(t *testing.T) {
+	if _, err := NewAttributePredicate("", AttributeLevelAny, AttributeOpExists, nil, nil); err == nil {
+		t.Fatal("expected error for empty key")
+	}
+	if _, err := NewAttributePredicate("k", "request", AttributeOpExists, nil, nil); err == nil {
+		t.Fatal("expected error for unknown level")
+	}
+	if _, err := NewAttributePredicate("k", AttributeLevelAny, "like", "x", nil); err == nil {
+		t.Fatal("expected error for unknown operator")
+	}
+	if _, err := NewAttributePredicate("k", AttributeLevelAny, AttributeOpGt, "abc", nil); err == nil {
+		t.Fatal("expected error for non-numeric gt operand")
+	}
+	if _, err := NewAttributePredicate("k", AttributeLevelAny, AttributeOpIn, nil, nil); err == nil {
+		t.Fatal("expected error for empty in values")
+	}
+}
+
+func TestFilterTraceContextAcrossSignals(t *testing.T) {
+	traceID := pcommon.TraceID([16]byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16})
+	otherTraceID := pcommon.TraceID([16]byte{16})
+	spanID := pcommon.SpanID([8]byte{1, 2, 3, 4, 5, 6, 7, 8})
+
+	f := Filter{TraceIDs: map[pcommon.TraceID]struct{}{traceID: {}}}
+
+	td := ptrace.NewTraces()
+	span := td.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans().AppendEmpty()
+	span.SetTraceID(otherTraceID)
+	if f.MatchTraces(td) {
+		t.Fatal("expected span miss for other trace")
+	}
+	span.SetTraceID(traceID)
+	span.SetSpanID(spanID)
+	if !f.MatchTraces(td) {
+		t.Fatal("expected span match by trace_id")
+	}
+
+	ld := plog.NewLogs()
+	record := ld.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords().AppendEmpty()
+	if f.MatchLogs(ld) {
+		t.Fatal("expected log miss without trace context")
+	}
+	record.SetTraceID(traceID)
+	if !f.MatchLogs(ld) {
+		t.Fatal("expected log match by trace_id")
+	}
+
+	f.SpanIDs = map[pcommon.SpanID]struct{}{spanID: {}}
+	if f.MatchLogs(ld) {
+		t.Fatal("expected log miss when span_ids is set and span differs")
+	}
+	if !f.MatchTraces(td) {
+		t.Fatal("expected span match by trace_id and span_id")
+	}
+
+	md := pmetric.NewMetrics()
+	rm := md.ResourceMetrics().AppendEmpty()
+	sm := rm.ScopeMetrics().AppendEmpty()
+	metric := sm.Metrics().AppendEmpty()
+	metric.SetName("http.server.request.duration")
+	dp := metric.SetEmptyHistogram().DataPoints().AppendEmpty()
+	f.SpanIDs = nil
+	if f.MatchMetrics(md) {
+		t.Fatal("expected metric miss without exemplars")
+	}
+	dp.Exemplars().AppendEmpty().SetTraceID(traceID)
+	if !f.MatchMetrics(md) {
+		t.Fatal("expected metric match by exemplar trace_id")
+	}
+}
+
+func TestFilterMatchTraces_SpanKindStatusDurationAndRoot(t *testing.T) {
+	td := ptrace.NewTraces()
+	span := td.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans().AppendEmpty()
+	span.SetName("GET /v1/orders")
+	span.SetKind(ptrace.SpanKindServer)
+	span.Status().SetCode(ptrace.StatusCodeError)
+	span.Status().SetMessage("upstream deadline exceeded")
+	span.SetStartTimestamp(pcommon.Timestamp(1_000_000_000))
+	span.SetEndTimestamp(pcommon.Timestamp(1_750_000_000))
+
+	f := Filter{
+		Signals:                   map[model.SignalType]struct{}{model.SignalTraces: {}},
+		SpanKinds:                 map[ptrace.SpanKind]struct{}{ptrace.SpanKindServer: {}},
+		SpanStatusCodes:           map[ptrace.StatusCode]struct{}{ptrace.StatusCodeError: {}},
+		SpanStatusMessageContains: "deadline",
+		MinSpanDuration:           500 * time.Millisecond,
+		MaxSpanDuration:           time.Second,
+		RootSpansOnly:             true,
+	}
+	if !f.MatchTraces(td) {
+		t.Fatal("expected slow failing root server span to match")
+	}
+
+	f.MinSpanDuration = time.Second
+	f.MaxSpanDuration = 0
+	if f.MatchTraces(td) {
+		t.Fatal("expected miss for span shorter than min duration")
+	}
+	f.MinSpanDuration = 0
+
+	span.SetParentSpanID(pcommon.SpanID([8]byte{1}))
+	if f.MatchTraces(td) {
+		t.Fatal("expected miss for child span when root_spans_only is set")
+	}
+	f.RootSpansOnly = false
+
+	span.SetKind(ptrace.SpanKindClient)
+	if f.MatchTraces(td) {
+		t.Fatal("expected miss for other span kind")
+	}
+}
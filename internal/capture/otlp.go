@@ -0,0 +1,214 @@
+package capture
+
+import (
+	"encoding/json"
+
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// filterMetrics copies the matching metrics of md into a new batch, keeping resource/scope structure.
+// Only resources and scopes with at least one matching metric are copied.
+func filterMetrics(matcher recordMatcher, md pmetric.Metrics) (pmetric.Metrics, bool) {
+	out := pmetric.NewMetrics()
+
+	rms := md.ResourceMetrics()
+	for i := 0; i < rms.Len(); i++ {
+		rm := rms.At(i)
+		var outRM pmetric.ResourceMetrics
+		resourceCopied := false
+
+		sms := rm.ScopeMetrics()
+		for j := 0; j < sms.Len(); j++ {
+			sm := sms.At(j)
+			var outSM pmetric.ScopeMetrics
+			scopeCopied := false
+
+			metrics := sm.Metrics()
+			for k := 0; k < metrics.Len(); k++ {
+				metric := metrics.At(k)
+				if !matcher.MatchMetric(rm.Resource().Attributes(), sm.Scope(), metric) {
+					continue
+				}
+				if !resourceCopied {
+					outRM = out.ResourceMetrics().AppendEmpty()
+					rm.Resource().CopyTo(outRM.Resource())
+					outRM.SetSchemaUrl(rm.SchemaUrl())
+					resourceCopied = true
+				}
+				if !scopeCopied {
+					outSM = outRM.ScopeMetrics().AppendEmpty()
+					sm.Scope().CopyTo(outSM.Scope())
+					outSM.SetSchemaUrl(sm.SchemaUrl())
+					scopeCopied = true
+				}
+				metric.CopyTo(outSM.Metrics().AppendEmpty())
+			}
+		}
+	}
+
+	return out, out.ResourceMetrics().Len() > 0
+}
+
+// filterTraces copies the matching spans of td into a new batch, keeping resource/scope structure.
+func filterTraces(matcher recordMatcher, td ptrace.Traces) (ptrace.Traces, bool) {
+	out := ptrace.NewTraces()
+
+	rss := td.ResourceSpans()
+	for i := 0; i < rss.Len(); i++ {
+		rs := rss.At(i)
+		var outRS ptrace.ResourceSpans
+		resourceCopied := false
+
+		ilss := rs.ScopeSpans()
+		for j := 0; j < ilss.Len(); j++ {
+			ss := ilss.At(j)
+			var outSS ptrace.ScopeSpans
+			scopeCopied := false
+
+			spans := ss.Spans()
+			for k := 0; k < spans.Len(); k++ {
+				span := spans.At(k)
+				if !matcher.MatchSpan(rs.Resource().Attributes(), ss.Scope(), span) {
+					continue
+				}
+				if !resourceCopied {
+					outRS = out.ResourceSpans().AppendEmpty()
+					rs.Resource().CopyTo(outRS.Resource())
+					outRS.SetSchemaUrl(rs.SchemaUrl())
+					resourceCopied = true
+				}
+				if !scopeCopied {
+					outSS = outRS.ScopeSpans().AppendEmpty()
+					ss.Scope().CopyTo(outSS.Scope())
+					outSS.SetSchemaUrl(ss.SchemaUrl())
+					scopeCopied = true
+				}
+				span.CopyTo(outSS.Spans().AppendEmpty())
+			}
+		}
+	}
+
+	return out, out.ResourceSpans().Len() > 0
+}
+
+// filterLogs copies the matching log records of ld into a new batch, keeping resource/scope structure.
+func filterLogs(matcher recordMatcher, ld plog.Logs) (plog.Logs, bool) {
+	out := plog.NewLogs()
+
+	rls := ld.ResourceLogs()
+	for i := 0; i < rls.Len(); i++ {
+		rl := rls.At(i)
+		var outRL plog.ResourceLogs
+		resourceCopied := false
+
+		sls := rl.ScopeLogs()
+		for j := 0; j < sls.Len(); j++ {
+			sl := sls.At(j)
+			var outSL plog.ScopeLogs
+			scopeCopied := false
+
+			logs := sl.LogRecords()
+			for k := 0; k < logs.Len(); k++ {
+				record := logs.At(k)
+				if !matcher.MatchLogRecord(rl.Resource().Attributes(), sl.Scope(), record) {
+					continue
+				}
+				if !resourceCopied {
+					outRL = out.ResourceLogs().AppendEmpty()
+					rl.Resource().CopyTo(outRL.Resource())
+					outRL.SetSchemaUrl(rl.SchemaUrl())
+					resourceCopied = true
+				}
+				if !scopeCopied {
+					outSL = outRL.ScopeLogs().AppendEmpty()
+					sl.Scope().CopyTo(outSL.Scope())
+					outSL.SetSchemaUrl(sl.SchemaUrl())
+					scopeCopied = true
+				}
+				record.CopyTo(outSL.LogRecords().AppendEmpty())
+			}
+		}
+	}
+
+	return out, out.ResourceLogs().Len() > 0
+}
+
+// buildMatchingMetricsOTLPJSON encodes the matching subset of md as an OTLP/JSON ExportMetricsServiceRequest.
+func buildMatchingMetricsOTLPJSON(matcher recordMatcher, md pmetric.Metrics) (json.RawMessage, int, bool) {
+	filtered, ok := filterMetrics(matcher, md)
+	if !ok {
+		return nil, 0, false
+	}
+	raw, err := (&pmetric.JSONMarshaler{}).MarshalMetrics(filtered)
+	if err != nil {
+		return nil, 0, false
+	}
+	return raw, filtered.MetricCount(), true
+}
+
+// buildMatchingTracesOTLPJSON encodes the matching subset of td as an OTLP/JSON ExportTraceServiceRequest.
+func buildMatchingTracesOTLPJSON(matcher recordMatcher, td ptrace.Traces) (json.RawMessage, int, bool) {
+	filtered, ok := filterTraces(matcher, td)
+	if !ok {
+		return nil, 0, false
+	}
+	raw, err := (&ptrace.JSONMarshaler{}).MarshalTraces(filtered)
+	if err != nil {
+		return nil, 0, false
+	}
+	return raw, filtered.SpanCount(), true
+}
+
+// buildMatchingLogsOTLPJSON encodes the matching subset of ld as an OTLP/JSON ExportLogsServiceRequest.
+func buildMatchingLogsOTLPJSON(matcher recordMatcher, ld plog.Logs) (json.RawMessage, int, bool) {
+	filtered, ok := filterLogs(matcher, ld)
+	if !ok {
+		return nil, 0, false
+	}
+	raw, err := (&plog.JSONMarshaler{}).MarshalLogs(filtered)
+	if err != nil {
+		return nil, 0, false
+	}
+	return raw, filtered.LogRecordCount(), true
+}
+
+// buildMatchingMetricsOTLPProto encodes the matching subset of md as a binary ExportMetricsServiceRequest.
+func buildMatchingMetricsOTLPProto(matcher recordMatcher, md pmetric.Metrics) ([]byte, int, bool) {
+	filtered, ok := filterMetrics(matcher, md)
+	if !ok {
+		return nil, 0, false
+	}
+	raw, err := (&pmetric.ProtoMarshaler{}).MarshalMetrics(filtered)
+	if err != nil {
+		return nil, 0, false
+	}
+	return raw, filtered.MetricCount(), true
+}
+
+// buildMatchingTracesOTLPProto encodes the matching subset of td as a binary ExportTraceServiceRequest.
+func buildMatchingTracesOTLPProto(matcher recordMatcher, td ptrace.Traces) ([]byte, int, bool) {
+	filtered, ok := filterTraces(matcher, td)
+	if !ok {
+		return nil, 0, false
+	}
+	raw, err := (&ptrace.ProtoMarshaler{}).MarshalTraces(filtered)
+	if err != nil {
+		return nil, 0, false
+	}
+	return raw, filtered.SpanCount(), true
+}
+
+// buildMatchingLogsOTLPProto encodes the matching subset of ld as a binary ExportLogsServiceRequest.
+func buildMatchingLogsOTLPProto(matcher recordMatcher, ld plog.Logs) ([]byte, int, bool) {
+	filtered, ok := filterLogs(matcher, ld)
+	if !ok {
+		return nil, 0, false
+	}
+	raw, err := (&plog.ProtoMarshaler{}).MarshalLogs(filtered)
+	if err != nil {
+		return nil, 0, false
+	}
+	return raw, filtered.LogRecordCount(), true
+}
@@ -0,0 +1,108 @@
+package capture
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	// regexPrefix marks a name filter value as an RE2 regular expression.
+	regexPrefix = "~"
+	// globPrefix marks a name filter value as a glob. Values without a prefix are exact, so names
+	// containing `*` or `?` (e.g. SQL statements) keep matching literally.
+	globPrefix = "glob:"
+)
+
+// IsPattern reports whether a name filter value is a regex or a glob instead of an exact literal.
+func IsPattern(value string) bool {
+	return strings.HasPrefix(value, regexPrefix) || strings.HasPrefix(value, globPrefix)
+}
+
+// CompilePattern compiles a regex (`~expr`) or glob (`glob:http.server.*`) name filter value.
+// Regular expressions use RE2 semantics and are not anchored implicitly; globs always match the whole name.
+func CompilePattern(value string) (*regexp.Regexp, error) {
+	if strings.HasPrefix(value, regexPrefix) {
+		expr := strings.TrimPrefix(value, regexPrefix)
+		if expr == "" {
+			return nil, fmt.Errorf("empty regular expression")
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression %q: %w", expr, err)
+		}
+		return re, nil
+	}
+
+	expr, err := globToRegexp(strings.TrimPrefix(value, globPrefix))
+	if err != nil {
+		return nil, err
+	}
+	return regexp.Compile(expr)
+}
+
+// globToRegexp translates a glob into an anchored RE2 expression.
+// Supported syntax: `*` (any run), `?` (any single char), `[abc]`/`[a-z]`/`[!abc]` classes and `\` escapes.
+func globToRegexp(glob string) (string, error) {
+	var sb strings.Builder
+	sb.WriteString("^")
+
+	runes := []rune(glob)
+	for i := 0; i < len(runes); i++ {
+		switch c := runes[i]; c {
+		case '*':
+			sb.WriteString(".*")
+		case '?':
+			sb.WriteString(".")
+		case '\\':
+			if i+1 >= len(runes) {
+				return "", fmt.Errorf("invalid glob %q: trailing escape", glob)
+			}
+			i++
+			sb.WriteString(regexp.QuoteMeta(string(runes[i])))
+		case '[':
+			end := i + 1
+			if end < len(runes) && (runes[end] == '!' || runes[end] == '^') {
+				end++
+			}
+			if end < len(runes) && runes[end] == ']' {
+				end++
+			}
+			for end < len(runes) && runes[end] != ']' {
+				end++
+			}
+			if end >= len(runes) {
+				return "", fmt.Errorf("invalid glob %q: unterminated character class", glob)
+			}
+
+			class := runes[i+1 : end]
+			sb.WriteString("[")
+			if len(class) > 0 && (class[0] == '!' || class[0] == '^') {
+				sb.WriteString("^")
+				class = class[1:]
+			}
+			for _, r := range class {
+				if r == '\\' || r == '[' {
+					sb.WriteRune('\\')
+				}
+				sb.WriteRune(r)
+			}
+			sb.WriteString("]")
+			i = end
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+
+	sb.WriteString("$")
+	return sb.String(), nil
+}
+
+func matchAnyPattern(value string, patterns []*regexp.Regexp) bool {
+	for _, pattern := range patterns {
+		if pattern.MatchString(value) {
+			return true
+		}
+	}
+	return false
+}
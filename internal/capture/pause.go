@@ -0,0 +1,46 @@
+package capture
+
+import "time"
+
+// Paused reports whether the session is paused. Publish* skips paused sessions before matching,
+// so their batches are neither streamed nor counted toward max_batches.
+func (s *Session) Paused() bool { return s.paused.Load() }
+
+// Pause stops streaming new batches while keeping the session, its counters and its queue.
+// It reports false if the session was already paused or has ended.
+func (s *Session) Pause() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed || s.paused.Load() {
+		return false
+	}
+	s.paused.Store(true)
+	s.pausedSince = time.Now()
+	return true
+}
+
+// Resume continues streaming after Pause. It reports false if the session was not paused or has ended.
+func (s *Session) Resume() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed || !s.paused.Load() {
+		return false
+	}
+	s.paused.Store(false)
+	s.pausedTotal += time.Since(s.pausedSince)
+	return true
+}
+
+// PausedDuration returns the total time spent paused, including a pause still in progress.
+func (s *Session) PausedDuration() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	total := s.pausedTotal
+	if s.paused.Load() {
+		total += time.Since(s.pausedSince)
+	}
+	return total
+}
@@ -0,0 +1,59 @@
+package capture
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/utrack/otellens/internal/model"
+)
+
+func TestPausedSessionSkipsBatchesWithoutCountingThem(t *testing.T) {
+	registry := NewRegistry(10)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	session, err := registry.Register(ctx, RegisterRequest{
+		Filter:     Filter{Signals: map[model.SignalType]struct{}{model.SignalMetrics: {}}},
+		MaxBatches: 2,
+		BufferSize: 2,
+	})
+	if err != nil {
+		t.Fatalf("register failed: %v", err)
+	}
+
+	if !session.Pause() || session.Pause() {
+		t.Fatal("expected only the first Pause to change state")
+	}
+	for i := 0; i < 5; i++ {
+		registry.PublishMetrics(newMetricsBatch("A"))
+	}
+	time.Sleep(10 * time.Millisecond)
+	if session.SentBatches() != 0 || session.DroppedBatches() != 0 || session.QueueDepth() != 0 {
+		t.Fatalf("expected paused session to skip batches, got sent=%d dropped=%d", session.SentBatches(), session.DroppedBatches())
+	}
+	paused := session.PausedDuration()
+	if paused < 10*time.Millisecond {
+		t.Fatalf("expected ongoing pause to be counted, got %v", paused)
+	}
+
+	if !session.Resume() || session.Resume() {
+		t.Fatal("expected only the first Resume to change state")
+	}
+	registry.PublishMetrics(newMetricsBatch("A"))
+	registry.PublishMetrics(newMetricsBatch("A"))
+
+	received := 0
+	for range session.Events() {
+		received++
+	}
+	if received != 2 {
+		t.Fatalf("expected max_batches to count only batches after resume, got %d", received)
+	}
+	if total := session.PausedDuration(); total < paused {
+		t.Fatalf("expected paused time to be kept after resume, got %v", total)
+	}
+	if session.Pause() {
+		t.Fatal("expected Pause to fail on an ended session")
+	}
+}
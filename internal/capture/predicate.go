@@ -0,0 +1,290 @@
+package capture
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// AttributeLevel selects which attribute map of a record an AttributePredicate inspects.
+type AttributeLevel string
+
+const (
+	// AttributeLevelAny checks every attribute map available for the record.
+	AttributeLevelAny       AttributeLevel = ""
+	AttributeLevelResource  AttributeLevel = "resource"
+	AttributeLevelScope     AttributeLevel = "scope"
+	AttributeLevelDataPoint AttributeLevel = "datapoint"
+	AttributeLevelSpan      AttributeLevel = "span"
+	AttributeLevelEvent     AttributeLevel = "event"
+	AttributeLevelLog       AttributeLevel = "log"
+)
+
+// AttributeOperator is a comparison applied to one attribute value.
+type AttributeOperator string
+
+const (
+	AttributeOpEq       AttributeOperator = "eq"
+	AttributeOpNeq      AttributeOperator = "neq"
+	AttributeOpRegex    AttributeOperator = "regex"
+	AttributeOpIn       AttributeOperator = "in"
+	AttributeOpExists   AttributeOperator = "exists"
+	AttributeOpGt       AttributeOperator = "gt"
+	AttributeOpGte      AttributeOperator = "gte"
+	AttributeOpLt       AttributeOperator = "lt"
+	AttributeOpLte      AttributeOperator = "lte"
+	AttributeOpContains AttributeOperator = "contains"
+)
+
+// AttributePredicate tests an attribute value at a given level.
+// Use NewAttributePredicate to build one; operands are normalized and compiled once.
+type AttributePredicate struct {
+	Key   string
+	Level AttributeLevel
+	Op    AttributeOperator
+
+	str     string
+	set     map[string]struct{}
+	number  float64
+	pattern *regexp.Regexp
+}
+
+// NewAttributePredicate validates and compiles one attribute predicate.
+// value is the operand for all operators except `in` (which uses values) and `exists` (which uses none).
+// Keys may be dotted paths into map-valued attributes, e.g. `http.request.header.x-tenant` or `payload.user.id`.
+func NewAttributePredicate(key string, level AttributeLevel, op AttributeOperator, value interface{}, values []interface{}) (AttributePredicate, error) {
+	if key == "" {
+		return AttributePredicate{}, errors.New("key must be set")
+	}
+	switch level {
+	case AttributeLevelAny, AttributeLevelResource, AttributeLevelScope, AttributeLevelDataPoint,
+		AttributeLevelSpan, AttributeLevelEvent, AttributeLevelLog:
+	default:
+		return AttributePredicate{}, fmt.Errorf("unknown level %q", level)
+	}
+
+	p := AttributePredicate{Key: key, Level: level, Op: op}
+	switch op {
+	case AttributeOpExists:
+	case AttributeOpEq, AttributeOpNeq, AttributeOpContains:
+		if value == nil {
+			return AttributePredicate{}, fmt.Errorf("operator %q requires value", op)
+		}
+		p.str = operandString(value)
+	case AttributeOpRegex:
+		expr, ok := value.(string)
+		if !ok || expr == "" {
+			return AttributePredicate{}, fmt.Errorf("operator %q requires a string value", op)
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return AttributePredicate{}, fmt.Errorf("invalid regular expression %q: %w", expr, err)
+		}
+		p.pattern = re
+	case AttributeOpIn:
+		if len(values) == 0 {
+			return AttributePredicate{}, fmt.Errorf("operator %q requires values", op)
+		}
+		p.set = make(map[string]struct{}, len(values))
+		for _, item := range values {
+			p.set[operandString(item)] = struct{}{}
+		}
+	case AttributeOpGt, AttributeOpGte, AttributeOpLt, AttributeOpLte:
+		number, ok := operandNumber(value)
+		if !ok {
+			return AttributePredicate{}, fmt.Errorf("operator %q requires a numeric value", op)
+		}
+		p.number = number
+	default:
+		return AttributePredicate{}, fmt.Errorf("unknown operator %q", op)
+	}
+
+	return p, nil
+}
+
+// appliesTo reports whether the predicate should inspect attributes at level.
+func (p AttributePredicate) appliesTo(level AttributeLevel) bool {
+	return p.Level == AttributeLevelAny || p.Level == level
+}
+
+// relevantFor reports whether the predicate can be evaluated against a record whose own attributes live at recordLevel.
+// Predicates bound to another signal's record level are ignored, like metric_names is ignored for traces.
+func (p AttributePredicate) relevantFor(recordLevel AttributeLevel) bool {
+	switch p.Level {
+	case AttributeLevelAny, AttributeLevelResource, AttributeLevelScope:
+		return true
+	case AttributeLevelEvent:
+		return recordLevel == AttributeLevelSpan
+	default:
+		return p.Level == recordLevel
+	}
+}
+
+func (p AttributePredicate) matchMap(attrs pcommon.Map) bool {
+	value, ok := lookupAttribute(attrs, p.Key)
+	if !ok {
+		return false
+	}
+	return p.matchValue(value)
+}
+
+func (p AttributePredicate) matchValue(value pcommon.Value) bool {
+	switch p.Op {
+	case AttributeOpExists:
+		return true
+	case AttributeOpEq:
+		return value.AsString() == p.str
+	case AttributeOpNeq:
+		return value.AsString() != p.str
+	case AttributeOpRegex:
+		return p.pattern.MatchString(value.AsString())
+	case AttributeOpIn:
+		_, ok := p.set[value.AsString()]
+		return ok
+	case AttributeOpContains:
+		if value.Type() == pcommon.ValueTypeSlice {
+			slice := value.Slice()
+			for i := 0; i < slice.Len(); i++ {
+				if slice.At(i).AsString() == p.str {
+					return true
+				}
+			}
+			return false
+		}
+		return value.Type() == pcommon.ValueTypeStr && strings.Contains(value.Str(), p.str)
+	case AttributeOpGt, AttributeOpGte, AttributeOpLt, AttributeOpLte:
+		number, ok := valueNumber(value)
+		if !ok {
+			return false
+		}
+		switch p.Op {
+		case AttributeOpGt:
+			return number > p.number
+		case AttributeOpGte:
+			return number >= p.number
+		case AttributeOpLt:
+			return number < p.number
+		default:
+			return number <= p.number
+		}
+	}
+	return false
+}
+
+// recordAttributes carries every attribute map visible to one record candidate.
+type recordAttributes struct {
+	resource pcommon.Map
+	scope    pcommon.Map
+	level    AttributeLevel
+	record   pcommon.Map
+	events   ptrace.SpanEventSlice
+	// hasEvents is false for records without span events (datapoints and logs).
+	hasEvents bool
+}
+
+// matchAttributePredicates reports whether every relevant predicate holds for one record candidate.
+func matchAttributePredicates(predicates []AttributePredicate, attrs recordAttributes) bool {
+	for _, p := range predicates {
+		if !p.relevantFor(attrs.level) {
+			continue
+		}
+		if !p.matchRecord(attrs) {
+			return false
+		}
+	}
+	return true
+}
+
+func (p AttributePredicate) matchRecord(attrs recordAttributes) bool {
+	if p.appliesTo(AttributeLevelResource) && p.matchMap(attrs.resource) {
+		return true
+	}
+	if p.appliesTo(AttributeLevelScope) && p.matchMap(attrs.scope) {
+		return true
+	}
+	if p.appliesTo(attrs.level) && p.matchMap(attrs.record) {
+		return true
+	}
+	if attrs.hasEvents && p.appliesTo(AttributeLevelEvent) {
+		for i := 0; i < attrs.events.Len(); i++ {
+			if p.matchMap(attrs.events.At(i).Attributes()) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// lookupAttribute resolves key in attrs. A literal key wins; otherwise dotted segments
+// descend into map-valued attributes, trying every split since OTel keys contain dots themselves.
+func lookupAttribute(attrs pcommon.Map, key string) (pcommon.Value, bool) {
+	if value, ok := attrs.Get(key); ok {
+		return value, true
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] != '.' {
+			continue
+		}
+		parent, ok := attrs.Get(key[:i])
+		if !ok || parent.Type() != pcommon.ValueTypeMap {
+			continue
+		}
+		if value, ok := lookupAttribute(parent.Map(), key[i+1:]); ok {
+			return value, true
+		}
+	}
+	return pcommon.NewValueEmpty(), false
+}
+
+func valueNumber(value pcommon.Value) (float64, bool) {
+	switch value.Type() {
+	case pcommon.ValueTypeInt:
+		return float64(value.Int()), true
+	case pcommon.ValueTypeDouble:
+		return value.Double(), true
+	case pcommon.ValueTypeStr:
+		number, err := strconv.ParseFloat(value.Str(), 64)
+		return number, err == nil
+	default:
+		return 0, false
+	}
+}
+
+func operandNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case string:
+		number, err := strconv.ParseFloat(v, 64)
+		return number, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// operandString renders a decoded JSON operand the same way pcommon.Value.AsString renders attributes.
+func operandString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return pcommon.NewValueDouble(v).AsString()
+	case int:
+		return strconv.Itoa(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
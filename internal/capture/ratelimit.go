@@ -0,0 +1,143 @@
+package capture
+
+import (
+	"time"
+
+	"github.com/utrack/otellens/internal/model"
+)
+
+// rateLimitNoticeInterval is the minimum time between two rate_limited markers of a session.
+const rateLimitNoticeInterval = 5 * time.Second
+
+// RateLimits caps how fast a session streams envelopes; zero fields are unlimited.
+// Each limit is a token bucket that holds one second worth of tokens.
+type RateLimits struct {
+	BatchesPerSecond float64
+	RecordsPerSecond float64
+	// BytesPerSecond limits the estimated encoded size of the payloads, the estimate max_bytes uses.
+	BytesPerSecond float64
+}
+
+// enabled reports whether any limit is set.
+func (l RateLimits) enabled() bool {
+	return l.BatchesPerSecond > 0 || l.RecordsPerSecond > 0 || l.BytesPerSecond > 0
+}
+
+// tokenBucket refills at rate tokens per second up to rate tokens. A take may overdraw it,
+// so an envelope larger than the burst still passes once the bucket is full, and the debt
+// delays the following ones.
+type tokenBucket struct {
+	rate   float64
+	tokens float64
+	last   time.Time
+}
+
+// newTokenBucket returns a full bucket, or nil for an unlimited rate.
+func newTokenBucket(rate float64, now time.Time) *tokenBucket {
+	if rate <= 0 {
+		return nil
+	}
+	return &tokenBucket{rate: rate, tokens: rate, last: now}
+}
+
+// allows reports whether n tokens may be taken at now.
+func (b *tokenBucket) allows(n float64, now time.Time) bool {
+	if b == nil {
+		return true
+	}
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens = min(b.rate, b.tokens+elapsed*b.rate)
+		b.last = now
+	}
+	return b.tokens >= min(n, b.rate)
+}
+
+func (b *tokenBucket) take(n float64) {
+	if b != nil {
+		b.tokens -= n
+	}
+}
+
+// rateLimiter applies a session's RateLimits; it is guarded by the session mutex.
+type rateLimiter struct {
+	batches *tokenBucket
+	records *tokenBucket
+	bytes   *tokenBucket
+
+	// suppressed accumulates what was suppressed since lastNotice.
+	suppressed model.RateLimited
+	lastNotice time.Time
+}
+
+// newRateLimiter returns nil if limits has no limit set.
+func newRateLimiter(limits RateLimits, now time.Time) *rateLimiter {
+	if !limits.enabled() {
+		return nil
+	}
+	return &rateLimiter{
+		batches: newTokenBucket(limits.BatchesPerSecond, now),
+		records: newTokenBucket(limits.RecordsPerSecond, now),
+		bytes:   newTokenBucket(limits.BytesPerSecond, now),
+	}
+}
+
+// allows reports whether an envelope of records and size fits all limits. Nothing is taken,
+// so an envelope dropped by backpressure afterwards does not count against the limits.
+func (l *rateLimiter) allows(records, size int, now time.Time) bool {
+	// All buckets are refilled, so none of them is left behind by a short-circuit.
+	batchesOK := l.batches.allows(1, now)
+	recordsOK := l.records.allows(float64(records), now)
+	bytesOK := l.bytes.allows(float64(size), now)
+	return batchesOK && recordsOK && bytesOK
+}
+
+func (l *rateLimiter) take(records, size int) {
+	l.batches.take(1)
+	l.records.take(float64(records))
+	l.bytes.take(float64(size))
+}
+
+// suppress counts a suppressed envelope.
+func (l *rateLimiter) suppress(records, size int) {
+	l.suppressed.Batches++
+	l.suppressed.Records += uint64(records)
+	l.suppressed.Bytes += uint64(size)
+}
+
+// notice returns the suppressed counts to report and resets them, once rateLimitNoticeInterval
+// passed since the previous notice; the first suppression is reported right away.
+func (l *rateLimiter) notice(now time.Time) (model.RateLimited, bool) {
+	if l.suppressed.Batches == 0 || !l.lastNotice.IsZero() && now.Sub(l.lastNotice) < rateLimitNoticeInterval {
+		return model.RateLimited{}, false
+	}
+	out := l.suppressed
+	l.suppressed = model.RateLimited{}
+	l.lastNotice = now
+	return out, true
+}
+
+// RateLimitedBatches returns the number of batches suppressed by the session's rate limits.
+func (s *Session) RateLimitedBatches() uint64 { return s.rateLimitedBatches.Load() }
+
+// RateLimitedRecords returns the number of records in the batches suppressed by the rate limits.
+func (s *Session) RateLimitedRecords() uint64 { return s.rateLimitedRecords.Load() }
+
+// rateLimitLocked applies the rate limits to an envelope about to be queued. It queues a
+// rate_limited marker when one is due and reports whether the envelope may be queued.
+func (s *Session) rateLimitLocked(envelope model.Envelope, size int, now time.Time) bool {
+	ok := s.limiter.allows(envelope.Records, size, now)
+	if !ok {
+		s.limiter.suppress(envelope.Records, size)
+		s.rateLimitedBatches.Add(1)
+		s.rateLimitedRecords.Add(uint64(envelope.Records))
+	}
+	if suppressed, due := s.limiter.notice(now); due {
+		s.queueMarkerLocked(model.Envelope{
+			Type:       model.EnvelopeTypeRateLimited,
+			SessionID:  s.id,
+			CapturedAt: now.UTC(),
+			Payload:    &suppressed,
+		})
+	}
+	return ok
+}
@@ -0,0 +1,91 @@
+package capture
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/utrack/otellens/internal/model"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+)
+
+func TestTokenBucketAllowsBurstAndCarriesDebt(t *testing.T) {
+	now := time.Now()
+	bucket := newTokenBucket(10, now)
+
+	if !bucket.allows(25, now) {
+		t.Fatal("expected an envelope larger than the burst to pass a full bucket")
+	}
+	bucket.take(25)
+	if bucket.allows(1, now.Add(time.Second)) {
+		t.Fatal("expected the debt to hold off envelopes after one second")
+	}
+	if !bucket.allows(10, now.Add(2500*time.Millisecond)) {
+		t.Fatal("expected the bucket to refill after the debt is paid")
+	}
+	if newTokenBucket(0, now) != nil {
+		t.Fatal("expected no bucket for an unlimited rate")
+	}
+}
+
+func TestRateLimitedBatchesAreCountedApartFromDrops(t *testing.T) {
+	registry := NewRegistry(10)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	session, err := registry.Register(ctx, RegisterRequest{
+		RateLimits: RateLimits{BatchesPerSecond: 2},
+		MaxBatches: 10,
+		BufferSize: 10,
+	})
+	if err != nil {
+		t.Fatalf("register failed: %v", err)
+	}
+	for i := 0; i < 5; i++ {
+		registry.PublishMetrics(newMetricsBatch("A"))
+	}
+	registry.Deregister(session.ID())
+
+	var events []model.Envelope
+	for event := range session.Events() {
+		events = append(events, event)
+	}
+	if len(events) != 3 || events[0].Records != 1 || events[1].Type != "" {
+		t.Fatalf("expected 2 envelopes followed by one notice, got %+v", events)
+	}
+	notice := events[2]
+	if notice.Type != model.EnvelopeTypeRateLimited || notice.BatchIndex != 3 {
+		t.Fatalf("expected a rate_limited notice after the envelopes, got %+v", notice)
+	}
+	if suppressed := notice.Payload.(*model.RateLimited); suppressed.Batches != 1 || suppressed.Records != 1 {
+		t.Fatalf("expected the notice to report the first suppressed batch, got %+v", suppressed)
+	}
+	if session.RateLimitedBatches() != 3 || session.RateLimitedRecords() != 3 || session.DroppedBatches() != 0 || session.SentBatches() != 2 {
+		t.Fatalf("expected 3 rate-limited batches and no drops, got %d rate-limited, %d dropped, %d sent",
+			session.RateLimitedBatches(), session.DroppedBatches(), session.SentBatches())
+	}
+}
+
+func TestByteRateLimitUsesTheBudgetEstimate(t *testing.T) {
+	registry := NewRegistry(10)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	md := newMetricsBatch("A")
+	size := (&pmetric.ProtoMarshaler{}).MetricsSize(md)
+	session, err := registry.Register(ctx, RegisterRequest{
+		RateLimits: RateLimits{BytesPerSecond: float64(size)},
+		MaxBatches: 10,
+		BufferSize: 10,
+	})
+	if err != nil {
+		t.Fatalf("register failed: %v", err)
+	}
+	registry.PublishMetrics(md)
+	registry.PublishMetrics(md)
+
+	if session.SentBatches() != 1 || session.SentBytes() != uint64(size) || session.RateLimitedBatches() != 1 {
+		t.Fatalf("expected one batch of %d bytes and one rate-limited, got %d sent, %d bytes, %d rate-limited",
+			size, session.SentBatches(), session.SentBytes(), session.RateLimitedBatches())
+	}
+}
@@ -0,0 +1,298 @@
+package capture
+
+import (
+	"sort"
+	"sync"
+	"time"
+	"unsafe"
+
+	"github.com/utrack/otellens/internal/model"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// FlightRecorderConfig bounds the recent batches kept for sessions that ask for history.
+type FlightRecorderConfig struct {
+	// MaxBytes bounds the memory held by the batches of each signal. The bound is approximate: it
+	// counts the encoded batches and their ring slots, not the spare capacity of the ring.
+	MaxBytes int64
+	// MaxAge is how long a batch is kept.
+	MaxAge time.Duration
+}
+
+// recordedBatchOverhead is accounted per recorded batch on top of its encoded size: the ring slot
+// holding it, including the slice header of the data.
+const recordedBatchOverhead = int64(unsafe.Sizeof(recordedBatch{}))
+
+// RegistryOption configures optional registry features.
+type RegistryOption func(*Registry)
+
+// WithFlightRecorder keeps recent batches of every signal, even without active sessions, so new
+// sessions can replay them via RegisterRequest.IncludeHistory. A zero MaxBytes or MaxAge disables it.
+func WithFlightRecorder(cfg FlightRecorderConfig) RegistryOption {
+	return func(r *Registry) {
+		if cfg.MaxBytes <= 0 || cfg.MaxAge <= 0 {
+			return
+		}
+		r.recorder = newFlightRecorder(cfg)
+	}
+}
+
+// recordedBatch is one batch kept as binary OTLP: it is immutable, independent of the pipeline's
+// pdata lifecycle, and its size is known exactly.
+type recordedBatch struct {
+	seq        uint64
+	signal     model.SignalType
+	recordedAt time.Time
+	data       []byte
+}
+
+func (b recordedBatch) size() int64 { return int64(len(b.data)) + recordedBatchOverhead }
+
+// batchRing holds the recorded batches of one signal, oldest first.
+type batchRing struct {
+	bytes   int64
+	batches []recordedBatch
+}
+
+func (r *batchRing) evictOldest() {
+	r.bytes -= r.batches[0].size()
+	// Clear the slot so the evicted data is not retained by the backing array.
+	r.batches[0] = recordedBatch{}
+	r.batches = r.batches[1:]
+}
+
+// flightRecorder is a per-signal ring of recent batches bounded by bytes and age.
+type flightRecorder struct {
+	maxBytes int64
+	maxAge   time.Duration
+
+	mu    sync.Mutex
+	seq   uint64
+	rings map[model.SignalType]*batchRing
+}
+
+func newFlightRecorder(cfg FlightRecorderConfig) *flightRecorder {
+	return &flightRecorder{
+		maxBytes: cfg.MaxBytes,
+		maxAge:   cfg.MaxAge,
+		rings: map[model.SignalType]*batchRing{
+			model.SignalMetrics: {},
+			model.SignalTraces:  {},
+			model.SignalLogs:    {},
+		},
+	}
+}
+
+// push records one encoded batch, evicting the oldest ones to stay within the bounds.
+// Batches larger than the whole budget are not recorded.
+func (f *flightRecorder) push(signal model.SignalType, data []byte, now time.Time) {
+	batch := recordedBatch{signal: signal, recordedAt: now, data: data}
+	if batch.size() > f.maxBytes {
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	ring := f.rings[signal]
+	f.expireLocked(ring, now)
+	for ring.bytes+batch.size() > f.maxBytes {
+		ring.evictOldest()
+	}
+	f.seq++
+	batch.seq = f.seq
+	ring.batches = append(ring.batches, batch)
+	ring.bytes += batch.size()
+}
+
+func (f *flightRecorder) expireLocked(ring *batchRing, now time.Time) {
+	cutoff := now.Add(-f.maxAge)
+	for len(ring.batches) > 0 && ring.batches[0].recordedAt.Before(cutoff) {
+		ring.evictOldest()
+	}
+}
+
+// since returns the batches of all signals recorded at or after from with a sequence number above
+// afterSeq, in recording order, and the highest sequence number recorded so far.
+func (f *flightRecorder) since(from time.Time, afterSeq uint64) ([]recordedBatch, uint64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	now := time.Now()
+	var out []recordedBatch
+	for _, ring := range f.rings {
+		f.expireLocked(ring, now)
+		for _, batch := range ring.batches {
+			if batch.seq > afterSeq && !batch.recordedAt.Before(from) {
+				out = append(out, batch)
+			}
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].seq < out[j].seq })
+	return out, f.seq
+}
+
+// bytes returns the memory currently accounted to recorded batches of signal.
+func (f *flightRecorder) bytes(signal model.SignalType) int64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.rings[signal].bytes
+}
+
+// historyEnvelope is a replayed envelope with its estimated payload size, see batchSizer.
+type historyEnvelope struct {
+	model.Envelope
+	size int
+}
+
+// historyEnvelopes builds the envelopes a session would have received for the recorded batches.
+// Envelopes keep the recording time as CapturedAt and are marked as history.
+func historyEnvelopes(session *Session, settings *sessionSettings, batches []recordedBatch) []historyEnvelope {
+	var envelopes []historyEnvelope
+	for _, batch := range batches {
+		if !settings.filter.acceptsSignal(batch.signal) {
+			continue
+		}
+		payload, records, size, ok := recordedPayload(session, settings, batch)
+		if !ok {
+			continue
+		}
+		envelopes = append(envelopes, historyEnvelope{
+			Envelope: model.Envelope{
+				SessionID:  session.ID(),
+				Signal:     batch.signal,
+				CapturedAt: batch.recordedAt.UTC(),
+				History:    true,
+				Records:    records,
+				Sampling:   settings.samplingInfo(),
+				Payload:    payload,
+			},
+			size: size,
+		})
+	}
+	return envelopes
+}
+
+// recordedPayload builds the session's payload for a recorded batch and estimates its size from
+// the recorded OTLP bytes.
+func recordedPayload(session *Session, settings *sessionSettings, batch recordedBatch) (interface{}, int, int, bool) {
+	var (
+		payload interface{}
+		records int
+		ok      bool
+		sizer   *batchSizer
+	)
+	otlpSize := func() int { return len(batch.data) }
+	switch batch.signal {
+	case model.SignalMetrics:
+		md, err := (&pmetric.ProtoUnmarshaler{}).UnmarshalMetrics(batch.data)
+		if err != nil {
+			return nil, 0, 0, false
+		}
+		payload, records, ok = sessionMetricsPayload(session, settings, md)
+		sizer = newBatchSizer(md.MetricCount(), otlpSize)
+	case model.SignalTraces:
+		td, err := (&ptrace.ProtoUnmarshaler{}).UnmarshalTraces(batch.data)
+		if err != nil {
+			return nil, 0, 0, false
+		}
+		payload, records, ok = sessionTracesPayload(session, settings, td)
+		sizer = newBatchSizer(td.SpanCount(), otlpSize)
+	case model.SignalLogs:
+		ld, err := (&plog.ProtoUnmarshaler{}).UnmarshalLogs(batch.data)
+		if err != nil {
+			return nil, 0, 0, false
+		}
+		payload, records, ok = sessionLogsPayload(session, settings, ld)
+		sizer = newBatchSizer(ld.LogRecordCount(), otlpSize)
+	}
+	if !ok {
+		return nil, 0, 0, false
+	}
+	return payload, records, sizer.estimate(session, payload, records), true
+}
+
+// recordMetrics records md and snapshots the sessions under the same read lock, so each batch is
+// either part of the history a registering session replays or delivered to it live, never both.
+func (r *Registry) recordMetrics(md pmetric.Metrics) []*Session {
+	data, err := (&pmetric.ProtoMarshaler{}).MarshalMetrics(md)
+	if err != nil {
+		return r.snapshotSessions()
+	}
+	return r.recordAndSnapshot(model.SignalMetrics, data)
+}
+
+func (r *Registry) recordTraces(td ptrace.Traces) []*Session {
+	data, err := (&ptrace.ProtoMarshaler{}).MarshalTraces(td)
+	if err != nil {
+		return r.snapshotSessions()
+	}
+	return r.recordAndSnapshot(model.SignalTraces, data)
+}
+
+func (r *Registry) recordLogs(ld plog.Logs) []*Session {
+	data, err := (&plog.ProtoMarshaler{}).MarshalLogs(ld)
+	if err != nil {
+		return r.snapshotSessions()
+	}
+	return r.recordAndSnapshot(model.SignalLogs, data)
+}
+
+func (r *Registry) recordAndSnapshot(signal model.SignalType, data []byte) []*Session {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	r.recorder.push(signal, data, time.Now())
+	sessions := make([]*Session, 0, len(r.sessions))
+	for _, session := range r.sessions {
+		sessions = append(sessions, session)
+	}
+	return sessions
+}
+
+// FlightRecorderEnabled reports whether sessions can ask for recorded history.
+func (r *Registry) FlightRecorderEnabled() bool { return r.recorder != nil }
+
+// historyMatchPasses bounds how often lockAfterHistory retries matching outside the lock.
+const historyMatchPasses = 3
+
+// lockAfterHistory returns the session's envelopes for the batches recorded since from, with r.mu
+// locked once no batch was recorded after the last match. Batches published from then on reach the
+// session live, so replayed and live batches neither overlap nor leave a gap. Batches are decoded
+// and filtered outside the lock; only those recorded during the last pass are matched under it.
+func (r *Registry) lockAfterHistory(session *Session, from time.Time) []historyEnvelope {
+	settings := session.settings.Load()
+	batches, seq := r.recorder.since(from, 0)
+	var history []historyEnvelope
+	for pass := 1; ; pass++ {
+		history = append(history, historyEnvelopes(session, settings, batches)...)
+		r.mu.Lock()
+		batches, seq = r.recorder.since(from, seq)
+		if len(batches) == 0 {
+			return history
+		}
+		if pass == historyMatchPasses {
+			return append(history, historyEnvelopes(session, settings, batches)...)
+		}
+		r.mu.Unlock()
+	}
+}
+
+// emitHistory queues replayed envelopes ahead of live ones. Only the most recent envelopes that fit
+// into the queue are kept; older ones count as dropped.
+func (s *Session) emitHistory(envelopes []historyEnvelope) {
+	if free := s.QueueCapacity() - s.QueueDepth(); len(envelopes) > free {
+		for _, envelope := range envelopes[:len(envelopes)-free] {
+			s.countMatched(envelope.Envelope)
+			s.countDropped(envelope.Envelope)
+		}
+		envelopes = envelopes[len(envelopes)-free:]
+	}
+	for _, envelope := range envelopes {
+		if _, completed := s.emit(envelope.Envelope, nil, envelope.size); completed {
+			return
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package capture
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/utrack/otellens/internal/model"
+)
+
+func TestFlightRecorderBoundsBytesAndAge(t *testing.T) {
+	recorder := newFlightRecorder(FlightRecorderConfig{MaxBytes: 3 * (100 + recordedBatchOverhead), MaxAge: time.Minute})
+	now := time.Now()
+
+	for i := 0; i < 5; i++ {
+		recorder.push(model.SignalLogs, make([]byte, 100), now)
+	}
+	recorder.push(model.SignalMetrics, make([]byte, 100), now)
+	if got := recorder.bytes(model.SignalLogs); got != 3*(100+recordedBatchOverhead) {
+		t.Fatalf("expected logs to be bounded to 3 batches, got %d bytes", got)
+	}
+	batches, seq := recorder.since(time.Time{}, 0)
+	if len(batches) != 4 || seq != 6 || batches[0].seq != 3 || batches[3].signal != model.SignalMetrics {
+		t.Fatalf("expected the 3 newest logs batches and the metrics batch in order, got %d batches up to %d", len(batches), seq)
+	}
+
+	recorder.push(model.SignalLogs, make([]byte, 500), now)
+	if got := recorder.bytes(model.SignalLogs); got != 3*(100+recordedBatchOverhead) {
+		t.Fatalf("expected a batch larger than the budget to be skipped, got %d bytes", got)
+	}
+
+	recorder.push(model.SignalLogs, make([]byte, 10), now.Add(2*time.Minute))
+	if got := recorder.bytes(model.SignalLogs); got != 10+recordedBatchOverhead {
+		t.Fatalf("expected expired batches to be evicted, got %d bytes", got)
+	}
+}
+
+func TestRegistryReplaysRecordedHistoryBeforeLiveBatches(t *testing.T) {
+	registry := NewRegistry(10, WithFlightRecorder(FlightRecorderConfig{MaxBytes: 1 << 20, MaxAge: time.Minute}))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	registry.PublishMetrics(newMetricsBatch("A"))
+	registry.PublishMetrics(newMetricsBatch("B"))
+	registry.PublishMetrics(newMetricsBatch("A"))
+
+	filter := Filter{
+		Signals:     map[model.SignalType]struct{}{model.SignalMetrics: {}},
+		MetricNames: map[string]struct{}{"A": {}},
+	}
+	session, err := registry.Register(ctx, RegisterRequest{Filter: filter, MaxBatches: 3, BufferSize: 3, IncludeHistory: time.Minute})
+	if err != nil {
+		t.Fatalf("register failed: %v", err)
+	}
+	live, err := registry.Register(ctx, RegisterRequest{Filter: filter, MaxBatches: 1, BufferSize: 1})
+	if err != nil {
+		t.Fatalf("register failed: %v", err)
+	}
+	registry.PublishMetrics(newMetricsBatch("A"))
+
+	var events []model.Envelope
+	for event := range session.Events() {
+		events = append(events, event)
+	}
+	if len(events) != 3 || !events[0].History || !events[1].History || events[2].History {
+		t.Fatalf("expected 2 history envelopes followed by 1 live envelope, got %+v", events)
+	}
+	for i, event := range events {
+		if event.BatchIndex != uint64(i+1) || event.Payload.(*model.MetricsPayload).Metrics[0].Name != "A" {
+			t.Fatalf("unexpected envelope %d: %+v", i, event)
+		}
+	}
+	if first := <-live.Events(); first.History {
+		t.Fatal("expected a session without include_history to start live")
+	}
+}
+
+func TestRegistryHistoryKeepsNewestEnvelopesThatFit(t *testing.T) {
+	registry := NewRegistry(10, WithFlightRecorder(FlightRecorderConfig{MaxBytes: 1 << 20, MaxAge: time.Minute}))
+	for _, name := range []string{"A", "B", "C"} {
+		registry.PublishMetrics(newMetricsBatch(name))
+	}
+
+	session, err := registry.Register(context.Background(), RegisterRequest{MaxBatches: 10, BufferSize: 2, IncludeHistory: time.Minute})
+	if err != nil {
+		t.Fatalf("register failed: %v", err)
+	}
+	defer registry.Deregister(session.ID())
+
+	if session.DroppedBatches() != 1 || session.QueueDepth() != 2 {
+		t.Fatalf("expected the oldest batch dropped and 2 queued, got %d dropped, %d queued", session.DroppedBatches(), session.QueueDepth())
+	}
+	if first := <-session.Events(); first.Payload.(*model.MetricsPayload).Metrics[0].Name != "B" {
+		t.Fatalf("expected replay to start at B, got %+v", first)
+	}
+}
+
+func TestRegistryHistoryHandsOffToLiveBatchesWhilePublishing(t *testing.T) {
+	registry := NewRegistry(10, WithFlightRecorder(FlightRecorderConfig{MaxBytes: 1 << 20, MaxAge: time.Minute}))
+	const batches = 200
+
+	published := make(chan struct{})
+	registered := make(chan struct{})
+	go func() {
+		defer close(published)
+		for i := 0; i < batches; i++ {
+			if i == batches/4 {
+				close(registered)
+			}
+			registry.PublishMetrics(newMetricsBatch(strconv.Itoa(i)))
+		}
+	}()
+
+	<-registered
+	session, err := registry.Register(context.Background(), RegisterRequest{MaxBatches: batches, BufferSize: batches, IncludeHistory: time.Minute})
+	if err != nil {
+		t.Fatalf("register failed: %v", err)
+	}
+	<-published
+	registry.Deregister(session.ID())
+
+	var i int
+	for event := range session.Events() {
+		if name := event.Payload.(*model.MetricsPayload).Metrics[0].Name; name != strconv.Itoa(i) {
+			t.Fatalf("expected batch %d exactly once and in order, got %s", i, name)
+		}
+		i++
+	}
+	if i != batches {
+		t.Fatalf("expected all %d batches between history and live envelopes, got %d", batches, i)
+	}
+}
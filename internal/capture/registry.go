@@ -3,6 +3,7 @@ package capture
 import (
 	"context"
 	"errors"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -16,12 +17,53 @@ import (
 
 var ErrSessionLimitReached = errors.New("session limit reached")
 
+// SessionInfo is descriptive metadata shown by session listings; it does not affect matching.
+type SessionInfo struct {
+	Label      string
+	RemoteAddr string
+	// Deadline is when the session times out; zero means no deadline.
+	Deadline time.Time
+	// Request is the client's original session definition, echoed back as-is.
+	Request interface{}
+}
+
 // RegisterRequest defines runtime knobs for creating a session.
 type RegisterRequest struct {
 	Filter         Filter
 	VerboseMetrics bool
-	MaxBatches     int
-	BufferSize     int
+	VerboseTraces  bool
+	// VerboseLogs adds the full record projection to logs payloads.
+	VerboseLogs bool
+	// Format selects the payload encoding; empty means model.FormatOtellens.
+	Format model.OutputFormat
+	// MaxLogRecords caps projected log records per envelope; 0 uses model.DefaultMaxLogRecords.
+	MaxLogRecords int
+	// SampleRate keeps this fraction of matching records: spans and logs by trace ID, so sampled traces
+	// stay complete, and metrics by name and resource attributes. 0 (unset) and 1 keep every record.
+	// Trigger filters are not sampled.
+	SampleRate float64
+	// SampleEveryN keeps every Nth batch that matched; 0 and 1 keep all. Skipped batches are not dropped.
+	SampleEveryN int
+	MaxBatches   int
+	// MaxRecords and MaxBytes end the session once the streamed batches hold this many records or
+	// this much estimated payload size; 0 is unlimited. The batch that exhausts a budget is streamed.
+	MaxRecords int
+	MaxBytes   int64
+	BufferSize int
+	Info       SessionInfo
+	// DetachGrace makes the session outlive its streams: it starts detached and is removed only
+	// when no stream has been attached for this long (or on timeout/max_batches).
+	// Detachable sessions retain their last BufferSize envelopes so a reattaching stream can resume.
+	DetachGrace time.Duration
+	// Trigger arms the session; it captures nothing until the trigger fires.
+	Trigger *Trigger
+	// RateLimits caps the envelopes streamed per second; suppressed batches are counted
+	// apart from backpressure drops. Replayed history is not limited.
+	RateLimits RateLimits
+	// IncludeHistory replays the matching batches the flight recorder kept from this long ago
+	// before live batches; it has no effect on armed sessions or unless the registry was created
+	// WithFlightRecorder.
+	IncludeHistory time.Duration
 }
 
 // Registry stores active capture sessions and routes matching telemetry batches.
@@ -32,20 +74,27 @@ type Registry struct {
 	sessions map[string]*Session
 
 	hasActive atomic.Bool
+
+	// recorder keeps recent batches for IncludeHistory; nil when the flight recorder is disabled.
+	recorder *flightRecorder
 }
 
 // NewRegistry creates a registry with a hard cap on active sessions.
-func NewRegistry(maxSessions int) *Registry {
+func NewRegistry(maxSessions int, opts ...RegistryOption) *Registry {
 	if maxSessions <= 0 {
 		maxSessions = 128
 	}
-	return &Registry{
+	registry := &Registry{
 		maxSessions: maxSessions,
 		sessions:    make(map[string]*Session),
 	}
+	for _, opt := range opts {
+		opt(registry)
+	}
+	return registry
 }
 
-func buildMatchingMetricsPayload(filter Filter, verboseMetrics bool, md pmetric.Metrics) (model.MetricsPayload, bool) {
+func buildMatchingMetricsPayload(matcher recordMatcher, verboseMetrics bool, md pmetric.Metrics) (model.MetricsPayload, bool) {
 	payload := model.MetricsPayload{Metrics: make([]model.Metric, 0)}
 
 	rms := md.ResourceMetrics()
@@ -56,14 +105,19 @@ func buildMatchingMetricsPayload(filter Filter, verboseMetrics bool, md pmetric.
 		sms := rm.ScopeMetrics()
 		for j := 0; j < sms.Len(); j++ {
 			sm := sms.At(j)
+			scopeMatched := false
 			metrics := sm.Metrics()
 			for k := 0; k < metrics.Len(); k++ {
 				metric := metrics.At(k)
-				if !filter.MatchMetric(rm.Resource().Attributes(), sm.Scope().Attributes(), metric) {
+				if !matcher.MatchMetric(rm.Resource().Attributes(), sm.Scope(), metric) {
 					continue
 				}
 				payload.Metrics = append(payload.Metrics, model.BuildMetric(rm.Resource().Attributes(), sm.Scope(), metric, verboseMetrics))
 				payload.MetricCount++
+				scopeMatched = true
+			}
+			if scopeMatched {
+				payload.ScopeMetrics++
 				resourceMatched = true
 			}
 		}
@@ -80,6 +134,89 @@ func buildMatchingMetricsPayload(filter Filter, verboseMetrics bool, md pmetric.
 	return payload, true
 }
 
+func buildMatchingTracesPayload(matcher recordMatcher, verboseTraces bool, td ptrace.Traces) (model.TracesPayload, bool) {
+	payload := model.TracesPayload{SpanNames: make([]string, 0)}
+	seen := make(map[string]struct{})
+
+	rss := td.ResourceSpans()
+	for i := 0; i < rss.Len(); i++ {
+		rs := rss.At(i)
+		resourceMatched := false
+
+		ilss := rs.ScopeSpans()
+		for j := 0; j < ilss.Len(); j++ {
+			ss := ilss.At(j)
+			scopeMatched := false
+			spans := ss.Spans()
+			for k := 0; k < spans.Len(); k++ {
+				span := spans.At(k)
+				if !matcher.MatchSpan(rs.Resource().Attributes(), ss.Scope(), span) {
+					continue
+				}
+				payload.AddSpanName(span.Name(), seen)
+				if verboseTraces {
+					payload.Spans = append(payload.Spans, model.BuildSpan(rs.Resource().Attributes(), ss.Scope(), span))
+				}
+				payload.SpanCount++
+				scopeMatched = true
+			}
+			if scopeMatched {
+				payload.ScopeSpans++
+				resourceMatched = true
+			}
+		}
+
+		if resourceMatched {
+			payload.ResourceSpans++
+		}
+	}
+
+	if payload.SpanCount == 0 {
+		return model.TracesPayload{}, false
+	}
+
+	return payload, true
+}
+
+func buildMatchingLogsPayload(matcher recordMatcher, maxLogRecords int, verboseLogs bool, ld plog.Logs) (model.LogsPayload, bool) {
+	payload := model.LogsPayload{Bodies: make([]string, 0)}
+
+	rls := ld.ResourceLogs()
+	for i := 0; i < rls.Len(); i++ {
+		rl := rls.At(i)
+		resourceMatched := false
+
+		sls := rl.ScopeLogs()
+		for j := 0; j < sls.Len(); j++ {
+			sl := sls.At(j)
+			scopeMatched := false
+			logs := sl.LogRecords()
+			for k := 0; k < logs.Len(); k++ {
+				record := logs.At(k)
+				if !matcher.MatchLogRecord(rl.Resource().Attributes(), sl.Scope(), record) {
+					continue
+				}
+				payload.AddRecord(rl.Resource().Attributes(), sl.Scope(), record, maxLogRecords, verboseLogs)
+				scopeMatched = true
+			}
+			if scopeMatched {
+				payload.ScopeLogs++
+				resourceMatched = true
+			}
+		}
+
+		if resourceMatched {
+			payload.ResourceLogs++
+		}
+	}
+
+	if payload.LogCount == 0 {
+		return model.LogsPayload{}, false
+	}
+
+	return payload, true
+}
+
 // HasActiveSessions returns true if at least one filter is currently registered.
 func (r *Registry) HasActiveSessions() bool {
 	return r.hasActive.Load()
@@ -91,65 +228,102 @@ func (r *Registry) Register(ctx context.Context, req RegisterRequest) (*Session,
 		req.MaxBatches = 1
 	}
 
-	r.mu.Lock()
+	if deadline, ok := ctx.Deadline(); ok && req.Info.Deadline.IsZero() {
+		req.Info.Deadline = deadline.UTC()
+	}
+
+	sessionID := uuid.NewString()
+	session := newSession(sessionID, req)
+
+	var history []historyEnvelope
+	if req.IncludeHistory > 0 && r.recorder != nil && req.Trigger == nil {
+		history = r.lockAfterHistory(session, time.Now().Add(-req.IncludeHistory))
+	} else {
+		r.mu.Lock()
+	}
 	defer r.mu.Unlock()
 
 	if len(r.sessions) >= r.maxSessions {
 		return nil, ErrSessionLimitReached
 	}
 
-	sessionID := uuid.NewString()
-	session := newSession(sessionID, req.Filter, req.VerboseMetrics, req.MaxBatches, req.BufferSize)
+	session.emitHistory(history)
 	r.sessions[sessionID] = session
 	r.hasActive.Store(true)
 
+	if session.Detachable() {
+		session.startGrace(func() { r.End(sessionID, model.EndReasonDetachExpired) })
+	}
+
 	go func() {
-		<-ctx.Done()
-		r.Deregister(sessionID)
+		select {
+		case <-ctx.Done():
+			r.End(sessionID, ContextEndReason(ctx))
+		case <-session.Done():
+			r.Deregister(sessionID)
+		}
 	}()
 
 	return session, nil
 }
 
-// Deregister closes and removes a session.
-func (r *Registry) Deregister(sessionID string) {
-	r.mu.Lock()
+// Deregister ends a session as cancelled and removes it; a session that already ended keeps its reason.
+func (r *Registry) Deregister(sessionID string) { r.End(sessionID, model.EndReasonCancelled) }
+
+// Session returns an active session by ID.
+func (r *Registry) Session(sessionID string) (*Session, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 	session, ok := r.sessions[sessionID]
-	if ok {
-		delete(r.sessions, sessionID)
-	}
-	r.hasActive.Store(len(r.sessions) > 0)
-	r.mu.Unlock()
+	return session, ok
+}
 
-	if ok {
-		session.Close()
-	}
+// Sessions returns all active sessions ordered by start time.
+func (r *Registry) Sessions() []*Session {
+	sessions := r.snapshotSessions()
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].StartedAt().Before(sessions[j].StartedAt())
+	})
+	return sessions
 }
 
 // PublishMetrics routes one metrics batch to all matching sessions.
 func (r *Registry) PublishMetrics(md pmetric.Metrics) {
-	if !r.HasActiveSessions() {
+	var sessions []*Session
+	switch {
+	case r.recorder != nil:
+		sessions = r.recordMetrics(md)
+	case r.HasActiveSessions():
+		sessions = r.snapshotSessions()
+	default:
 		return
 	}
 
-	sessions := r.snapshotSessions()
-
+	sizer := newBatchSizer(md.MetricCount(), func() int { return (&pmetric.ProtoMarshaler{}).MetricsSize(md) })
 	for _, session := range sessions {
-		payload, ok := buildMatchingMetricsPayload(session.Filter(), session.VerboseMetrics(), md)
+		if session.Paused() {
+			continue
+		}
+		if session.Armed() && !(session.trigger.Filter.MatchMetrics(md) && session.fire(model.SignalMetrics)) {
+			continue
+		}
+		settings := session.settings.Load()
+		payload, records, ok := sessionMetricsPayload(session, settings, md)
 		if !ok {
 			continue
 		}
-		built := payload
+		size := sizer.estimate(session, payload, records)
 
 		envelope := model.Envelope{
 			SessionID:  session.ID(),
 			Signal:     model.SignalMetrics,
-			BatchIndex: session.SentBatches() + 1,
 			CapturedAt: time.Now().UTC(),
-			Payload:    &built,
+			Records:    records,
+			Sampling:   settings.samplingInfo(),
+			Payload:    payload,
 		}
 
-		_, completed := session.Emit(envelope)
+		_, completed := session.emit(envelope, settings, size)
 		if completed {
 			r.Deregister(session.ID())
 		}
@@ -158,31 +332,41 @@ func (r *Registry) PublishMetrics(md pmetric.Metrics) {
 
 // PublishTraces routes one traces batch to all matching sessions.
 func (r *Registry) PublishTraces(td ptrace.Traces) {
-	if !r.HasActiveSessions() {
+	var sessions []*Session
+	switch {
+	case r.recorder != nil:
+		sessions = r.recordTraces(td)
+	case r.HasActiveSessions():
+		sessions = r.snapshotSessions()
+	default:
 		return
 	}
 
-	sessions := r.snapshotSessions()
-	var payload *model.TracesPayload
-
+	sizer := newBatchSizer(td.SpanCount(), func() int { return (&ptrace.ProtoMarshaler{}).TracesSize(td) })
 	for _, session := range sessions {
-		if !session.Filter().MatchTraces(td) {
+		if session.Paused() {
 			continue
 		}
-		if payload == nil {
-			built := model.BuildTracesPayload(td)
-			payload = &built
+		if session.Armed() && !(session.trigger.Filter.MatchTraces(td) && session.fire(model.SignalTraces)) {
+			continue
 		}
+		settings := session.settings.Load()
+		payload, records, ok := sessionTracesPayload(session, settings, td)
+		if !ok {
+			continue
+		}
+		size := sizer.estimate(session, payload, records)
 
 		envelope := model.Envelope{
 			SessionID:  session.ID(),
 			Signal:     model.SignalTraces,
-			BatchIndex: session.SentBatches() + 1,
 			CapturedAt: time.Now().UTC(),
+			Records:    records,
+			Sampling:   settings.samplingInfo(),
 			Payload:    payload,
 		}
 
-		_, completed := session.Emit(envelope)
+		_, completed := session.emit(envelope, settings, size)
 		if completed {
 			r.Deregister(session.ID())
 		}
@@ -191,37 +375,83 @@ func (r *Registry) PublishTraces(td ptrace.Traces) {
 
 // PublishLogs routes one logs batch to all matching sessions.
 func (r *Registry) PublishLogs(ld plog.Logs) {
-	if !r.HasActiveSessions() {
+	var sessions []*Session
+	switch {
+	case r.recorder != nil:
+		sessions = r.recordLogs(ld)
+	case r.HasActiveSessions():
+		sessions = r.snapshotSessions()
+	default:
 		return
 	}
 
-	sessions := r.snapshotSessions()
-	var payload *model.LogsPayload
-
+	sizer := newBatchSizer(ld.LogRecordCount(), func() int { return (&plog.ProtoMarshaler{}).LogsSize(ld) })
 	for _, session := range sessions {
-		if !session.Filter().MatchLogs(ld) {
+		if session.Paused() {
+			continue
+		}
+		if session.Armed() && !(session.trigger.Filter.MatchLogs(ld) && session.fire(model.SignalLogs)) {
 			continue
 		}
-		if payload == nil {
-			built := model.BuildLogsPayload(ld)
-			payload = &built
+		settings := session.settings.Load()
+		payload, records, ok := sessionLogsPayload(session, settings, ld)
+		if !ok {
+			continue
 		}
+		size := sizer.estimate(session, payload, records)
 
 		envelope := model.Envelope{
 			SessionID:  session.ID(),
 			Signal:     model.SignalLogs,
-			BatchIndex: session.SentBatches() + 1,
 			CapturedAt: time.Now().UTC(),
+			Records:    records,
+			Sampling:   settings.samplingInfo(),
 			Payload:    payload,
 		}
 
-		_, completed := session.Emit(envelope)
+		_, completed := session.emit(envelope, settings, size)
 		if completed {
 			r.Deregister(session.ID())
 		}
 	}
 }
 
+// sessionMetricsPayload builds the session's payload for md and counts the matching metrics.
+func sessionMetricsPayload(session *Session, settings *sessionSettings, md pmetric.Metrics) (interface{}, int, bool) {
+	switch session.Format() {
+	case model.FormatOTLPJSON:
+		return buildMatchingMetricsOTLPJSON(settings.matcher, md)
+	case model.FormatOTLPProto:
+		return buildMatchingMetricsOTLPProto(settings.matcher, md)
+	}
+	payload, ok := buildMatchingMetricsPayload(settings.matcher, settings.verboseMetrics, md)
+	return &payload, payload.MetricCount, ok
+}
+
+// sessionTracesPayload builds the session's payload for td and counts the matching spans.
+func sessionTracesPayload(session *Session, settings *sessionSettings, td ptrace.Traces) (interface{}, int, bool) {
+	switch session.Format() {
+	case model.FormatOTLPJSON:
+		return buildMatchingTracesOTLPJSON(settings.matcher, td)
+	case model.FormatOTLPProto:
+		return buildMatchingTracesOTLPProto(settings.matcher, td)
+	}
+	payload, ok := buildMatchingTracesPayload(settings.matcher, settings.verboseTraces, td)
+	return &payload, payload.SpanCount, ok
+}
+
+// sessionLogsPayload builds the session's payload for ld and counts the matching log records.
+func sessionLogsPayload(session *Session, settings *sessionSettings, ld plog.Logs) (interface{}, int, bool) {
+	switch session.Format() {
+	case model.FormatOTLPJSON:
+		return buildMatchingLogsOTLPJSON(settings.matcher, ld)
+	case model.FormatOTLPProto:
+		return buildMatchingLogsOTLPProto(settings.matcher, ld)
+	}
+	payload, ok := buildMatchingLogsPayload(settings.matcher, settings.maxLogRecords, settings.verboseLogs, ld)
+	return &payload, payload.LogCount, ok
+}
+
 func (r *Registry) snapshotSessions() []*Session {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
@@ -2,11 +2,15 @@ package capture
 
 import (
 	"context"
+	"encoding/json"
 	"testing"
 	"time"
 
 	"github.com/utrack/otellens/internal/model"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
 	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
 )
 
 func TestRegistryHasActiveSessions(t *testing.T) {
@@ -186,6 +190,10 @@ func TestRegistryPublishMetrics_VerboseMetricsControlsHistogramBuckets(t *testin
 			dp.SetSum(3)
 			dp.ExplicitBounds().FromRaw([]float64{1, 2})
 			dp.BucketCounts().FromRaw([]uint64{1, 1, 0})
+			ex := dp.Exemplars().AppendEmpty()
+			ex.SetDoubleValue(1.5)
+			ex.SetTraceID(pcommon.TraceID([16]byte{0xab}))
+			ex.FilteredAttributes().PutStr("user.id", "42")
 
 			registry.PublishMetrics(md)
 
@@ -203,6 +211,90 @@ func TestRegistryPublishMetrics_VerboseMetricsControlsHistogramBuckets(t *testin
 				if hasBuckets != tc.expectBuckets {
 					t.Fatalf("bucket fields mismatch: expect=%v got_counts=%v got_bounds=%v", tc.expectBuckets, detail.BucketCounts, detail.ExplicitBounds)
 				}
+				if (len(detail.Exemplars) > 0) != tc.expectBuckets {
+					t.Fatalf("exemplars mismatch: expect=%v got=%+v", tc.expectBuckets, detail.Exemplars)
+				}
+				if tc.expectBuckets {
+					exemplar := detail.Exemplars[0]
+					if exemplar.Value != 1.5 || exemplar.TraceID != "ab000000000000000000000000000000" || exemplar.SpanID != "" {
+						t.Fatalf("unexpected exemplar: %+v", exemplar)
+					}
+					if exemplar.FilteredAttributes["user.id"] != "42" {
+						t.Fatalf("unexpected exemplar attributes: %v", exemplar.FilteredAttributes)
+					}
+				}
+			case <-time.After(2 * time.Second):
+				t.Fatal("expected emitted metrics event")
+			}
+		})
+	}
+}
+
+func TestRegistryPublishMetrics_ExponentialHistogramDetails(t *testing.T) {
+	testCases := []struct {
+		name           string
+		verboseMetrics bool
+	}{
+		{name: "default concise metrics", verboseMetrics: false},
+		{name: "verbose metrics", verboseMetrics: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			registry := NewRegistry(2)
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			session, err := registry.Register(ctx, RegisterRequest{
+				Filter:         Filter{Signals: map[model.SignalType]struct{}{model.SignalMetrics: {}}},
+				VerboseMetrics: tc.verboseMetrics,
+				MaxBatches:     1,
+				BufferSize:     1,
+			})
+			if err != nil {
+				t.Fatalf("register failed: %v", err)
+			}
+
+			md := pmetric.NewMetrics()
+			metric := md.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics().AppendEmpty()
+			metric.SetName("exp.metric")
+			dp := metric.SetEmptyExponentialHistogram().DataPoints().AppendEmpty()
+			dp.SetCount(6)
+			dp.SetScale(0)
+			dp.SetZeroCount(1)
+			dp.SetZeroThreshold(0.001)
+			dp.SetMin(0)
+			dp.SetMax(9.5)
+			dp.Positive().SetOffset(-2)
+			dp.Positive().BucketCounts().FromRaw([]uint64{2, 3})
+
+			registry.PublishMetrics(md)
+
+			select {
+			case event := <-session.Events():
+				payload, ok := event.Payload.(*model.MetricsPayload)
+				if !ok {
+					t.Fatalf("unexpected payload type: %T", event.Payload)
+				}
+				detail := payload.Metrics[0].DataPoints[0]
+				if detail.Scale == nil || *detail.Scale != 0 || detail.ZeroCount != 1 || detail.ZeroThreshold != 0.001 {
+					t.Fatalf("unexpected exponential histogram scalars: %+v", detail)
+				}
+				if detail.Min == nil || *detail.Min != 0 || detail.Max == nil || *detail.Max != 9.5 {
+					t.Fatalf("expected min/max to be set, got min=%v max=%v", detail.Min, detail.Max)
+				}
+				if detail.Negative != nil {
+					t.Fatalf("expected empty negative buckets to be omitted, got %+v", detail.Negative)
+				}
+				if !tc.verboseMetrics {
+					if detail.Positive != nil {
+						t.Fatalf("expected no bucket details, got %+v", detail.Positive)
+					}
+					return
+				}
+				if detail.Positive == nil || detail.Positive.Offset != -2 || len(detail.Positive.BucketCounts) != 2 {
+					t.Fatalf("unexpected positive buckets: %+v", detail.Positive)
+				}
 			case <-time.After(2 * time.Second):
 				t.Fatal("expected emitted metrics event")
 			}
@@ -210,14 +302,337 @@ func TestRegistryPublishMetrics_VerboseMetricsControlsHistogramBuckets(t *testin
 	}
 }
 
-func newMetricsBatch(name string) pmetric.Metrics {
+func TestRegistryPublishTracesAndLogs_EmitOnlyMatchingRecords(t *testing.T) {
+	registry := NewRegistry(10)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	traceSession, err := registry.Register(ctx, RegisterRequest{
+		Filter: Filter{
+			Signals:   map[model.SignalType]struct{}{model.SignalTraces: {}},
+			SpanNames: map[string]struct{}{"GET /": {}},
+		},
+		MaxBatches: 1,
+		BufferSize: 1,
+	})
+	if err != nil {
+		t.Fatalf("register failed: %v", err)
+	}
+	logSession, err := registry.Register(ctx, RegisterRequest{
+		Filter: Filter{
+			Signals:         map[model.SignalType]struct{}{model.SignalLogs: {}},
+			LogBodyContains: "timeout",
+		},
+		MaxBatches: 1,
+		BufferSize: 1,
+	})
+	if err != nil {
+		t.Fatalf("register failed: %v", err)
+	}
+
+	td := ptrace.NewTraces()
+	for _, name := range []string{"GET /", "POST /graphql"} {
+		rs := td.ResourceSpans().AppendEmpty()
+		rs.ScopeSpans().AppendEmpty().Spans().AppendEmpty().SetName(name)
+		rs.ScopeSpans().AppendEmpty().Spans().AppendEmpty().SetName("db.query")
+	}
+	registry.PublishTraces(td)
+
+	select {
+	case event := <-traceSession.Events():
+		payload, ok := event.Payload.(*model.TracesPayload)
+		if !ok {
+			t.Fatalf("unexpected payload type: %T", event.Payload)
+		}
+		if payload.SpanCount != 1 || payload.ResourceSpans != 1 || payload.ScopeSpans != 1 {
+			t.Fatalf("expected one matching span in one resource/scope, got %+v", payload)
+		}
+		if len(payload.SpanNames) != 1 || payload.SpanNames[0] != "GET /" {
+			t.Fatalf("unexpected span names: %v", payload.SpanNames)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected emitted traces event")
+	}
+
+	ld := plog.NewLogs()
+	logs := ld.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords()
+	logs.AppendEmpty().Body().SetStr("request ok")
+	logs.AppendEmpty().Body().SetStr("upstream timeout")
+	registry.PublishLogs(ld)
+
+	select {
+	case event := <-logSession.Events():
+		payload, ok := event.Payload.(*model.LogsPayload)
+		if !ok {
+			t.Fatalf("unexpected payload type: %T", event.Payload)
+		}
+		if payload.LogCount != 1 || len(payload.Bodies) != 1 || payload.Bodies[0] != "upstream timeout" {
+			t.Fatalf("expected only the matching log record, got %+v", payload)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected emitted logs event")
+	}
+}
+
+func TestRegistryPublishTraces_VerboseTracesProjectsSpans(t *testing.T) {
+	testCases := []struct {
+		name          string
+		verboseTraces bool
+	}{
+		{name: "default concise traces", verboseTraces: false},
+		{name: "verbose traces", verboseTraces: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			registry := NewRegistry(2)
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			session, err := registry.Register(ctx, RegisterRequest{
+				Filter:        Filter{Signals: map[model.SignalType]struct{}{model.SignalTraces: {}}},
+				VerboseTraces: tc.verboseTraces,
+				MaxBatches:    1,
+				BufferSize:    1,
+			})
+			if err != nil {
+				t.Fatalf("register failed: %v", err)
+			}
+
+			td := ptrace.NewTraces()
+			rs := td.ResourceSpans().AppendEmpty()
+			rs.Resource().Attributes().PutStr("service.name", "checkout")
+			ss := rs.ScopeSpans().AppendEmpty()
+			ss.Scope().SetName("lib")
+			span := ss.Spans().AppendEmpty()
+			span.SetName("GET /")
+			span.SetTraceID(pcommon.TraceID([16]byte{0xab, 1}))
+			span.SetSpanID(pcommon.SpanID([8]byte{0xcd, 2}))
+			span.SetParentSpanID(pcommon.SpanID([8]byte{0xef, 3}))
+			span.SetKind(ptrace.SpanKindServer)
+			span.SetStartTimestamp(100)
+			span.SetEndTimestamp(350)
+			span.Status().SetCode(ptrace.StatusCodeError)
+			span.Events().AppendEmpty().SetName("exception")
+			span.Links().AppendEmpty().SetTraceID(pcommon.TraceID([16]byte{0x01}))
+
+			registry.PublishTraces(td)
+
+			select {
+			case event := <-session.Events():
+				payload, ok := event.Payload.(*model.TracesPayload)
+				if !ok {
+					t.Fatalf("unexpected payload type: %T", event.Payload)
+				}
+				if !tc.verboseTraces {
+					if len(payload.Spans) != 0 {
+						t.Fatalf("expected no span details, got %d", len(payload.Spans))
+					}
+					return
+				}
+				if len(payload.Spans) != 1 {
+					t.Fatalf("expected one span detail, got %d", len(payload.Spans))
+				}
+				detail := payload.Spans[0]
+				if detail.TraceID != "ab010000000000000000000000000000" || detail.ParentSpanID != "ef03000000000000" {
+					t.Fatalf("unexpected ids: trace=%s parent=%s", detail.TraceID, detail.ParentSpanID)
+				}
+				if detail.Kind != "server" || detail.Status.Code != "error" || detail.DurationNano != 250 {
+					t.Fatalf("unexpected span shape: %+v", detail)
+				}
+				if len(detail.Events) != 1 || len(detail.Links) != 1 {
+					t.Fatalf("expected one event and one link, got %d/%d", len(detail.Events), len(detail.Links))
+				}
+				if detail.Scope.Name != "lib" || detail.ResourceAttributes["service.name"] != "checkout" {
+					t.Fatalf("expected resource and scope context, got %+v", detail)
+				}
+			case <-time.After(2 * time.Second):
+				t.Fatal("expected emitted traces event")
+			}
+		})
+	}
+}
+
+func TestRegistryPublishLogs_SummarizesBodiesByDefault(t *testing.T) {
+	registry := NewRegistry(2)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	session, err := registry.Register(ctx, RegisterRequest{
+		Filter:        Filter{Signals: map[model.SignalType]struct{}{model.SignalLogs: {}}},
+		MaxLogRecords: 2,
+		MaxBatches:    1,
+		BufferSize:    1,
+	})
+	if err != nil {
+		t.Fatalf("register failed: %v", err)
+	}
+
+	ld := plog.NewLogs()
+	records := ld.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords()
+	records.AppendEmpty().Body().SetStr("first")
+	records.AppendEmpty()
+	records.AppendEmpty().Body().SetStr("third")
+
+	registry.PublishLogs(ld)
+
+	select {
+	case event := <-session.Events():
+		payload := event.Payload.(*model.LogsPayload)
+		if payload.LogCount != 3 || len(payload.Bodies) != 1 || payload.Bodies[0] != "first" || !payload.Truncated {
+			t.Fatalf("expected 3 counted, the non-empty body of the first 2 and truncated, got %+v", payload)
+		}
+		if payload.Records != nil {
+			t.Fatalf("expected no record projections without verbose_logs, got %+v", payload.Records)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected emitted logs event")
+	}
+}
+
+func TestRegistryPublishLogs_VerboseLogsProjectsStructuredRecordsUpToLimit(t *testing.T) {
+	registry := NewRegistry(2)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	session, err := registry.Register(ctx, RegisterRequest{
+		Filter:        Filter{Signals: map[model.SignalType]struct{}{model.SignalLogs: {}}},
+		VerboseLogs:   true,
+		MaxLogRecords: 2,
+		MaxBatches:    1,
+		BufferSize:    1,
+	})
+	if err != nil {
+		t.Fatalf("register failed: %v", err)
+	}
+
+	ld := plog.NewLogs()
+	rl := ld.ResourceLogs().AppendEmpty()
+	rl.Resource().Attributes().PutStr("service.name", "checkout")
+	sl := rl.ScopeLogs().AppendEmpty()
+	sl.Scope().SetName("lib")
+	first := sl.LogRecords().AppendEmpty()
+	first.SetSeverityNumber(plog.SeverityNumberWarn)
+	first.SetTraceID(pcommon.TraceID([16]byte{0xab}))
+	body := first.Body().SetEmptyMap()
+	body.PutStr("event", "retry")
+	body.PutEmptySlice("attempts").AppendEmpty().SetInt(1)
+	sl.LogRecords().AppendEmpty().Body().SetStr("second")
+	sl.LogRecords().AppendEmpty().Body().SetStr("third")
+
+	registry.PublishLogs(ld)
+
+	select {
+	case event := <-session.Events():
+		payload, ok := event.Payload.(*model.LogsPayload)
+		if !ok {
+			t.Fatalf("unexpected payload type: %T", event.Payload)
+		}
+		if payload.LogCount != 3 || len(payload.Records) != 2 || !payload.Truncated {
+			t.Fatalf("expected 3 counted, 2 projected and truncated, got count=%d records=%d truncated=%v",
+				payload.LogCount, len(payload.Records), payload.Truncated)
+		}
+		record := payload.Records[0]
+		structured, ok := record.Body.(map[string]interface{})
+		if !ok {
+			t.Fatalf("expected structured map body, got %T", record.Body)
+		}
+		if structured["event"] != "retry" {
+			t.Fatalf("unexpected body: %v", structured)
+		}
+		if attempts, ok := structured["attempts"].([]interface{}); !ok || len(attempts) != 1 {
+			t.Fatalf("expected attempts slice to stay structured, got %#v", structured["attempts"])
+		}
+		if record.TraceID != "ab000000000000000000000000000000" || record.SeverityNumber != int32(plog.SeverityNumberWarn) {
+			t.Fatalf("unexpected record context: %+v", record)
+		}
+		if record.Scope.Name != "lib" || record.ResourceAttributes["service.name"] != "checkout" {
+			t.Fatalf("expected resource and scope context, got %+v", record)
+		}
+		if payload.Records[1].Body != "second" {
+			t.Fatalf("unexpected second body: %v", payload.Records[1].Body)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected emitted logs event")
+	}
+}
+
+func TestRegistryPublish_OTLPJSONFormatKeepsMatchingSubset(t *testing.T) {
+	registry := NewRegistry(2)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	session, err := registry.Register(ctx, RegisterRequest{
+		Filter: Filter{
+			MetricNames: map[string]struct{}{"keep": {}},
+			SpanNames:   map[string]struct{}{"keep": {}},
+		},
+		Format:     model.FormatOTLPJSON,
+		MaxBatches: 2,
+		BufferSize: 2,
+	})
+	if err != nil {
+		t.Fatalf("register failed: %v", err)
+	}
+
+	md := pmetric.NewMetrics()
+	for _, name := range []string{"drop", "keep"} {
+		rm := md.ResourceMetrics().AppendEmpty()
+		rm.Resource().Attributes().PutStr("service.name", name)
+		sm := rm.ScopeMetrics().AppendEmpty()
+		sm.Scope().SetName("lib")
+		metric := sm.Metrics().AppendEmpty()
+		metric.SetName(name)
+		metric.SetEmptySum().DataPoints().AppendEmpty().SetIntValue(7)
+	}
+	registry.PublishMetrics(md)
+
+	td := ptrace.NewTraces()
+	spans := td.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans()
+	spans.AppendEmpty().SetName("keep")
+	spans.AppendEmpty().SetName("drop")
+	registry.PublishTraces(td)
+
+	event := <-session.Events()
+	raw, ok := event.Payload.(json.RawMessage)
+	if !ok {
+		t.Fatalf("unexpected payload type: %T", event.Payload)
+	}
+	gotMetrics, err := (&pmetric.JSONUnmarshaler{}).UnmarshalMetrics(raw)
+	if err != nil {
+		t.Fatalf("payload is not OTLP/JSON metrics: %v", err)
+	}
+	if gotMetrics.ResourceMetrics().Len() != 1 || gotMetrics.MetricCount() != 1 {
+		t.Fatalf("expected one resource with one metric, got %d/%d", gotMetrics.ResourceMetrics().Len(), gotMetrics.MetricCount())
+	}
+	rm := gotMetrics.ResourceMetrics().At(0)
+	if service, _ := rm.Resource().Attributes().Get("service.name"); service.Str() != "keep" {
+		t.Fatalf("unexpected resource: %v", rm.Resource().Attributes().AsRaw())
+	}
+	if rm.ScopeMetrics().At(0).Scope().Name() != "lib" {
+		t.Fatalf("expected scope to be kept, got %q", rm.ScopeMetrics().At(0).Scope().Name())
+	}
+
+	event = <-session.Events()
+	gotTraces, err := (&ptrace.JSONUnmarshaler{}).UnmarshalTraces(event.Payload.(json.RawMessage))
+	if err != nil {
+		t.Fatalf("payload is not OTLP/JSON traces: %v", err)
+	}
+	if gotTraces.SpanCount() != 1 || gotTraces.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0).Name() != "keep" {
+		t.Fatalf("expected only the matching span, got %d spans", gotTraces.SpanCount())
+	}
+}
+
+func newMetricsBatch(names ...string) pmetric.Metrics {
 	md := pmetric.NewMetrics()
 	rm := md.ResourceMetrics().AppendEmpty()
 	sm := rm.ScopeMetrics().AppendEmpty()
-	metric := sm.Metrics().AppendEmpty()
-	metric.SetName(name)
-	gauge := metric.SetEmptyGauge()
-	dp := gauge.DataPoints().AppendEmpty()
-	dp.SetDoubleValue(1)
+	for _, name := range names {
+		metric := sm.Metrics().AppendEmpty()
+		metric.SetName(name)
+		gauge := metric.SetEmptyGauge()
+		dp := gauge.DataPoints().AppendEmpty()
+		dp.SetDoubleValue(1)
+	}
 	return md
 }
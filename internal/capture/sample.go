@@ -0,0 +1,102 @@
+package capture
+
+import (
+	"hash/fnv"
+	"math/rand/v2"
+
+	"github.com/utrack/otellens/internal/model"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// sampleScale maps the top 53 bits of a hash onto [0, 1) exactly.
+const sampleScale = 1 << 53
+
+// recordMatcher selects the records of a batch that go into a payload; Filter implements it.
+type recordMatcher interface {
+	MatchMetric(resourceAttrs pcommon.Map, scope pcommon.InstrumentationScope, metric pmetric.Metric) bool
+	MatchSpan(resourceAttrs pcommon.Map, scope pcommon.InstrumentationScope, span ptrace.Span) bool
+	MatchLogRecord(resourceAttrs pcommon.Map, scope pcommon.InstrumentationScope, record plog.LogRecord) bool
+}
+
+// samples reports whether rate drops any records; 0 (unset) and 1 keep them all.
+func samples(rate float64) bool { return rate > 0 && rate < 1 }
+
+// sampledFilter matches the records of Filter that a session's sample rate keeps. Only the
+// payloads of a session use it, so trigger filters never sample.
+type sampledFilter struct {
+	Filter
+	rate float64
+}
+
+func (f sampledFilter) MatchMetric(resourceAttrs pcommon.Map, scope pcommon.InstrumentationScope, metric pmetric.Metric) bool {
+	return f.Filter.MatchMetric(resourceAttrs, scope, metric) && f.keepMetric(resourceAttrs, metric)
+}
+
+func (f sampledFilter) MatchSpan(resourceAttrs pcommon.Map, scope pcommon.InstrumentationScope, span ptrace.Span) bool {
+	return f.Filter.MatchSpan(resourceAttrs, scope, span) && f.keepTrace(span.TraceID())
+}
+
+func (f sampledFilter) MatchLogRecord(resourceAttrs pcommon.Map, scope pcommon.InstrumentationScope, record plog.LogRecord) bool {
+	return f.Filter.MatchLogRecord(resourceAttrs, scope, record) && f.keepTrace(record.TraceID())
+}
+
+// keepTrace keeps a record by its trace ID, so every span and log of a kept trace is kept in all
+// sessions and on all collectors. Records without a trace ID are kept at random.
+func (f sampledFilter) keepTrace(traceID pcommon.TraceID) bool {
+	if traceID.IsEmpty() {
+		return rand.Float64() < f.rate
+	}
+	h := fnv.New64a()
+	_, _ = h.Write(traceID[:])
+	return f.keepHash(h.Sum64())
+}
+
+// keepMetric keeps a metric by its name and resource attributes, so a kept metric stays continuous
+// across batches. All datapoints of a kept metric are kept.
+func (f sampledFilter) keepMetric(resourceAttrs pcommon.Map, metric pmetric.Metric) bool {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(metric.Name()))
+	key := h.Sum64()
+	// Combined order-independently, as producers may not keep attribute order stable.
+	resourceAttrs.Range(func(k string, v pcommon.Value) bool {
+		h.Reset()
+		_, _ = h.Write([]byte(k))
+		_, _ = h.Write([]byte{0})
+		_, _ = h.Write([]byte(v.AsString()))
+		key += mix64(h.Sum64())
+		return true
+	})
+	return f.keepHash(key)
+}
+
+func (f sampledFilter) keepHash(h uint64) bool {
+	return float64(mix64(h)>>11) < f.rate*sampleScale
+}
+
+// mix64 is the splitmix64 finalizer; it spreads hash bits so thresholds on the top bits are uniform.
+func mix64(h uint64) uint64 {
+	h ^= h >> 30
+	h *= 0xbf58476d1ce4e5b9
+	h ^= h >> 27
+	h *= 0x94d049bb133111eb
+	h ^= h >> 31
+	return h
+}
+
+// samplingInfo describes the sampling applied by settings, or nil if nothing is sampled.
+func (s *sessionSettings) samplingInfo() *model.Sampling {
+	if !samples(s.sampleRate) && s.sampleEveryN <= 1 {
+		return nil
+	}
+	out := &model.Sampling{Rate: 1, EveryN: 1}
+	if samples(s.sampleRate) {
+		out.Rate = s.sampleRate
+	}
+	if s.sampleEveryN > 1 {
+		out.EveryN = s.sampleEveryN
+	}
+	return out
+}
@@ -0,0 +1,141 @@
+package capture
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/utrack/otellens/internal/model"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+func TestSampleRateKeepsWholeTracesDeterministically(t *testing.T) {
+	filter := sampledFilter{rate: 0.3}
+	resource := pcommon.NewMap()
+	scope := pcommon.NewInstrumentationScope()
+
+	kept := 0
+	for i := 0; i < 2000; i++ {
+		var traceID pcommon.TraceID
+		binary.BigEndian.PutUint64(traceID[8:], uint64(i+1))
+		root := ptrace.NewSpan()
+		root.SetTraceID(traceID)
+		child := ptrace.NewSpan()
+		child.SetTraceID(traceID)
+		child.SetParentSpanID(pcommon.SpanID{1})
+
+		keep := filter.MatchSpan(resource, scope, root)
+		if filter.MatchSpan(resource, scope, child) != keep {
+			t.Fatalf("expected all spans of trace %d to be sampled alike", i)
+		}
+		if keep {
+			kept++
+		}
+	}
+	if kept < 500 || kept > 700 {
+		t.Fatalf("expected about 600 of 2000 traces to be kept, got %d", kept)
+	}
+}
+
+func TestSampleRateKeepsMetricsByNameAndResource(t *testing.T) {
+	filter := sampledFilter{rate: 0.5}
+	scope := pcommon.NewInstrumentationScope()
+
+	kept := 0
+	for i := 0; i < 200; i++ {
+		metric := pmetric.NewMetric()
+		metric.SetName(fmt.Sprintf("metric.%d", i))
+		resource := pcommon.NewMap()
+		resource.PutStr("service.name", "checkout")
+		resource.PutInt("shard", int64(i%3))
+		reordered := pcommon.NewMap()
+		reordered.PutInt("shard", int64(i%3))
+		reordered.PutStr("service.name", "checkout")
+
+		keep := filter.MatchMetric(resource, scope, metric)
+		if filter.MatchMetric(reordered, scope, metric) != keep {
+			t.Fatalf("expected metric %d to be sampled alike regardless of attribute order", i)
+		}
+		if keep {
+			kept++
+		}
+	}
+	if kept == 0 || kept == 200 {
+		t.Fatalf("expected some metrics to be sampled out, kept %d", kept)
+	}
+}
+
+func TestSampleEveryNKeepsEveryNthMatchingBatch(t *testing.T) {
+	registry := NewRegistry(10)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	session, err := registry.Register(ctx, RegisterRequest{
+		Filter:       Filter{MetricNames: map[string]struct{}{"A": {}}},
+		SampleEveryN: 3,
+		MaxBatches:   10,
+		BufferSize:   10,
+	})
+	if err != nil {
+		t.Fatalf("register failed: %v", err)
+	}
+	for i := 0; i < 7; i++ {
+		registry.PublishMetrics(newMetricsBatch("B"))
+		registry.PublishMetrics(newMetricsBatch("A"))
+	}
+	registry.Deregister(session.ID())
+
+	var events []model.Envelope
+	for event := range session.Events() {
+		events = append(events, event)
+	}
+	if len(events) != 3 || session.DroppedBatches() != 0 {
+		t.Fatalf("expected batches 1, 4 and 7 of A without drops, got %d envelopes and %d drops", len(events), session.DroppedBatches())
+	}
+	if sampling := events[0].Sampling; sampling == nil || sampling.EveryN != 3 || sampling.Rate != 1 {
+		t.Fatalf("expected sampling to be reported, got %+v", sampling)
+	}
+}
+
+func TestSampleRateAppliesToPayloadsButNotToTriggers(t *testing.T) {
+	sampler := sampledFilter{rate: 0.1}
+	var traceID pcommon.TraceID
+	for i := 1; ; i++ {
+		binary.BigEndian.PutUint64(traceID[8:], uint64(i))
+		if !sampler.keepTrace(traceID) {
+			break
+		}
+	}
+	td := ptrace.NewTraces()
+	td.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans().AppendEmpty().SetTraceID(traceID)
+
+	registry := NewRegistry(10)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	session, err := registry.Register(ctx, RegisterRequest{
+		Trigger:    &Trigger{Filter: Filter{Signals: map[model.SignalType]struct{}{model.SignalTraces: {}}}},
+		SampleRate: sampler.rate,
+		MaxBatches: 10,
+		BufferSize: 10,
+	})
+	if err != nil {
+		t.Fatalf("register failed: %v", err)
+	}
+	registry.PublishTraces(td)
+	registry.Deregister(session.ID())
+
+	if session.Armed() {
+		t.Fatal("expected a span sampled out of the payloads to fire the trigger")
+	}
+	var events []model.Envelope
+	for event := range session.Events() {
+		events = append(events, event)
+	}
+	if len(events) != 1 || events[0].Type != model.EnvelopeTypeTriggered {
+		t.Fatalf("expected only the triggered marker, the span being sampled out, got %+v", events)
+	}
+}
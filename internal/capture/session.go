@@ -3,47 +3,156 @@ package capture
 import (
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/utrack/otellens/internal/model"
 )
 
 // Session is a single active API-driven capture stream.
 type Session struct {
-	id             string
-	filter         Filter
-	verboseMetrics bool
-	maxBatches     uint64
+	id          string
+	format      model.OutputFormat
+	info        SessionInfo
+	startedAt   time.Time
+	maxBatches  uint64
+	maxRecords  uint64
+	maxBytes    uint64
+	detachGrace time.Duration
 
+	// settings holds the replaceable matching rules; Publish* loads them once per batch.
+	settings atomic.Pointer[sessionSettings]
+
+	// mu serializes Emit, Update and Close, so BatchIndex is assigned in queue order
+	// and nothing is sent on a closed channel.
+	mu     sync.Mutex
 	events chan model.Envelope
 	done   chan struct{}
-	once   sync.Once
+	closed bool
+	// lastIndex is the BatchIndex of the most recently queued event, markers included.
+	lastIndex uint64
+	// matchedBatches counts the batches offered to emit, for SampleEveryN.
+	matchedBatches uint64
+	// pendingMarkers are markers that did not fit into the queue yet, oldest first.
+	pendingMarkers []model.Envelope
+	// endReason tells why the session ended, see model.StreamEnd.Reason; set with endedAt when it closes.
+	endReason string
+	endedAt   time.Time
+	// firstCapturedAt and lastCapturedAt are the CapturedAt of the first and last queued telemetry envelopes.
+	firstCapturedAt time.Time
+	lastCapturedAt  time.Time
+
+	// paused is read without locking by Publish*; pausedSince and pausedTotal are guarded by mu.
+	paused      atomic.Bool
+	pausedSince time.Time
+	pausedTotal time.Duration
+
+	// trigger is set for armed sessions; armed is read without locking by Publish*,
+	// triggeredAt and captureTimer are guarded by mu.
+	trigger      *Trigger
+	armed        atomic.Bool
+	triggeredAt  time.Time
+	captureTimer *time.Timer
+
+	// limiter applies the session's RateLimits; nil when none are set. Guarded by mu.
+	limiter *rateLimiter
+
+	attached   bool
+	graceTimer *time.Timer
+	history    *envelopeRing
 
 	sentBatches    atomic.Uint64
 	droppedBatches atomic.Uint64
+	sentRecords    atomic.Uint64
+	sentBytes      atomic.Uint64
+	bytesWritten   atomic.Uint64
+	// signals holds per-signal counters for the three signals; the map itself is never modified.
+	signals map[model.SignalType]*signalCounters
+
+	rateLimitedBatches atomic.Uint64
+	rateLimitedRecords atomic.Uint64
 }
 
-func newSession(id string, filter Filter, verboseMetrics bool, maxBatches int, bufferSize int) *Session {
+func newSession(id string, req RegisterRequest) *Session {
+	bufferSize := req.BufferSize
 	if bufferSize <= 0 {
 		bufferSize = 32
 	}
-	return &Session{
-		id:             id,
-		filter:         filter,
-		verboseMetrics: verboseMetrics,
-		maxBatches:     uint64(maxBatches),
-		events:         make(chan model.Envelope, bufferSize),
-		done:           make(chan struct{}),
+	format := req.Format
+	if format == "" {
+		format = model.FormatOtellens
 	}
+	now := time.Now().UTC()
+	session := &Session{
+		id:          id,
+		format:      format,
+		info:        req.Info,
+		startedAt:   now,
+		maxBatches:  uint64(req.MaxBatches),
+		maxRecords:  uint64(req.MaxRecords),
+		maxBytes:    uint64(req.MaxBytes),
+		detachGrace: req.DetachGrace,
+		events:      make(chan model.Envelope, bufferSize),
+		done:        make(chan struct{}),
+		limiter:     newRateLimiter(req.RateLimits, now),
+		signals:     newSignalCounters(),
+	}
+	session.settings.Store(newSessionSettings(SessionUpdate{
+		Filter:         req.Filter,
+		VerboseMetrics: req.VerboseMetrics,
+		VerboseTraces:  req.VerboseTraces,
+		VerboseLogs:    req.VerboseLogs,
+		MaxLogRecords:  req.MaxLogRecords,
+		SampleRate:     req.SampleRate,
+		SampleEveryN:   req.SampleEveryN,
+		Request:        req.Info.Request,
+	}))
+	if req.Trigger != nil {
+		session.trigger = req.Trigger
+		session.armed.Store(true)
+	}
+	if session.Detachable() {
+		session.history = newEnvelopeRing(bufferSize)
+	}
+	return session
 }
 
 // ID returns the immutable session identifier.
 func (s *Session) ID() string { return s.id }
 
 // Filter returns session filter definition.
-func (s *Session) Filter() Filter { return s.filter }
+func (s *Session) Filter() Filter { return s.settings.Load().filter }
 
 // VerboseMetrics returns whether verbose metric datapoints are enabled for this session.
-func (s *Session) VerboseMetrics() bool { return s.verboseMetrics }
+func (s *Session) VerboseMetrics() bool { return s.settings.Load().verboseMetrics }
+
+// VerboseTraces returns whether full span projections are enabled for this session.
+func (s *Session) VerboseTraces() bool { return s.settings.Load().verboseTraces }
+
+// VerboseLogs returns whether full log record projections are enabled for this session.
+func (s *Session) VerboseLogs() bool { return s.settings.Load().verboseLogs }
+
+// MaxLogRecords returns how many log records one envelope projects before marking it truncated.
+func (s *Session) MaxLogRecords() int { return s.settings.Load().maxLogRecords }
+
+// Format returns the payload encoding used for this session's envelopes.
+func (s *Session) Format() model.OutputFormat { return s.format }
+
+// Info returns the descriptive metadata supplied at registration.
+// Request reflects the latest Update.
+func (s *Session) Info() SessionInfo {
+	info := s.info
+	info.Request = s.settings.Load().request
+	return info
+}
+
+// StartedAt returns when the session was registered.
+func (s *Session) StartedAt() time.Time { return s.startedAt }
+
+// QueueDepth returns how many envelopes are waiting to be streamed.
+func (s *Session) QueueDepth() int { return len(s.events) }
+
+// QueueCapacity returns the size of the session queue.
+func (s *Session) QueueCapacity() int { return cap(s.events) }
 
 // Events returns a read-only stream of capture envelopes.
 func (s *Session) Events() <-chan model.Envelope { return s.events }
@@ -58,31 +167,103 @@ func (s *Session) SentBatches() uint64 { return s.sentBatches.Load() }
 func (s *Session) DroppedBatches() uint64 { return s.droppedBatches.Load() }
 
 // Emit tries to enqueue one envelope without blocking the hot path.
+// It assigns the envelope's BatchIndex. Only binary and OTLP/JSON payloads count toward max_bytes.
 func (s *Session) Emit(envelope model.Envelope) (streamed bool, completed bool) {
-	select {
-	case <-s.done:
+	size, _ := encodedSize(envelope.Payload)
+	return s.emit(envelope, nil, size)
+}
+
+// emit enqueues an envelope built with the given settings. If the settings were replaced in the
+// meantime, the envelope is discarded so nothing matched by an old filter follows the update marker.
+// size is the payload size estimated by a batchSizer, counted toward max_bytes and the byte rate limit.
+func (s *Session) emit(envelope model.Envelope, matched *sessionSettings, size int) (streamed bool, completed bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
 		return false, true
-	default:
+	}
+	if s.paused.Load() || matched != nil && s.settings.Load() != matched {
+		return false, false
+	}
+	s.countMatched(envelope)
+	s.matchedBatches++
+	if every := s.settings.Load().sampleEveryN; every > 1 && (s.matchedBatches-1)%uint64(every) != 0 {
+		return false, false
+	}
+	for len(s.pendingMarkers) > 0 {
+		if !s.enqueueLocked(s.pendingMarkers[0]) {
+			s.countDropped(envelope)
+			return false, false
+		}
+		s.pendingMarkers = s.pendingMarkers[1:]
+	}
+	limited := s.limiter != nil && !envelope.History
+	if limited && !s.rateLimitLocked(envelope, size, time.Now()) {
+		return false, false
 	}
 
+	if !s.enqueueLocked(envelope) {
+		s.countDropped(envelope)
+		return false, false
+	}
+	s.countSentLocked(envelope)
+	if limited {
+		s.limiter.take(envelope.Records, size)
+	}
+	sent := s.sentBatches.Add(1)
+	records := s.sentRecords.Add(uint64(envelope.Records))
+	bytes := s.sentBytes.Add(uint64(size))
+	if limit := s.budgetExhausted(sent, records, bytes); limit != "" {
+		s.closeLocked(limit)
+		return true, true
+	}
+	return true, false
+}
+
+// enqueueLocked assigns the next BatchIndex and queues the event if there is room.
+func (s *Session) enqueueLocked(envelope model.Envelope) bool {
+	envelope.BatchIndex = s.lastIndex + 1
 	select {
 	case s.events <- envelope:
-		sent := s.sentBatches.Add(1)
-		if s.maxBatches > 0 && sent >= s.maxBatches {
-			s.Close()
-			return true, true
+		s.lastIndex = envelope.BatchIndex
+		if s.history != nil {
+			s.history.push(envelope)
 		}
-		return true, false
+		return true
 	default:
-		s.droppedBatches.Add(1)
-		return false, false
+		return false
+	}
+}
+
+// queueMarkerLocked queues a marker, or keeps it pending until the next envelope finds room.
+func (s *Session) queueMarkerLocked(marker model.Envelope) {
+	if len(s.pendingMarkers) == 0 && s.enqueueLocked(marker) {
+		return
 	}
+	s.pendingMarkers = append(s.pendingMarkers, marker)
 }
 
-// Close ends the session and releases stream resources.
-func (s *Session) Close() {
-	s.once.Do(func() {
-		close(s.done)
-		close(s.events)
-	})
+// Close ends the session as cancelled and releases stream resources.
+func (s *Session) Close() { s.End(model.EndReasonCancelled) }
+
+func (s *Session) closeLocked(reason string) {
+	if s.closed {
+		return
+	}
+	s.closed = true
+	s.endReason = reason
+	s.endedAt = time.Now()
+	if s.paused.Load() {
+		s.pausedTotal += time.Since(s.pausedSince)
+		s.paused.Store(false)
+	}
+	if s.graceTimer != nil {
+		s.graceTimer.Stop()
+	}
+	if s.captureTimer != nil {
+		s.captureTimer.Stop()
+	}
+	close(s.done)
+	close(s.events)
 }
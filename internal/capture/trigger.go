@@ -0,0 +1,54 @@
+package capture
+
+import (
+	"time"
+
+	"github.com/utrack/otellens/internal/model"
+)
+
+// Trigger arms a session: it captures nothing until a published batch holds a record matching Filter.
+// The batch that fires the trigger is the first one offered to the capture filter.
+type Trigger struct {
+	Filter Filter
+	// CaptureFor ends the session this long after the trigger fired; 0 leaves it to max_batches and the timeout.
+	CaptureFor time.Duration
+	// Request is the client's trigger definition, carried by the triggered marker.
+	Request interface{}
+}
+
+// Armed reports whether the session still waits for its trigger.
+func (s *Session) Armed() bool { return s.armed.Load() }
+
+// TriggeredAt returns when the trigger fired; zero while armed and for sessions without a trigger.
+func (s *Session) TriggeredAt() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.triggeredAt
+}
+
+// fire disarms the session and queues a model.EnvelopeTypeTriggered marker for the signal that fired it.
+// It reports whether the session is capturing now, which is also true if another batch fired it first.
+func (s *Session) fire(signal model.SignalType) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return false
+	}
+	if !s.armed.Load() {
+		return true
+	}
+	s.armed.Store(false)
+	s.triggeredAt = time.Now().UTC()
+	s.queueMarkerLocked(model.Envelope{
+		Type:       model.EnvelopeTypeTriggered,
+		SessionID:  s.id,
+		Signal:     signal,
+		CapturedAt: s.triggeredAt,
+		Payload:    s.trigger.Request,
+	})
+	if s.trigger.CaptureFor > 0 {
+		s.captureTimer = time.AfterFunc(s.trigger.CaptureFor, func() { s.End(model.EndReasonCaptureWindow) })
+	}
+	return true
+}
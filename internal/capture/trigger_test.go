@@ -0,0 +1,91 @@
+package capture
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/utrack/otellens/internal/model"
+	"go.opentelemetry.io/collector/pdata/plog"
+)
+
+func newLogsBatch(severity plog.SeverityNumber) plog.Logs {
+	ld := plog.NewLogs()
+	record := ld.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords().AppendEmpty()
+	record.SetSeverityNumber(severity)
+	record.Body().SetStr("boom")
+	return ld
+}
+
+func TestArmedSessionCapturesOnlyAfterTriggerFires(t *testing.T) {
+	registry := NewRegistry(10)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	session, err := registry.Register(ctx, RegisterRequest{
+		Filter: Filter{Signals: map[model.SignalType]struct{}{model.SignalMetrics: {}, model.SignalLogs: {}}},
+		Trigger: &Trigger{
+			Filter: Filter{
+				Signals:           map[model.SignalType]struct{}{model.SignalLogs: {}},
+				MinSeverityNumber: plog.SeverityNumberError,
+			},
+			Request: "trigger",
+		},
+		MaxBatches: 2,
+		BufferSize: 4,
+	})
+	if err != nil {
+		t.Fatalf("register failed: %v", err)
+	}
+
+	registry.PublishMetrics(newMetricsBatch("A"))
+	registry.PublishLogs(newLogsBatch(plog.SeverityNumberInfo))
+	if !session.Armed() || session.QueueDepth() != 0 || session.SentBatches() != 0 {
+		t.Fatal("expected an armed session to capture nothing before the trigger matches")
+	}
+
+	registry.PublishLogs(newLogsBatch(plog.SeverityNumberError))
+	if session.Armed() || session.TriggeredAt().IsZero() {
+		t.Fatal("expected the trigger to fire")
+	}
+	registry.PublishMetrics(newMetricsBatch("B"))
+
+	var events []model.Envelope
+	for event := range session.Events() {
+		events = append(events, event)
+	}
+	if len(events) != 3 {
+		t.Fatalf("expected triggered marker, triggering logs batch and one metrics batch, got %+v", events)
+	}
+	if events[0].Type != model.EnvelopeTypeTriggered || events[0].Signal != model.SignalLogs || events[0].Payload != "trigger" {
+		t.Fatalf("expected triggered marker first, got %+v", events[0])
+	}
+	if events[1].Signal != model.SignalLogs || events[2].Signal != model.SignalMetrics || events[2].BatchIndex != 3 {
+		t.Fatalf("expected captured batches after the marker, got %+v", events[1:])
+	}
+}
+
+func TestTriggerCaptureForEndsSession(t *testing.T) {
+	registry := NewRegistry(10)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	session, err := registry.Register(ctx, RegisterRequest{
+		Trigger:    &Trigger{Filter: Filter{Signals: map[model.SignalType]struct{}{model.SignalMetrics: {}}}, CaptureFor: 20 * time.Millisecond},
+		MaxBatches: 100,
+		BufferSize: 100,
+	})
+	if err != nil {
+		t.Fatalf("register failed: %v", err)
+	}
+
+	registry.PublishMetrics(newMetricsBatch("A"))
+	select {
+	case <-session.Done():
+	case <-ctx.Done():
+		t.Fatal("expected the session to end after the capture window")
+	}
+	if session.SentBatches() != 1 || session.EndReason() != model.EndReasonCaptureWindow {
+		t.Fatalf("expected the triggering batch to be captured until the capture window ended, got %d, %q", session.SentBatches(), session.EndReason())
+	}
+}
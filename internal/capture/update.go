@@ -0,0 +1,101 @@
+package capture
+
+import (
+	"time"
+
+	"github.com/utrack/otellens/internal/model"
+)
+
+// SessionUpdate replaces the matching rules of a running session.
+type SessionUpdate struct {
+	Filter         Filter
+	VerboseMetrics bool
+	VerboseTraces  bool
+	VerboseLogs    bool
+	// MaxLogRecords caps projected log records per envelope; 0 uses model.DefaultMaxLogRecords.
+	MaxLogRecords int
+	// SampleRate keeps this fraction of matching records, see RegisterRequest.SampleRate.
+	SampleRate float64
+	// SampleEveryN keeps every Nth batch that matched; 0 and 1 keep all.
+	SampleEveryN int
+	// Request replaces SessionInfo.Request and is carried by the update marker.
+	Request interface{}
+}
+
+// sessionSettings is one immutable generation of a session's matching rules.
+type sessionSettings struct {
+	filter         Filter
+	verboseMetrics bool
+	verboseTraces  bool
+	verboseLogs    bool
+	maxLogRecords  int
+	sampleRate     float64
+	sampleEveryN   int
+	request        interface{}
+	// matcher selects the records of the session's payloads: filter, sampled by sampleRate.
+	matcher recordMatcher
+}
+
+func newSessionSettings(update SessionUpdate) *sessionSettings {
+	maxLogRecords := update.MaxLogRecords
+	if maxLogRecords <= 0 {
+		maxLogRecords = model.DefaultMaxLogRecords
+	}
+	var matcher recordMatcher = update.Filter
+	if samples(update.SampleRate) {
+		matcher = sampledFilter{Filter: update.Filter, rate: update.SampleRate}
+	}
+	return &sessionSettings{
+		filter:         update.Filter,
+		verboseMetrics: update.VerboseMetrics,
+		verboseTraces:  update.VerboseTraces,
+		verboseLogs:    update.VerboseLogs,
+		maxLogRecords:  maxLogRecords,
+		sampleRate:     update.SampleRate,
+		sampleEveryN:   update.SampleEveryN,
+		request:        update.Request,
+		matcher:        matcher,
+	}
+}
+
+// Update atomically replaces the session's filter and verbosity. Counters and BatchIndex continue,
+// and a model.EnvelopeTypeFilterUpdated marker is queued at the position where the new rules apply.
+// If the queue is full, the marker is queued ahead of the next envelope instead.
+func (s *Session) Update(update SessionUpdate) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrSessionNotFound
+	}
+	s.settings.Store(newSessionSettings(update))
+
+	marker := model.Envelope{
+		Type:       model.EnvelopeTypeFilterUpdated,
+		SessionID:  s.id,
+		CapturedAt: time.Now().UTC(),
+		Payload:    update.Request,
+	}
+	// A pending marker of an earlier update is superseded by this one.
+	pending := s.pendingMarkers[:0]
+	for _, m := range s.pendingMarkers {
+		if m.Type != model.EnvelopeTypeFilterUpdated {
+			pending = append(pending, m)
+		}
+	}
+	s.pendingMarkers = pending
+	s.queueMarkerLocked(marker)
+	return nil
+}
+
+// UpdateSession replaces the filter and verbosity of an active session.
+func (r *Registry) UpdateSession(sessionID string, update SessionUpdate) (*Session, error) {
+	session, ok := r.Session(sessionID)
+	if !ok {
+		return nil, ErrSessionNotFound
+	}
+	if err := session.Update(update); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
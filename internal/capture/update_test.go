@@ -0,0 +1,91 @@
+package capture
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/utrack/otellens/internal/model"
+)
+
+func TestRegistryUpdateSessionKeepsCountersAndMarksPosition(t *testing.T) {
+	registry := NewRegistry(10)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	metricsOnly := func(name string) Filter {
+		return Filter{
+			Signals:     map[model.SignalType]struct{}{model.SignalMetrics: {}},
+			MetricNames: map[string]struct{}{name: {}},
+		}
+	}
+	session, err := registry.Register(ctx, RegisterRequest{Filter: metricsOnly("A"), MaxBatches: 3, BufferSize: 8})
+	if err != nil {
+		t.Fatalf("register failed: %v", err)
+	}
+
+	registry.PublishMetrics(newMetricsBatch("A"))
+	if _, err := registry.UpdateSession(session.ID(), SessionUpdate{Filter: metricsOnly("B"), VerboseMetrics: true, Request: "next"}); err != nil {
+		t.Fatalf("update failed: %v", err)
+	}
+	registry.PublishMetrics(newMetricsBatch("A"))
+	registry.PublishMetrics(newMetricsBatch("B"))
+	registry.PublishMetrics(newMetricsBatch("B"))
+
+	var events []model.Envelope
+	for event := range session.Events() {
+		events = append(events, event)
+	}
+	if len(events) != 4 {
+		t.Fatalf("expected A, marker, B, B; got %+v", events)
+	}
+	if events[1].Type != model.EnvelopeTypeFilterUpdated || events[1].BatchIndex != 2 || events[1].Payload != "next" {
+		t.Fatalf("expected marker at position 2, got %+v", events[1])
+	}
+	for i, event := range events {
+		if event.BatchIndex != uint64(i+1) {
+			t.Fatalf("expected contiguous batch indexes, got %d at %d", event.BatchIndex, i)
+		}
+	}
+	if payload := events[2].Payload.(*model.MetricsPayload); payload.Metrics[0].Name != "B" {
+		t.Fatalf("expected metric B after the update, got %s", payload.Metrics[0].Name)
+	}
+	if session.SentBatches() != 3 || !session.VerboseMetrics() || session.Info().Request != "next" {
+		t.Fatalf("expected 3 sent batches, counted across the update, with new settings")
+	}
+
+	if _, err := registry.UpdateSession(session.ID(), SessionUpdate{}); !errors.Is(err, ErrSessionNotFound) {
+		t.Fatalf("expected ErrSessionNotFound for an ended session, got %v", err)
+	}
+}
+
+func TestSessionUpdateDiscardsStaleEnvelopesAndDefersMarker(t *testing.T) {
+	session := newSession("s", RegisterRequest{MaxBatches: 10, BufferSize: 1})
+	defer session.Close()
+
+	stale := session.settings.Load()
+	if err := session.Update(SessionUpdate{}); err != nil {
+		t.Fatalf("update failed: %v", err)
+	}
+	if streamed, _ := session.emit(model.Envelope{Signal: model.SignalLogs}, stale, 0); streamed {
+		t.Fatal("expected envelope built with replaced settings to be discarded")
+	}
+	if session.DroppedBatches() != 0 {
+		t.Fatal("discarded stale envelope must not count as dropped")
+	}
+
+	// The queue holds the first marker; the second one has to wait for room.
+	if err := session.Update(SessionUpdate{}); err != nil {
+		t.Fatalf("update failed: %v", err)
+	}
+	if first := <-session.Events(); first.Type != model.EnvelopeTypeFilterUpdated || first.BatchIndex != 1 {
+		t.Fatalf("expected first marker, got %+v", first)
+	}
+	if streamed, _ := session.Emit(model.Envelope{Signal: model.SignalLogs}); streamed {
+		t.Fatal("expected envelope to be dropped while the deferred marker fills the queue")
+	}
+	if second := <-session.Events(); second.Type != model.EnvelopeTypeFilterUpdated || second.BatchIndex != 2 {
+		t.Fatalf("expected deferred marker, got %+v", second)
+	}
+}
@@ -11,10 +11,23 @@ const typeStr = "otellens"
 
 // Config configures the otellens exporter behavior.
 type Config struct {
-	HTTPAddr              string        `mapstructure:"http_addr"`
+	HTTPAddr string `mapstructure:"http_addr"`
+	// GRPCAddr enables the gRPC capture service on this address; empty disables it.
+	GRPCAddr              string        `mapstructure:"grpc_addr"`
 	MaxConcurrentSessions int           `mapstructure:"max_concurrent_sessions"`
 	DefaultSessionTimeout time.Duration `mapstructure:"default_session_timeout"`
 	SessionBufferSize     int           `mapstructure:"session_buffer_size"`
+	// WebSocketAllowedOrigins lists the cross-origin pages allowed to open /v1/ws; "*" allows any.
+	WebSocketAllowedOrigins []string `mapstructure:"websocket_allowed_origins"`
+	// FlightRecorder keeps recent batches for sessions that request include_history_seconds.
+	FlightRecorder FlightRecorderConfig `mapstructure:"flight_recorder"`
+}
+
+// FlightRecorderConfig bounds the per-signal history of recent batches.
+type FlightRecorderConfig struct {
+	// MaxBytes approximately bounds the memory held per signal; 0 disables the flight recorder.
+	MaxBytes int64         `mapstructure:"max_bytes"`
+	MaxAge   time.Duration `mapstructure:"max_age"`
 }
 
 var _ component.Config = (*Config)(nil)
@@ -25,6 +38,9 @@ func createDefaultConfig() component.Config {
 		MaxConcurrentSessions: 256,
 		DefaultSessionTimeout: 30 * time.Second,
 		SessionBufferSize:     64,
+		FlightRecorder: FlightRecorderConfig{
+			MaxAge: time.Minute,
+		},
 	}
 }
 
@@ -33,6 +49,9 @@ func (cfg *Config) Validate() error {
 	if cfg.HTTPAddr == "" {
 		return fmt.Errorf("http_addr must be set")
 	}
+	if cfg.GRPCAddr != "" && cfg.GRPCAddr == cfg.HTTPAddr {
+		return fmt.Errorf("grpc_addr must differ from http_addr")
+	}
 	if cfg.MaxConcurrentSessions <= 0 {
 		return fmt.Errorf("max_concurrent_sessions must be > 0")
 	}
@@ -42,5 +61,11 @@ func (cfg *Config) Validate() error {
 	if cfg.SessionBufferSize <= 0 {
 		return fmt.Errorf("session_buffer_size must be > 0")
 	}
+	if cfg.FlightRecorder.MaxBytes < 0 {
+		return fmt.Errorf("flight_recorder.max_bytes must be >= 0")
+	}
+	if cfg.FlightRecorder.MaxBytes > 0 && cfg.FlightRecorder.MaxAge <= 0 {
+		return fmt.Errorf("flight_recorder.max_age must be > 0")
+	}
 	return nil
 }
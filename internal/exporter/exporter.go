@@ -14,8 +14,12 @@ type sinkExporter struct {
 	runtime *runtime
 }
 
-func newSinkExporter(cfg *Config) *sinkExporter {
-	return &sinkExporter{runtime: acquireRuntime(cfg)}
+func newSinkExporter(cfg *Config) (*sinkExporter, error) {
+	rt, err := acquireRuntime(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &sinkExporter{runtime: rt}, nil
 }
 
 func (e *sinkExporter) start(context.Context, component.Host) error {
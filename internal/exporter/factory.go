@@ -21,7 +21,10 @@ func NewFactory() exporter.Factory {
 }
 
 func createTracesExporter(ctx context.Context, set exporter.Settings, cfg component.Config) (exporter.Traces, error) {
-	exp := newSinkExporter(cfg.(*Config))
+	exp, err := newSinkExporter(cfg.(*Config))
+	if err != nil {
+		return nil, err
+	}
 	return exporterhelper.NewTraces(
 		ctx,
 		set,
@@ -34,7 +37,10 @@ func createTracesExporter(ctx context.Context, set exporter.Settings, cfg compon
 }
 
 func createMetricsExporter(ctx context.Context, set exporter.Settings, cfg component.Config) (exporter.Metrics, error) {
-	exp := newSinkExporter(cfg.(*Config))
+	exp, err := newSinkExporter(cfg.(*Config))
+	if err != nil {
+		return nil, err
+	}
 	return exporterhelper.NewMetrics(
 		ctx,
 		set,
@@ -47,7 +53,10 @@ func createMetricsExporter(ctx context.Context, set exporter.Settings, cfg compo
 }
 
 func createLogsExporter(ctx context.Context, set exporter.Settings, cfg component.Config) (exporter.Logs, error) {
-	exp := newSinkExporter(cfg.(*Config))
+	exp, err := newSinkExporter(cfg.(*Config))
+	if err != nil {
+		return nil, err
+	}
 	return exporterhelper.NewLogs(
 		ctx,
 		set,
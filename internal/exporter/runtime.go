@@ -2,14 +2,18 @@ package exporter
 
 import (
 	"context"
+	"fmt"
+	"net"
 	"net/http"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/utrack/otellens/internal/capture"
+	"github.com/utrack/otellens/internal/grpcapi"
 	"github.com/utrack/otellens/internal/httpapi"
 	"go.uber.org/zap"
+	"google.golang.org/grpc"
 )
 
 type runtime struct {
@@ -17,6 +21,8 @@ type runtime struct {
 	registry *capture.Registry
 	logger   *zap.Logger
 	server   *http.Server
+	// grpcServer serves the capture service on cfg.GRPCAddr; nil when it is disabled.
+	grpcServer *grpc.Server
 
 	refs atomic.Int64
 
@@ -32,15 +38,24 @@ var (
 	runtimes   = make(map[string]*runtime)
 )
 
-func acquireRuntime(cfg *Config) *runtime {
+// acquireRuntime returns the runtime serving cfg.HTTPAddr, creating it for the first exporter.
+// Exporters sharing http_addr share the servers, so they must agree on grpc_addr.
+func acquireRuntime(cfg *Config) (*runtime, error) {
 	runtimesMu.Lock()
 	defer runtimesMu.Unlock()
 
 	rt, ok := runtimes[cfg.HTTPAddr]
+	if ok && rt.cfg.GRPCAddr != cfg.GRPCAddr {
+		return nil, fmt.Errorf("exporters sharing http_addr %q must set the same grpc_addr, got %q and %q",
+			cfg.HTTPAddr, rt.cfg.GRPCAddr, cfg.GRPCAddr)
+	}
 	if !ok {
 		logger := zap.NewNop()
-		registry := capture.NewRegistry(cfg.MaxConcurrentSessions)
-		handler := httpapi.NewHandler(registry, logger)
+		registry := capture.NewRegistry(cfg.MaxConcurrentSessions, capture.WithFlightRecorder(capture.FlightRecorderConfig{
+			MaxBytes: cfg.FlightRecorder.MaxBytes,
+			MaxAge:   cfg.FlightRecorder.MaxAge,
+		}))
+		handler := httpapi.NewHandler(registry, logger, httpapi.WithAllowedOrigins(cfg.WebSocketAllowedOrigins...))
 		mux := http.NewServeMux()
 		handler.RegisterRoutes(mux)
 
@@ -54,22 +69,49 @@ func acquireRuntime(cfg *Config) *runtime {
 				ReadHeaderTimeout: 5 * time.Second,
 			},
 		}
+		if cfg.GRPCAddr != "" {
+			rt.grpcServer = grpc.NewServer()
+			grpcapi.NewService(registry, logger).RegisterService(rt.grpcServer)
+		}
 		runtimes[cfg.HTTPAddr] = rt
 	}
 	if cfg.MaxConcurrentSessions > rt.cfg.MaxConcurrentSessions {
 		rt.cfg.MaxConcurrentSessions = cfg.MaxConcurrentSessions
 	}
 	rt.refs.Add(1)
-	return rt
+	return rt, nil
 }
 
+// start binds the listeners before serving in the background, so an unusable address fails the start.
 func (r *runtime) start() error {
 	r.startOnce.Do(func() {
+		listener, err := net.Listen("tcp", r.server.Addr)
+		if err != nil {
+			r.startErr = fmt.Errorf("listen on http_addr: %w", err)
+			return
+		}
+		var grpcListener net.Listener
+		if r.grpcServer != nil {
+			grpcListener, err = net.Listen("tcp", r.cfg.GRPCAddr)
+			if err != nil {
+				_ = listener.Close()
+				r.startErr = fmt.Errorf("listen on grpc_addr: %w", err)
+				return
+			}
+		}
+
 		go func() {
-			if err := r.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			if err := r.server.Serve(listener); err != nil && err != http.ErrServerClosed {
 				r.logger.Error("otellens API server failed", zap.Error(err), zap.String("addr", r.server.Addr))
 			}
 		}()
+		if grpcListener != nil {
+			go func() {
+				if err := r.grpcServer.Serve(grpcListener); err != nil {
+					r.logger.Error("otellens gRPC server failed", zap.Error(err), zap.String("addr", r.cfg.GRPCAddr))
+				}
+			}()
+		}
 	})
 	return r.startErr
 }
@@ -80,7 +122,12 @@ func (r *runtime) release(ctx context.Context) error {
 	}
 
 	r.shutdownOnce.Do(func() {
+		// Ending the sessions first lets their streams finish with a StreamEnd instead of being cut off.
+		r.registry.Shutdown()
 		r.shutdownErr = r.server.Shutdown(ctx)
+		if r.grpcServer != nil {
+			stopGRPC(ctx, r.grpcServer)
+		}
 		runtimesMu.Lock()
 		delete(runtimes, r.server.Addr)
 		runtimesMu.Unlock()
@@ -88,3 +135,18 @@ func (r *runtime) release(ctx context.Context) error {
 
 	return r.shutdownErr
 }
+
+// stopGRPC waits for running capture streams to finish until ctx is done, then closes them.
+func stopGRPC(ctx context.Context, server *grpc.Server) {
+	stopped := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		server.Stop()
+		<-stopped
+	}
+}
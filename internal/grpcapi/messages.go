@@ -0,0 +1,228 @@
+package grpcapi
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/utrack/otellens/internal/model"
+	"github.com/utrack/otellens/internal/request"
+	otellensv1 "github.com/utrack/otellens/proto/otellens/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+)
+
+// streamRequest converts otellens.v1.StreamRequest into the shared session definition.
+func streamRequest(in *otellensv1.StreamRequest) (request.StreamRequest, error) {
+	filters, err := attributeFilters(in.GetAttributeFilters())
+	if err != nil {
+		return request.StreamRequest{}, err
+	}
+	req := request.StreamRequest{
+		MetricNames:               in.GetMetricNames(),
+		SpanNames:                 in.GetSpanNames(),
+		AttributeNames:            in.GetAttributeNames(),
+		LogBodyContains:           in.GetLogBodyContains(),
+		MinSeverityNumber:         in.GetMinSeverityNumber(),
+		ResourceAttributes:        in.GetResourceAttributes(),
+		AttributeFilters:          filters,
+		Where:                     in.GetWhere(),
+		SpanKinds:                 in.GetSpanKinds(),
+		SpanStatusCodes:           in.GetSpanStatusCodes(),
+		SpanStatusMessageContains: in.GetSpanStatusMessageContains(),
+		MinSpanDurationMs:         in.GetMinSpanDurationMs(),
+		MaxSpanDurationMs:         in.GetMaxSpanDurationMs(),
+		RootSpansOnly:             in.GetRootSpansOnly(),
+		TraceIDs:                  in.GetTraceIds(),
+		SpanIDs:                   in.GetSpanIds(),
+		HasExemplars:              in.GetHasExemplars(),
+		ExemplarTraceIDs:          in.GetExemplarTraceIds(),
+		BucketCountsCount:         optionalInt(in.BucketCountsCount),
+		ExplicitBoundsCount:       optionalInt(in.ExplicitBoundsCount),
+		ExponentialScale:          optionalInt(in.ExponentialScale),
+		PositiveBucketCountsCount: optionalInt(in.PositiveBucketCountsCount),
+		NegativeBucketCountsCount: optionalInt(in.NegativeBucketCountsCount),
+		MaxBatches:                int(in.GetMaxBatches()),
+		TimeoutSeconds:            int(in.GetTimeoutSeconds()),
+		Label:                     in.GetLabel(),
+		IncludeHistorySeconds:     int(in.GetIncludeHistorySeconds()),
+		SampleRate:                in.GetSampleRate(),
+		SampleEveryN:              int(in.GetSampleEveryN()),
+		MaxBatchesPerSecond:       in.GetMaxBatchesPerSecond(),
+		MaxRecordsPerSecond:       in.GetMaxRecordsPerSecond(),
+		MaxBytesPerSecond:         in.GetMaxBytesPerSecond(),
+		MaxRecords:                int(in.GetMaxRecords()),
+		MaxBytes:                  in.GetMaxBytes(),
+	}
+	for _, signal := range in.GetSignals() {
+		req.Signals = append(req.Signals, model.SignalType(signal))
+	}
+	if trigger := in.GetTrigger(); trigger != nil {
+		filter, err := streamRequest(trigger.GetFilter())
+		if err != nil {
+			return request.StreamRequest{}, fmt.Errorf("trigger: %w", err)
+		}
+		req.Trigger = &request.TriggerRequest{StreamRequest: filter, CaptureSeconds: int(trigger.GetCaptureSeconds())}
+	}
+	return req, nil
+}
+
+func attributeFilters(in []*otellensv1.AttributeFilter) ([]request.AttributeFilter, error) {
+	if len(in) == 0 {
+		return nil, nil
+	}
+	out := make([]request.AttributeFilter, 0, len(in))
+	for i, filter := range in {
+		value, err := anyValue(filter.GetValue())
+		if err != nil {
+			return nil, fmt.Errorf("attribute_filters[%d]: %w", i, err)
+		}
+		var values []interface{}
+		for _, v := range filter.GetValues() {
+			value, err := anyValue(v)
+			if err != nil {
+				return nil, fmt.Errorf("attribute_filters[%d]: %w", i, err)
+			}
+			values = append(values, value)
+		}
+		out = append(out, request.AttributeFilter{
+			Key:    filter.GetKey(),
+			Level:  filter.GetLevel(),
+			Op:     filter.GetOp(),
+			Value:  value,
+			Values: values,
+		})
+	}
+	return out, nil
+}
+
+// anyValue converts the scalar variants of an OTLP AnyValue; nil stays nil.
+func anyValue(v *commonpb.AnyValue) (interface{}, error) {
+	switch value := v.GetValue().(type) {
+	case nil:
+		return nil, nil
+	case *commonpb.AnyValue_StringValue:
+		return value.StringValue, nil
+	case *commonpb.AnyValue_BoolValue:
+		return value.BoolValue, nil
+	case *commonpb.AnyValue_IntValue:
+		return value.IntValue, nil
+	case *commonpb.AnyValue_DoubleValue:
+		return value.DoubleValue, nil
+	default:
+		return nil, errors.New("only scalar attribute values are supported")
+	}
+}
+
+func optionalInt(v *int32) *int {
+	if v == nil {
+		return nil
+	}
+	out := int(*v)
+	return &out
+}
+
+// captureEvent converts a session envelope; marker envelopes become their own event variant.
+func captureEvent(envelope model.Envelope) (*otellensv1.CaptureEvent, error) {
+	switch envelope.Type {
+	case "":
+		out, err := envelopeMessage(envelope)
+		if err != nil {
+			return nil, err
+		}
+		return &otellensv1.CaptureEvent{Event: &otellensv1.CaptureEvent_Envelope{Envelope: out}}, nil
+	case model.EnvelopeTypeFilterUpdated:
+		return &otellensv1.CaptureEvent{Event: &otellensv1.CaptureEvent_FilterUpdated{FilterUpdated: &otellensv1.FilterUpdated{
+			SessionId:         envelope.SessionID,
+			BatchIndex:        envelope.BatchIndex,
+			UpdatedAtUnixNano: unixNano(envelope.CapturedAt),
+		}}}, nil
+	case model.EnvelopeTypeTriggered:
+		return &otellensv1.CaptureEvent{Event: &otellensv1.CaptureEvent_Triggered{Triggered: &otellensv1.Triggered{
+			SessionId:           envelope.SessionID,
+			BatchIndex:          envelope.BatchIndex,
+			Signal:              string(envelope.Signal),
+			TriggeredAtUnixNano: unixNano(envelope.CapturedAt),
+		}}}, nil
+	case model.EnvelopeTypeRateLimited:
+		out := &otellensv1.RateLimited{
+			SessionId:          envelope.SessionID,
+			BatchIndex:         envelope.BatchIndex,
+			ReportedAtUnixNano: unixNano(envelope.CapturedAt),
+		}
+		if suppressed, ok := envelope.Payload.(*model.RateLimited); ok {
+			out.Batches = suppressed.Batches
+			out.Records = suppressed.Records
+			out.Bytes = suppressed.Bytes
+		}
+		return &otellensv1.CaptureEvent{Event: &otellensv1.CaptureEvent_RateLimited{RateLimited: out}}, nil
+	default:
+		return nil, fmt.Errorf("unknown envelope type %q", envelope.Type)
+	}
+}
+
+// envelopeMessage carries the binary OTLP payload of an envelope as is; clients decode it.
+func envelopeMessage(envelope model.Envelope) (*otellensv1.Envelope, error) {
+	payload, ok := envelope.Payload.([]byte)
+	if !ok {
+		return nil, fmt.Errorf("unexpected payload type %T for protobuf envelope", envelope.Payload)
+	}
+	out := &otellensv1.Envelope{
+		SessionId:          envelope.SessionID,
+		Signal:             string(envelope.Signal),
+		BatchIndex:         envelope.BatchIndex,
+		CapturedAtUnixNano: unixNano(envelope.CapturedAt),
+		History:            envelope.History,
+		Records:            uint32(envelope.Records),
+	}
+	if envelope.Sampling != nil {
+		out.Sampling = &otellensv1.Sampling{Rate: envelope.Sampling.Rate, EveryN: uint32(envelope.Sampling.EveryN)}
+	}
+
+	switch envelope.Signal {
+	case model.SignalMetrics:
+		out.Payload = &otellensv1.Envelope_Metrics{Metrics: payload}
+	case model.SignalTraces:
+		out.Payload = &otellensv1.Envelope_Traces{Traces: payload}
+	case model.SignalLogs:
+		out.Payload = &otellensv1.Envelope_Logs{Logs: payload}
+	default:
+		return nil, fmt.Errorf("unknown signal %q", envelope.Signal)
+	}
+	return out, nil
+}
+
+func streamEnd(end model.StreamEnd) *otellensv1.CaptureEvent {
+	out := &otellensv1.StreamEnd{
+		SessionId:          end.SessionID,
+		Sent:               end.Sent,
+		Dropped:            end.Dropped,
+		PausedSeconds:      end.PausedSeconds,
+		RateLimited:        end.RateLimited,
+		RateLimitedRecords: end.RateLimitedRecords,
+		LimitReached:       end.LimitReached,
+		Reason:             end.Reason,
+		DurationSeconds:    end.DurationSeconds,
+		BytesWritten:       end.BytesWritten,
+	}
+	if len(end.Signals) > 0 {
+		out.Signals = make(map[string]*otellensv1.SignalStats, len(end.Signals))
+		for signal, stats := range end.Signals {
+			out.Signals[string(signal)] = &otellensv1.SignalStats{Matched: stats.Matched, Sent: stats.Sent, Dropped: stats.Dropped}
+		}
+	}
+	if end.FirstCapturedAt != nil {
+		out.FirstCapturedAtUnixNano = unixNano(*end.FirstCapturedAt)
+	}
+	if end.LastCapturedAt != nil {
+		out.LastCapturedAtUnixNano = unixNano(*end.LastCapturedAt)
+	}
+	return &otellensv1.CaptureEvent{Event: &otellensv1.CaptureEvent_End{End: out}}
+}
+
+// unixNano returns 0 for the zero time.
+func unixNano(t time.Time) uint64 {
+	if t.IsZero() {
+		return 0
+	}
+	return uint64(t.UnixNano())
+}
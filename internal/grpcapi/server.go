@@ -0,0 +1,101 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/utrack/otellens/internal/capture"
+	"github.com/utrack/otellens/internal/model"
+	"github.com/utrack/otellens/internal/request"
+	otellensv1 "github.com/utrack/otellens/proto/otellens/v1"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	defaultSessionTimeout = 30 * time.Second
+
+	// sessionIDHeader carries the session ID in the response header metadata.
+	sessionIDHeader = "otellens-session-id"
+)
+
+// Service implements otellens.v1.Capture on top of a capture registry.
+type Service struct {
+	otellensv1.UnimplementedCaptureServer
+
+	registry *capture.Registry
+	logger   *zap.Logger
+}
+
+func NewService(registry *capture.Registry, logger *zap.Logger) *Service {
+	return &Service{registry: registry, logger: logger}
+}
+
+// RegisterService registers the capture service on server.
+func (s *Service) RegisterService(server *grpc.Server) {
+	otellensv1.RegisterCaptureServer(server, s)
+}
+
+// Stream registers a session and sends its envelopes followed by the terminal StreamEnd.
+// Payloads are always binary OTLP, so format-related request fields are not part of the message.
+func (s *Service) Stream(in *otellensv1.StreamRequest, stream otellensv1.Capture_StreamServer) error {
+	req, err := streamRequest(in)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err := request.Validate(req); err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	if req.IncludeHistorySeconds > 0 && !s.registry.FlightRecorderEnabled() {
+		return status.Error(codes.FailedPrecondition, request.ErrFlightRecorderDisabled.Error())
+	}
+	req.Format = model.FormatOTLPProto
+
+	timeout := defaultSessionTimeout
+	if req.TimeoutSeconds > 0 {
+		timeout = time.Duration(req.TimeoutSeconds) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(stream.Context(), timeout)
+	defer cancel()
+
+	var remoteAddr string
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		remoteAddr = p.Addr.String()
+	}
+	registerReq, err := request.RegisterRequest(req, remoteAddr)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	session, err := s.registry.Register(ctx, registerReq)
+	if err != nil {
+		if errors.Is(err, capture.ErrSessionLimitReached) {
+			return status.Error(codes.ResourceExhausted, err.Error())
+		}
+		return status.Error(codes.Internal, err.Error())
+	}
+	// A session that is still running when the stream returns lost its client.
+	defer s.registry.End(session.ID(), model.EndReasonClientDisconnect)
+
+	if err := stream.SendHeader(metadata.Pairs(sessionIDHeader, session.ID())); err != nil {
+		return err
+	}
+
+	for event := range session.Events() {
+		out, err := captureEvent(event)
+		if err == nil {
+			err = stream.Send(out)
+		}
+		if err != nil {
+			s.logger.Debug("failed to stream event", zap.Error(err), zap.String("session_id", session.ID()))
+			return err
+		}
+		session.AddBytesWritten(proto.Size(out))
+	}
+	return stream.Send(streamEnd(session.StreamEnd()))
+}
@@ -0,0 +1,191 @@
+package grpcapi
+
+import (
+	"context"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/utrack/otellens/internal/capture"
+	otellensv1 "github.com/utrack/otellens/proto/otellens/v1"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+)
+
+func newTestClient(t *testing.T, registry *capture.Registry) otellensv1.CaptureClient {
+	t.Helper()
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	NewService(registry, zap.NewNop()).RegisterService(server)
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return otellensv1.NewCaptureClient(conn)
+}
+
+func TestStreamSendsOTLPEnvelopesAndEnd(t *testing.T) {
+	registry := capture.NewRegistry(4)
+	client := newTestClient(t, registry)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	stream, err := client.Stream(ctx, &otellensv1.StreamRequest{
+		Signals:     []string{"metrics"},
+		MetricNames: []string{"A"},
+		MaxBatches:  1,
+		Label:       "grpc-test",
+	})
+	if err != nil {
+		t.Fatalf("open stream: %v", err)
+	}
+
+	header, err := stream.Header()
+	if err != nil {
+		t.Fatalf("header: %v", err)
+	}
+	ids := header.Get(sessionIDHeader)
+	if len(ids) != 1 {
+		t.Fatalf("expected session ID header, got %v", header)
+	}
+	session, ok := registry.Session(ids[0])
+	if !ok || session.Info().Label != "grpc-test" {
+		t.Fatalf("expected registered session with label, got %v", ok)
+	}
+
+	registry.PublishMetrics(newMetricsBatch("B", "A"))
+
+	event, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("recv envelope: %v", err)
+	}
+	envelope := event.GetEnvelope()
+	if envelope == nil || envelope.GetSessionId() != ids[0] || envelope.GetSignal() != "metrics" || envelope.GetBatchIndex() != 1 {
+		t.Fatalf("unexpected envelope %v", event)
+	}
+	md, err := (&pmetric.ProtoUnmarshaler{}).UnmarshalMetrics(envelope.GetMetrics())
+	if err != nil {
+		t.Fatalf("decode payload: %v", err)
+	}
+	if md.ResourceMetrics().Len() != 1 || md.ResourceMetrics().At(0).ScopeMetrics().Len() != 1 {
+		t.Fatalf("expected one resource and scope in the payload, got %d resources", md.ResourceMetrics().Len())
+	}
+	if metrics := md.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics(); metrics.Len() != 1 || metrics.At(0).Name() != "A" {
+		t.Fatalf("expected only metric A in payload, got %d metrics", metrics.Len())
+	}
+
+	event, err = stream.Recv()
+	if err != nil {
+		t.Fatalf("recv end: %v", err)
+	}
+	end := event.GetEnd()
+	if end == nil || end.GetSessionId() != ids[0] || end.GetSent() != 1 || end.GetBytesWritten() != uint64(proto.Size(&otellensv1.CaptureEvent{Event: &otellensv1.CaptureEvent_Envelope{Envelope: envelope}})) {
+		t.Fatalf("unexpected end %v", event)
+	}
+	if _, err := stream.Recv(); err != io.EOF {
+		t.Fatalf("expected stream to close after end, got %v", err)
+	}
+}
+
+func TestStreamRejectsInvalidRequests(t *testing.T) {
+	registry := capture.NewRegistry(1)
+	client := newTestClient(t, registry)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	stream, err := client.Stream(ctx, &otellensv1.StreamRequest{Signals: []string{"metrics"}})
+	if err == nil {
+		_, err = stream.Recv()
+	}
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument without max_batches, got %v", err)
+	}
+
+	valid := &otellensv1.StreamRequest{Signals: []string{"metrics"}, MaxBatches: 5}
+	first, err := client.Stream(ctx, valid)
+	if err == nil {
+		_, err = first.Header()
+	}
+	if err != nil {
+		t.Fatalf("header: %v", err)
+	}
+	second, err := client.Stream(ctx, valid)
+	if err == nil {
+		_, err = second.Recv()
+	}
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("expected ResourceExhausted beyond the session limit, got %v", err)
+	}
+}
+
+func TestStreamRequestConvertsAllFieldKinds(t *testing.T) {
+	scale := int32(-3)
+	req, err := streamRequest(&otellensv1.StreamRequest{
+		ResourceAttributes: map[string]string{"service.name": "checkout"},
+		AttributeFilters: []*otellensv1.AttributeFilter{{
+			Key:   "http.status_code",
+			Op:    "gte",
+			Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: 500}},
+		}},
+		MinSpanDurationMs: 100,
+		ExponentialScale:  &scale,
+		Trigger: &otellensv1.Trigger{
+			Filter:         &otellensv1.StreamRequest{Signals: []string{"logs"}},
+			CaptureSeconds: 30,
+		},
+	})
+	if err != nil {
+		t.Fatalf("convert: %v", err)
+	}
+	if req.ResourceAttributes["service.name"] != "checkout" {
+		t.Fatalf("unexpected resource attributes %v", req.ResourceAttributes)
+	}
+	if len(req.AttributeFilters) != 1 || req.AttributeFilters[0].Op != "gte" || req.AttributeFilters[0].Value != int64(500) {
+		t.Fatalf("unexpected attribute filters %+v", req.AttributeFilters)
+	}
+	if req.MinSpanDurationMs != 100 {
+		t.Fatalf("unexpected min_span_duration_ms %v", req.MinSpanDurationMs)
+	}
+	if req.ExponentialScale == nil || *req.ExponentialScale != -3 {
+		t.Fatalf("unexpected exponential_scale %v", req.ExponentialScale)
+	}
+	if req.Trigger == nil || len(req.Trigger.Signals) != 1 || req.Trigger.Signals[0] != "logs" || req.Trigger.CaptureSeconds != 30 {
+		t.Fatalf("unexpected trigger %+v", req.Trigger)
+	}
+
+	_, err = streamRequest(&otellensv1.StreamRequest{AttributeFilters: []*otellensv1.AttributeFilter{{
+		Key:   "k",
+		Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_ArrayValue{ArrayValue: &commonpb.ArrayValue{}}},
+	}}})
+	if err == nil || !strings.Contains(err.Error(), "attribute_filters[0]") {
+		t.Fatalf("expected an error for a non-scalar attribute value, got %v", err)
+	}
+}
+
+func newMetricsBatch(names ...string) pmetric.Metrics {
+	md := pmetric.NewMetrics()
+	sm := md.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty()
+	for _, name := range names {
+		metric := sm.Metrics().AppendEmpty()
+		metric.SetName(name)
+		metric.SetEmptyGauge().DataPoints().AppendEmpty().SetDoubleValue(1)
+	}
+	return md
+}
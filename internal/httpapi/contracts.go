@@ -1,24 +1,60 @@
 package httpapi
 
-import "github.com/utrack/otellens/internal/model"
+import (
+	"time"
 
-// StreamRequest defines filters for one on-demand capture session.
-type StreamRequest struct {
-	Signals             []model.SignalType `json:"signals"`
-	MetricNames         []string           `json:"metric_names"`
-	SpanNames           []string           `json:"span_names"`
-	AttributeNames      []string           `json:"attribute_names"`
-	LogBodyContains     string             `json:"log_body_contains"`
-	MinSeverityNumber   int32              `json:"min_severity_number"`
-	ResourceAttributes  map[string]string  `json:"resource_attributes"`
-	BucketCountsCount   *int               `json:"bucket_counts_count"`
-	ExplicitBoundsCount *int               `json:"explicit_bounds_count"`
-	VerboseMetrics      bool               `json:"verbose_metrics"`
-	MaxBatches          int                `json:"max_batches"`
-	TimeoutSeconds      int                `json:"timeout_seconds"`
-}
+	"github.com/utrack/otellens/internal/request"
+)
 
 // StreamError is serialized for API-level failures.
 type StreamError struct {
 	Error string `json:"error"`
+	// Position is the 1-based character offset of a `where` expression parse error.
+	Position int `json:"position,omitempty"`
+}
+
+// SessionView describes one active capture session.
+type SessionView struct {
+	ID             string                `json:"id"`
+	Label          string                `json:"label,omitempty"`
+	RemoteAddr     string                `json:"remote_addr,omitempty"`
+	StartedAt      time.Time             `json:"started_at"`
+	Deadline       *time.Time            `json:"deadline,omitempty"`
+	Filter         request.StreamRequest `json:"filter"`
+	SentBatches    uint64                `json:"sent_batches"`
+	DroppedBatches uint64                `json:"dropped_batches"`
+	SentRecords    uint64                `json:"sent_records"`
+	// SentBytes is only measured for sessions with max_bytes or max_bytes_per_second.
+	SentBytes     uint64 `json:"sent_bytes,omitempty"`
+	QueueDepth    int    `json:"queue_depth"`
+	QueueCapacity int    `json:"queue_capacity"`
+	// Detachable sessions are created via POST /v1/sessions and consumed via GET /v1/sessions/{id}/stream.
+	Detachable bool `json:"detachable"`
+	Attached   bool `json:"attached"`
+	// Paused sessions skip incoming batches until resumed; PausedSeconds includes a pause in progress.
+	Paused        bool    `json:"paused"`
+	PausedSeconds float64 `json:"paused_seconds"`
+	// Armed sessions wait for their trigger; TriggeredAt is set once it fired.
+	Armed       bool       `json:"armed"`
+	TriggeredAt *time.Time `json:"triggered_at,omitempty"`
+}
+
+// SessionList is the response of the session listing endpoint.
+type SessionList struct {
+	Sessions []SessionView `json:"sessions"`
+}
+
+// WSClientMessage is one control message sent by a client on /v1/ws.
+// Type is one of start, update, pause, resume or stop; start and update carry the session definition in Request.
+type WSClientMessage struct {
+	Type    string                 `json:"type"`
+	Request *request.StreamRequest `json:"request,omitempty"`
+}
+
+// WSServerMessage is one message sent to a client on /v1/ws.
+// Type is one of started, updated, paused, resumed, envelope, filter_updated, triggered, end, heartbeat or error; Data holds
+// the matching payload (SessionView, model.Envelope, model.StreamEnd, model.Heartbeat or StreamError).
+type WSServerMessage struct {
+	Type string      `json:"type"`
+	Data interface{} `json:"data"`
 }
@@ -5,25 +5,56 @@ import (
 	"encoding/json"
 	"errors"
 	"net/http"
-	"strings"
 	"time"
 
 	"github.com/utrack/otellens/internal/capture"
 	"github.com/utrack/otellens/internal/model"
-	"go.opentelemetry.io/collector/pdata/plog"
+	"github.com/utrack/otellens/internal/request"
 	"go.uber.org/zap"
 )
 
-const defaultSessionTimeout = 30 * time.Second
+const (
+	defaultSessionTimeout = 30 * time.Second
+
+	defaultHeartbeatInterval = 15 * time.Second
+)
 
 // Handler exposes HTTP endpoints for live capture sessions.
 type Handler struct {
 	registry *capture.Registry
 	logger   *zap.Logger
+
+	// heartbeatInterval paces keep-alive events on streams that support them (SSE).
+	heartbeatInterval time.Duration
+	// allowedOrigins are the cross-origin pages allowed to open WebSockets, see WithAllowedOrigins.
+	allowedOrigins map[string]struct{}
+}
+
+// HandlerOption configures optional handler features.
+type HandlerOption func(*Handler)
+
+// WithAllowedOrigins lets pages served from origins (e.g. "https://ui.example.com") open WebSocket
+// captures; "*" allows any origin. Same-origin pages and clients without an Origin header are
+// always allowed.
+func WithAllowedOrigins(origins ...string) HandlerOption {
+	return func(h *Handler) {
+		for _, origin := range origins {
+			h.allowedOrigins[origin] = struct{}{}
+		}
+	}
 }
 
-func NewHandler(registry *capture.Registry, logger *zap.Logger) *Handler {
-	return &Handler{registry: registry, logger: logger}
+func NewHandler(registry *capture.Registry, logger *zap.Logger, opts ...HandlerOption) *Handler {
+	h := &Handler{
+		registry:          registry,
+		logger:            logger,
+		heartbeatInterval: defaultHeartbeatInterval,
+		allowedOrigins:    make(map[string]struct{}),
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
 }
 
 // RegisterRoutes registers HTTP routes for the API server.
@@ -31,6 +62,9 @@ func (h *Handler) RegisterRoutes(mux *http.ServeMux) {
 	mux.HandleFunc("/", h.handleRoot)
 	mux.HandleFunc("/ui", h.handleUI)
 	mux.HandleFunc("/v1/capture/stream", h.handleStream)
+	mux.HandleFunc(sessionsPath, h.handleSessions)
+	mux.HandleFunc(sessionsPath+"/", h.handleSession)
+	mux.HandleFunc(websocketPath, h.handleWebSocket)
 	mux.HandleFunc("/healthz", h.handleHealth)
 }
 
@@ -45,13 +79,13 @@ func (h *Handler) handleStream(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var req StreamRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.writeErr(w, http.StatusBadRequest, "invalid JSON body")
+	req, registerReq, ok := h.decodeStreamRequest(w, r)
+	if !ok {
 		return
 	}
-	if err := validateRequest(req); err != nil {
-		h.writeErr(w, http.StatusBadRequest, err.Error())
+	sse := accepts(r, sseMediaType)
+	if sse && registerReq.Format == model.FormatOTLPProto {
+		h.writeErr(w, http.StatusNotAcceptable, "binary sessions cannot be streamed as server-sent events")
 		return
 	}
 
@@ -66,21 +100,13 @@ func (h *Handler) handleStream(w http.ResponseWriter, r *http.Request) {
 		defer cancel()
 	}
 
-	session, err := h.registry.Register(ctx, capture.RegisterRequest{
-		Filter:         requestToFilter(req),
-		VerboseMetrics: req.VerboseMetrics,
-		MaxBatches:     req.MaxBatches,
-		BufferSize:     req.MaxBatches,
-	})
+	session, err := h.registry.Register(ctx, registerReq)
 	if err != nil {
-		status := http.StatusInternalServerError
-		if errors.Is(err, capture.ErrSessionLimitReached) {
-			status = http.StatusTooManyRequests
-		}
-		h.writeErr(w, status, err.Error())
+		h.writeRegisterErr(w, err)
 		return
 	}
-	defer h.registry.Deregister(session.ID())
+	// A session that is still running when the handler returns lost its client.
+	defer h.registry.End(session.ID(), model.EndReasonClientDisconnect)
 
 	flusher, ok := w.(http.Flusher)
 	if !ok {
@@ -88,104 +114,121 @@ func (h *Handler) handleStream(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/x-ndjson")
+	stream := newSessionStreamWriter(w, session, sse)
+	w.Header().Set("Content-Type", stream.ContentType())
 	w.Header().Set("Cache-Control", "no-store")
 	w.WriteHeader(http.StatusOK)
 
-	enc := json.NewEncoder(w)
+	if !h.pump(ctx, stream, flusher, session, 0) {
+		h.registry.End(session.ID(), capture.ContextEndReason(ctx))
+		_ = stream.WriteEnd(session.StreamEnd())
+		flusher.Flush()
+	}
+}
+
+// decodeStreamRequest parses, validates and negotiates one session definition.
+// It writes the error response itself and returns false when the request is rejected.
+func (h *Handler) decodeStreamRequest(w http.ResponseWriter, r *http.Request) (request.StreamRequest, capture.RegisterRequest, bool) {
+	var req request.StreamRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeErr(w, http.StatusBadRequest, "invalid JSON body")
+		return request.StreamRequest{}, capture.RegisterRequest{}, false
+	}
+	if err := request.Validate(req); err != nil {
+		h.writeErr(w, http.StatusBadRequest, err.Error())
+		return request.StreamRequest{}, capture.RegisterRequest{}, false
+	}
+	if req.IncludeHistorySeconds > 0 && !h.registry.FlightRecorderEnabled() {
+		h.writeErr(w, http.StatusBadRequest, request.ErrFlightRecorderDisabled.Error())
+		return request.StreamRequest{}, capture.RegisterRequest{}, false
+	}
+	if accepts(r, protobufStreamMediaType) {
+		if req.Format != "" {
+			h.writeErr(w, http.StatusBadRequest, "format cannot be combined with Accept: "+protobufStreamMediaType)
+			return request.StreamRequest{}, capture.RegisterRequest{}, false
+		}
+		req.Format = model.FormatOTLPProto
+	}
+	registerReq, err := request.RegisterRequest(req, r.RemoteAddr)
+	if err != nil {
+		h.writeFilterErr(w, err)
+		return request.StreamRequest{}, capture.RegisterRequest{}, false
+	}
+	return req, registerReq, true
+}
+
+// pump streams session events until the session ends or ctx is done.
+// Events with BatchIndex <= lastSent were already delivered by a replay and are skipped.
+// It returns true once the session ended and the terminal event was written.
+func (h *Handler) pump(ctx context.Context, stream streamWriter, flusher http.Flusher, session *capture.Session, lastSent uint64) bool {
+	var heartbeat <-chan time.Time
+	heartbeats, ok := stream.(heartbeatWriter)
+	if ok && h.heartbeatInterval > 0 {
+		ticker := time.NewTicker(h.heartbeatInterval)
+		defer ticker.Stop()
+		heartbeat = ticker.C
+	}
+
 	for {
 		select {
 		case <-ctx.Done():
-			_ = enc.Encode(model.StreamEnd{
-				Type:      "end",
-				SessionID: session.ID(),
-				Sent:      session.SentBatches(),
-				Dropped:   session.DroppedBatches(),
-			})
+			return false
+		case now := <-heartbeat:
+			if err := heartbeats.WriteHeartbeat(model.Heartbeat{Type: "heartbeat", SessionID: session.ID(), Time: now.UTC()}); err != nil {
+				return false
+			}
 			flusher.Flush()
-			return
 		case event, ok := <-session.Events():
 			if !ok {
-				_ = enc.Encode(model.StreamEnd{
-					Type:      "end",
-					SessionID: session.ID(),
-					Sent:      session.SentBatches(),
-					Dropped:   session.DroppedBatches(),
-				})
+				_ = stream.WriteEnd(session.StreamEnd())
 				flusher.Flush()
-				return
+				return true
+			}
+			if event.BatchIndex <= lastSent {
+				continue
 			}
-			if err := enc.Encode(event); err != nil {
-				h.logger.Debug("failed to stream event", zap.Error(err), zap.String("session_id", session.ID()))
-				return
+			if err := stream.WriteEvent(event); err != nil {
+				h.writeStreamErr(stream, flusher, session, err)
+				return false
 			}
+			lastSent = event.BatchIndex
 			flusher.Flush()
 		}
 	}
 }
 
-func validateRequest(req StreamRequest) error {
-	if req.MaxBatches <= 0 {
-		return errors.New("max_batches must be > 0")
+// writeStreamErr reports a failure once the response started, on encodings that support it (SSE);
+// others can only drop the connection.
+func (h *Handler) writeStreamErr(stream streamWriter, flusher http.Flusher, session *capture.Session, err error) {
+	h.logger.Debug("failed to stream event", zap.Error(err), zap.String("session_id", session.ID()))
+	if errs, ok := stream.(errorWriter); ok {
+		_ = errs.WriteError(StreamError{Error: err.Error()})
+		flusher.Flush()
 	}
-	if req.TimeoutSeconds < 0 {
-		return errors.New("timeout_seconds must be >= 0")
-	}
-	if req.BucketCountsCount != nil && *req.BucketCountsCount < 0 {
-		return errors.New("bucket_counts_count must be >= 0")
-	}
-	if req.ExplicitBoundsCount != nil && *req.ExplicitBoundsCount < 0 {
-		return errors.New("explicit_bounds_count must be >= 0")
-	}
-	return nil
 }
 
-func requestToFilter(req StreamRequest) capture.Filter {
-	signals := make(map[model.SignalType]struct{}, len(req.Signals))
-	for _, signal := range req.Signals {
-		signals[signal] = struct{}{}
-	}
-
-	metricNames, metricNamesExclude := parseExactFilterValues(req.MetricNames)
-	spanNames, spanNamesExclude := parseExactFilterValues(req.SpanNames)
-	attributeNames, attributeExclude := parseExactFilterValues(req.AttributeNames)
-
-	return capture.Filter{
-		Signals:             signals,
-		MetricNames:         metricNames,
-		MetricNamesExclude:  metricNamesExclude,
-		SpanNames:           spanNames,
-		SpanNamesExclude:    spanNamesExclude,
-		AttributeNames:      attributeNames,
-		AttributeExclude:    attributeExclude,
-		BucketCountsCount:   req.BucketCountsCount,
-		ExplicitBoundsCount: req.ExplicitBoundsCount,
-		LogBodyContains:     req.LogBodyContains,
-		MinSeverityNumber:   plog.SeverityNumber(req.MinSeverityNumber),
-		ResourceAttributes:  req.ResourceAttributes,
-	}
+// writeFilterErr reports an invalid filter as 400, including the parse position for `where` errors.
+func (h *Handler) writeFilterErr(w http.ResponseWriter, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	_ = json.NewEncoder(w).Encode(filterStreamError(err))
 }
 
-func parseExactFilterValues(values []string) (map[string]struct{}, map[string]struct{}) {
-	include := make(map[string]struct{}, len(values))
-	exclude := make(map[string]struct{}, len(values))
-
-	for _, raw := range values {
-		value := strings.TrimSpace(raw)
-		if value == "" {
-			continue
-		}
-		if strings.HasPrefix(value, "!") {
-			excluded := strings.TrimSpace(strings.TrimPrefix(value, "!"))
-			if excluded != "" {
-				exclude[excluded] = struct{}{}
-			}
-			continue
-		}
-		include[value] = struct{}{}
+func filterStreamError(err error) StreamError {
+	out := StreamError{Error: err.Error()}
+	var exprErr *capture.ExprError
+	if errors.As(err, &exprErr) {
+		out.Position = exprErr.Position
 	}
+	return out
+}
 
-	return include, exclude
+func (h *Handler) writeRegisterErr(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if errors.Is(err, capture.ErrSessionLimitReached) {
+		status = http.StatusTooManyRequests
+	}
+	h.writeErr(w, status, err.Error())
 }
 
 func (h *Handler) writeErr(w http.ResponseWriter, code int, message string) {
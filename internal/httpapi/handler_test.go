@@ -3,8 +3,12 @@ package httpapi
 import (
 	"bufio"
 	"bytes"
+	"context"
+	"encoding/binary"
 	"encoding/json"
 	"fmt"
+	"io"
+	"math"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -13,6 +17,8 @@ import (
 
 	"github.com/utrack/otellens/internal/capture"
 	"github.com/utrack/otellens/internal/model"
+	"github.com/utrack/otellens/internal/request"
+	"go.opentelemetry.io/collector/pdata/plog"
 	"go.opentelemetry.io/collector/pdata/pmetric"
 	"go.uber.org/zap"
 )
@@ -91,58 +97,47 @@ func TestUIServesHTML(t *testing.T) {
 	}
 }
 
-func TestRequestToFilterSupportsNotValues(t *testing.T) {
-	bucketCountsCount := 28
-	explicitBoundsCount := 29
-
-	f := requestToFilter(StreamRequest{
-		Signals:             []model.SignalType{"metrics", "traces"},
-		MetricNames:         []string{"http.server.request.duration", "!foo", " !bar "},
-		SpanNames:           []string{"GET /", "!POST /"},
-		AttributeNames:      []string{"client_name", "!blocked"},
-		BucketCountsCount:   &bucketCountsCount,
-		ExplicitBoundsCount: &explicitBoundsCount,
-		MaxBatches:          1,
-	})
+func TestHandleStreamRejectsInvalidPattern(t *testing.T) {
+	h := NewHandler(capture.NewRegistry(4), zap.NewNop())
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
 
-	if _, ok := f.MetricNames["http.server.request.duration"]; !ok {
-		t.Fatal("expected metric include value")
-	}
-	if _, ok := f.MetricNamesExclude["foo"]; !ok {
-		t.Fatal("expected metric exclude value foo")
-	}
-	if _, ok := f.MetricNamesExclude["bar"]; !ok {
-		t.Fatal("expected metric exclude value bar")
-	}
+	body := bytes.NewBufferString(`{"metric_names":["~(unclosed"],"max_batches":1}`)
+	req := httptest.NewRequest(http.MethodPost, "/v1/capture/stream", body)
+	res := httptest.NewRecorder()
 
-	if _, ok := f.SpanNames["GET /"]; !ok {
-		t.Fatal("expected span include value")
+	mux.ServeHTTP(res, req)
+	if res.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", res.Code)
 	}
-	if _, ok := f.SpanNamesExclude["POST /"]; !ok {
-		t.Fatal("expected span exclude value")
+	if !strings.Contains(res.Body.String(), "metric_names") {
+		t.Fatalf("expected error to name the field, got %s", res.Body.String())
 	}
+}
 
-	if _, ok := f.AttributeNames["client_name"]; !ok {
-		t.Fatal("expected attribute include value")
-	}
-	if _, ok := f.AttributeExclude["blocked"]; !ok {
-		t.Fatal("expected attribute exclude value")
-	}
-	if f.BucketCountsCount == nil || *f.BucketCountsCount != bucketCountsCount {
-		t.Fatalf("expected bucket_counts_count=%d", bucketCountsCount)
-	}
-	if f.ExplicitBoundsCount == nil || *f.ExplicitBoundsCount != explicitBoundsCount {
-		t.Fatalf("expected explicit_bounds_count=%d", explicitBoundsCount)
+func TestHandleStreamRejectsInvalidWhereWithPosition(t *testing.T) {
+	h := NewHandler(capture.NewRegistry(4), zap.NewNop())
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	body := bytes.NewBufferString(`{"where":"name == \"a\" and (","max_batches":1}`)
+	req := httptest.NewRequest(http.MethodPost, "/v1/capture/stream", body)
+	res := httptest.NewRecorder()
+
+	mux.ServeHTTP(res, req)
+	if res.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", res.Code)
 	}
-}
 
-func TestValidateRequestRejectsNegativeHistogramCountFilters(t *testing.T) {
-	negOne := -1
-	if err := validateRequest(StreamRequest{MaxBatches: 1, BucketCountsCount: &negOne}); err == nil {
-		t.Fatal("expected validation error for negative bucket_counts_count")
+	var out StreamError
+	if err := json.Unmarshal(res.Body.Bytes(), &out); err != nil {
+		t.Fatalf("decode error: %v", err)
 	}
-	if err := validateRequest(StreamRequest{MaxBatches: 1, ExplicitBoundsCount: &negOne}); err == nil {
-		t.Fatal("expected validation error for negative explicit_bounds_count")
+	if out.Position != 18 {
+		t.Fatalf("expected position 18, got %d (%s)", out.Position, out.Error)
+	}
+	if !strings.HasPrefix(out.Error, "where:") {
+		t.Fatalf("expected where-prefixed error, got %q", out.Error)
 	}
 }
 
@@ -262,6 +257,658 @@ func TestHandleStreamStreamsMatchingMetricsAndEndsSession(t *testing.T) {
 	}
 }
 
+func TestHandleStreamBinaryProtobufFrames(t *testing.T) {
+	registry := capture.NewRegistry(4)
+	h := NewHandler(registry, zap.NewNop())
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	type frame struct {
+		kind byte
+		body []byte
+	}
+	resultCh := make(chan []frame, 1)
+	errCh := make(chan error, 1)
+
+	go func() {
+		body := bytes.NewBufferString(`{"signals":["metrics"],"metric_names":["A"],"max_batches":1,"timeout_seconds":5}`)
+		req, err := http.NewRequest(http.MethodPost, server.URL+"/v1/capture/stream", body)
+		if err != nil {
+			errCh <- err
+			return
+		}
+		req.Header.Set("Accept", "application/x-protobuf-stream")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			errCh <- err
+			return
+		}
+		defer resp.Body.Close()
+
+		if got := resp.Header.Get("Content-Type"); got != "application/x-protobuf-stream" {
+			errCh <- fmt.Errorf("unexpected content type: %q", got)
+			return
+		}
+
+		reader := bufio.NewReader(resp.Body)
+		frames := make([]frame, 0, 2)
+		for len(frames) < 2 {
+			kind, err := reader.ReadByte()
+			if err != nil {
+				errCh <- err
+				return
+			}
+			size, err := binary.ReadUvarint(reader)
+			if err != nil {
+				errCh <- err
+				return
+			}
+			buf := make([]byte, size)
+			if _, err := io.ReadFull(reader, buf); err != nil {
+				errCh <- err
+				return
+			}
+			frames = append(frames, frame{kind: kind, body: buf})
+		}
+		resultCh <- frames
+	}()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !registry.HasActiveSessions() && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !registry.HasActiveSessions() {
+		t.Fatal("expected an active capture session")
+	}
+
+	md := newMetricsBatch("A")
+	md.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().AppendEmpty().SetName("B")
+	registry.PublishMetrics(md)
+
+	select {
+	case err := <-errCh:
+		t.Fatalf("stream request failed: %v", err)
+	case frames := <-resultCh:
+		if frames[0].kind != frameMetrics {
+			t.Fatalf("expected metrics frame, got type %d", frames[0].kind)
+		}
+		got, err := (&pmetric.ProtoUnmarshaler{}).UnmarshalMetrics(frames[0].body)
+		if err != nil {
+			t.Fatalf("metrics frame is not OTLP protobuf: %v", err)
+		}
+		if got.MetricCount() != 1 || got.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).Name() != "A" {
+			t.Fatalf("expected only metric A, got %d metrics", got.MetricCount())
+		}
+		if frames[1].kind != frameControl {
+			t.Fatalf("expected control frame, got type %d", frames[1].kind)
+		}
+		var end model.StreamEnd
+		if err := json.Unmarshal(frames[1].body, &end); err != nil {
+			t.Fatalf("control frame is not JSON: %v", err)
+		}
+		if end.Type != "end" || end.Sent != 1 || end.Reason != model.LimitMaxBatches {
+			t.Fatalf("unexpected end frame: %+v", end)
+		}
+		if want := uint64(2 + len(frames[0].body)); end.BytesWritten != want || end.Signals[model.SignalMetrics].Sent != 1 {
+			t.Fatalf("expected the metrics frame of %d bytes in the end frame, got %+v", want, end)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("timed out waiting for binary frames")
+	}
+}
+
+func TestHandleStreamRejectsFormatWithBinaryAccept(t *testing.T) {
+	h := NewHandler(capture.NewRegistry(4), zap.NewNop())
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	body := bytes.NewBufferString(`{"format":"otlp_json","max_batches":1}`)
+	req := httptest.NewRequest(http.MethodPost, "/v1/capture/stream", body)
+	req.Header.Set("Accept", "application/json, application/x-protobuf-stream")
+	res := httptest.NewRecorder()
+
+	mux.ServeHTTP(res, req)
+
+	if res.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", res.Code)
+	}
+}
+
+func TestSessionsAPIListsInspectsAndCancels(t *testing.T) {
+	registry := capture.NewRegistry(4)
+	h := NewHandler(registry, zap.NewNop())
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	session, err := registry.Register(ctx, capture.RegisterRequest{
+		MaxBatches: 4,
+		BufferSize: 4,
+		Info: capture.SessionInfo{
+			Label:      "oncall-1234",
+			RemoteAddr: "10.0.0.1:5000",
+			Request:    request.StreamRequest{Signals: []model.SignalType{model.SignalMetrics}, MaxBatches: 4, Label: "oncall-1234"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("register failed: %v", err)
+	}
+
+	res := httptest.NewRecorder()
+	mux.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/v1/sessions", nil))
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", res.Code)
+	}
+	var list SessionList
+	if err := json.Unmarshal(res.Body.Bytes(), &list); err != nil {
+		t.Fatalf("invalid list body: %v", err)
+	}
+	if len(list.Sessions) != 1 || list.Sessions[0].ID != session.ID() {
+		t.Fatalf("expected the registered session, got %+v", list.Sessions)
+	}
+	view := list.Sessions[0]
+	if view.Label != "oncall-1234" || view.RemoteAddr != "10.0.0.1:5000" || view.QueueCapacity != 4 {
+		t.Fatalf("unexpected session view: %+v", view)
+	}
+	if view.Deadline == nil || len(view.Filter.Signals) != 1 {
+		t.Fatalf("expected deadline and filter, got %+v", view)
+	}
+
+	res = httptest.NewRecorder()
+	mux.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/v1/sessions/"+session.ID(), nil))
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", res.Code)
+	}
+
+	res = httptest.NewRecorder()
+	mux.ServeHTTP(res, httptest.NewRequest(http.MethodDelete, "/v1/sessions/"+session.ID(), nil))
+	if res.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", res.Code)
+	}
+	select {
+	case <-session.Done():
+	default:
+		t.Fatal("expected deleted session to be closed")
+	}
+
+	for _, method := range []string{http.MethodGet, http.MethodDelete} {
+		res = httptest.NewRecorder()
+		mux.ServeHTTP(res, httptest.NewRequest(method, "/v1/sessions/"+session.ID(), nil))
+		if res.Code != http.StatusNotFound {
+			t.Fatalf("%s: expected 404 after delete, got %d", method, res.Code)
+		}
+	}
+}
+
+func TestDetachedSessionReattachAndResume(t *testing.T) {
+	registry := capture.NewRegistry(4)
+	h := NewHandler(registry, zap.NewNop())
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	body := bytes.NewBufferString(`{"signals":["metrics"],"max_batches":5,"timeout_seconds":10,"detach_grace_seconds":5}`)
+	resp, err := http.Post(server.URL+"/v1/sessions", "application/json", body)
+	if err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+	var created SessionView
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+		t.Fatalf("invalid create body: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated || !created.Detachable {
+		t.Fatalf("expected 201 with detachable session, got %d %+v", resp.StatusCode, created)
+	}
+
+	registry.PublishMetrics(newMetricsBatch("A"))
+	registry.PublishMetrics(newMetricsBatch("B"))
+
+	readEnvelopes := func(url string, n int) []model.Envelope {
+		t.Helper()
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			t.Fatalf("build attach request: %v", err)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("attach failed: %v", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("unexpected attach status: %d", resp.StatusCode)
+		}
+		scanner := bufio.NewScanner(resp.Body)
+		out := make([]model.Envelope, 0, n)
+		for len(out) < n && scanner.Scan() {
+			var envelope model.Envelope
+			if err := json.Unmarshal(scanner.Bytes(), &envelope); err != nil {
+				t.Fatalf("invalid envelope: %v", err)
+			}
+			out = append(out, envelope)
+		}
+		return out
+	}
+
+	streamURL := server.URL + "/v1/sessions/" + created.ID + "/stream"
+	first := readEnvelopes(streamURL, 2)
+	if len(first) != 2 || first[0].BatchIndex != 1 || first[1].BatchIndex != 2 {
+		t.Fatalf("expected batches 1 and 2, got %+v", first)
+	}
+
+	waitDetached := func() {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for {
+			session, ok := registry.Session(created.ID)
+			if !ok {
+				t.Fatal("expected session to survive the disconnect")
+			}
+			if !session.Attached() {
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Fatal("expected session to be detached after disconnect")
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	waitDetached()
+
+	resumed := readEnvelopes(streamURL+"?after=1", 1)
+	if len(resumed) != 1 || resumed[0].BatchIndex != 2 {
+		t.Fatalf("expected replay of batch 2, got %+v", resumed)
+	}
+	waitDetached()
+
+	res := httptest.NewRecorder()
+	mux.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/v1/sessions/"+created.ID+"/stream?after=9", nil))
+	if res.Code != http.StatusGone {
+		t.Fatalf("expected 410 for a resume point ahead of the session, got %d", res.Code)
+	}
+}
+
+func TestUpdateSessionFilterKeepsSessionAndLimits(t *testing.T) {
+	registry := capture.NewRegistry(4)
+	h := NewHandler(registry, zap.NewNop())
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	res := httptest.NewRecorder()
+	mux.ServeHTTP(res, httptest.NewRequest(http.MethodPost, "/v1/sessions",
+		bytes.NewBufferString(`{"signals":["metrics"],"metric_names":["A"],"max_batches":5,"label":"incident"}`)))
+	var created SessionView
+	if err := json.NewDecoder(res.Body).Decode(&created); err != nil {
+		t.Fatalf("invalid create body: %v", err)
+	}
+	defer registry.Deregister(created.ID)
+
+	put := func(path, body string) *httptest.ResponseRecorder {
+		res := httptest.NewRecorder()
+		mux.ServeHTTP(res, httptest.NewRequest(http.MethodPut, path, bytes.NewBufferString(body)))
+		return res
+	}
+
+	filterPath := "/v1/sessions/" + created.ID + "/filter"
+	if res := put(filterPath, `{"signals":["metrics"],"where":"name =="}`); res.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an invalid filter, got %d", res.Code)
+	}
+	if res := put("/v1/sessions/unknown/filter", `{}`); res.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for an unknown session, got %d", res.Code)
+	}
+
+	res = put(filterPath, `{"signals":["metrics"],"metric_names":["B"],"verbose_metrics":true,"max_batches":99}`)
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", res.Code, res.Body.String())
+	}
+	var updated SessionView
+	if err := json.NewDecoder(res.Body).Decode(&updated); err != nil {
+		t.Fatalf("invalid update body: %v", err)
+	}
+	if updated.ID != created.ID || updated.Filter.MaxBatches != 5 || updated.Label != "incident" ||
+		len(updated.Filter.MetricNames) != 1 || updated.Filter.MetricNames[0] != "B" || !updated.Filter.VerboseMetrics {
+		t.Fatalf("expected new filter with kept limits, got %+v", updated)
+	}
+
+	session, _ := registry.Session(created.ID)
+	if !session.VerboseMetrics() {
+		t.Fatal("expected verbosity to be replaced")
+	}
+	replay, err := session.Replay(0)
+	if err != nil || len(replay) != 1 || replay[0].Type != model.EnvelopeTypeFilterUpdated {
+		t.Fatalf("expected a buffered filter_updated marker, got %+v / %v", replay, err)
+	}
+}
+
+func TestCreateSessionIncludesRecordedHistory(t *testing.T) {
+	body := `{"signals":["metrics"],"metric_names":["A"],"max_batches":5,"include_history_seconds":60}`
+
+	disabled := http.NewServeMux()
+	NewHandler(capture.NewRegistry(4), zap.NewNop()).RegisterRoutes(disabled)
+	res := httptest.NewRecorder()
+	disabled.ServeHTTP(res, httptest.NewRequest(http.MethodPost, "/v1/sessions", bytes.NewBufferString(body)))
+	if res.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 without a flight recorder, got %d", res.Code)
+	}
+
+	registry := capture.NewRegistry(4, capture.WithFlightRecorder(capture.FlightRecorderConfig{MaxBytes: 1 << 20, MaxAge: time.Minute}))
+	mux := http.NewServeMux()
+	NewHandler(registry, zap.NewNop()).RegisterRoutes(mux)
+	registry.PublishMetrics(newMetricsBatch("A"))
+	registry.PublishMetrics(newMetricsBatch("B"))
+
+	res = httptest.NewRecorder()
+	mux.ServeHTTP(res, httptest.NewRequest(http.MethodPost, "/v1/sessions", bytes.NewBufferString(body)))
+	if res.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", res.Code, res.Body.String())
+	}
+	var created SessionView
+	if err := json.NewDecoder(res.Body).Decode(&created); err != nil {
+		t.Fatalf("invalid create body: %v", err)
+	}
+	defer registry.Deregister(created.ID)
+	if created.SentBatches != 1 || created.Filter.IncludeHistorySeconds != 60 {
+		t.Fatalf("expected the recorded batch of A to be replayed, got %+v", created)
+	}
+
+	session, _ := registry.Session(created.ID)
+	if event := <-session.Events(); !event.History || event.BatchIndex != 1 {
+		t.Fatalf("expected a history envelope first, got %+v", event)
+	}
+}
+
+func TestCreateArmedSessionWithTrigger(t *testing.T) {
+	registry := capture.NewRegistry(4)
+	mux := http.NewServeMux()
+	NewHandler(registry, zap.NewNop()).RegisterRoutes(mux)
+
+	create := func(body string) *httptest.ResponseRecorder {
+		res := httptest.NewRecorder()
+		mux.ServeHTTP(res, httptest.NewRequest(http.MethodPost, "/v1/sessions", bytes.NewBufferString(body)))
+		return res
+	}
+	for _, body := range []string{
+		`{"max_batches":5,"trigger":{"signals":["logs"],"capture_seconds":-1}}`,
+		`{"max_batches":5,"trigger":{"signals":["logs"],"trigger":{}}}`,
+		`{"max_batches":5,"trigger":{"signals":["logs"],"where":"severity_number >="}}`,
+		`{"max_batches":5,"trigger":{"min_severity_number":17}}`,
+	} {
+		if res := create(body); res.Code != http.StatusBadRequest {
+			t.Fatalf("expected 400 for %s, got %d", body, res.Code)
+		}
+	}
+
+	res := create(`{"signals":["metrics"],"max_batches":5,"trigger":{"signals":["logs"],"min_severity_number":17,"capture_seconds":60}}`)
+	if res.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", res.Code, res.Body.String())
+	}
+	var created SessionView
+	if err := json.NewDecoder(res.Body).Decode(&created); err != nil {
+		t.Fatalf("invalid create body: %v", err)
+	}
+	defer registry.Deregister(created.ID)
+	if !created.Armed || created.TriggeredAt != nil || created.Filter.Trigger == nil || created.Filter.Trigger.CaptureSeconds != 60 {
+		t.Fatalf("expected an armed session echoing its trigger, got %+v", created)
+	}
+
+	session, _ := registry.Session(created.ID)
+	registry.PublishMetrics(newMetricsBatch("A"))
+	if !session.Armed() {
+		t.Fatal("expected a severity trigger on logs to ignore a metrics batch")
+	}
+
+	ld := plog.NewLogs()
+	ld.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords().AppendEmpty().SetSeverityNumber(plog.SeverityNumberError)
+	registry.PublishLogs(ld)
+
+	res = httptest.NewRecorder()
+	mux.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/v1/sessions/"+created.ID, nil))
+	var view SessionView
+	if err := json.NewDecoder(res.Body).Decode(&view); err != nil {
+		t.Fatalf("invalid session body: %v", err)
+	}
+	if view.Armed || view.TriggeredAt == nil {
+		t.Fatalf("expected the trigger to have fired, got %+v", view)
+	}
+	if event := <-session.Events(); event.Type != model.EnvelopeTypeTriggered || event.Signal != model.SignalLogs {
+		t.Fatalf("expected a triggered marker, got %+v", event)
+	}
+}
+
+func TestPauseAndResumeSession(t *testing.T) {
+	registry := capture.NewRegistry(4)
+	h := NewHandler(registry, zap.NewNop())
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	session, err := registry.Register(context.Background(), capture.RegisterRequest{MaxBatches: 5})
+	if err != nil {
+		t.Fatalf("register failed: %v", err)
+	}
+	defer registry.Deregister(session.ID())
+
+	post := func(action string) SessionView {
+		t.Helper()
+		res := httptest.NewRecorder()
+		mux.ServeHTTP(res, httptest.NewRequest(http.MethodPost, "/v1/sessions/"+session.ID()+"/"+action, nil))
+		if res.Code != http.StatusOK {
+			t.Fatalf("expected 200 for %s, got %d", action, res.Code)
+		}
+		var view SessionView
+		if err := json.NewDecoder(res.Body).Decode(&view); err != nil {
+			t.Fatalf("invalid %s body: %v", action, err)
+		}
+		return view
+	}
+
+	if view := post("pause"); !view.Paused || !session.Paused() {
+		t.Fatalf("expected paused session, got %+v", view)
+	}
+	if view := post("pause"); !view.Paused {
+		t.Fatal("expected repeated pause to keep the session paused")
+	}
+	time.Sleep(5 * time.Millisecond)
+	if view := post("resume"); view.Paused || view.PausedSeconds <= 0 {
+		t.Fatalf("expected resumed session with paused time, got %+v", view)
+	}
+
+	res := httptest.NewRecorder()
+	mux.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/v1/sessions/"+session.ID()+"/pause", nil))
+	if res.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405 for GET pause, got %d", res.Code)
+	}
+	res = httptest.NewRecorder()
+	mux.ServeHTTP(res, httptest.NewRequest(http.MethodPost, "/v1/sessions/unknown/resume", nil))
+	if res.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for an unknown session, got %d", res.Code)
+	}
+
+	if end := session.StreamEnd(); end.PausedSeconds <= 0 {
+		t.Fatalf("expected paused time in StreamEnd, got %+v", end)
+	}
+}
+
+type sseEvent struct {
+	id    string
+	event string
+	data  string
+}
+
+// readSSEEvents reads up to n events from an SSE endpoint.
+func readSSEEvents(t *testing.T, url string, header http.Header, n int) []sseEvent {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatalf("build request: %v", err)
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	return readSSEResponse(t, req, n)
+}
+
+// readSSEResponse sends req and reads up to n events from its response.
+func readSSEResponse(t *testing.T, req *http.Request, n int) []sseEvent {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		t.Fatalf("sse request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if got := resp.Header.Get("Content-Type"); got != "text/event-stream" {
+		t.Fatalf("unexpected content type: %q", got)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	out := make([]sseEvent, 0, n)
+	var current sseEvent
+	for len(out) < n && scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			out = append(out, current)
+			current = sseEvent{}
+		case strings.HasPrefix(line, "id: "):
+			current.id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			current.event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			current.data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+	return out
+}
+
+func TestSessionEventsSSEWithHeartbeatAndLastEventID(t *testing.T) {
+	registry := capture.NewRegistry(4)
+	h := NewHandler(registry, zap.NewNop())
+	h.heartbeatInterval = 20 * time.Millisecond
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	resp, err := http.Post(server.URL+"/v1/sessions", "application/json",
+		bytes.NewBufferString(`{"signals":["metrics"],"max_batches":5,"timeout_seconds":10}`))
+	if err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+	var created SessionView
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+		t.Fatalf("invalid create body: %v", err)
+	}
+	resp.Body.Close()
+
+	registry.PublishMetrics(newMetricsBatch("A"))
+	registry.PublishMetrics(newMetricsBatch("B"))
+
+	eventsURL := server.URL + "/v1/sessions/" + created.ID + "/events"
+	events := readSSEEvents(t, eventsURL, nil, 3)
+	if len(events) != 3 {
+		t.Fatalf("expected 3 events, got %+v", events)
+	}
+	if events[0].event != "envelope" || events[0].id != "1" || events[1].id != "2" {
+		t.Fatalf("expected envelopes 1 and 2 first, got %+v", events[:2])
+	}
+	var envelope model.Envelope
+	if err := json.Unmarshal([]byte(events[0].data), &envelope); err != nil || envelope.Signal != model.SignalMetrics {
+		t.Fatalf("unexpected envelope data %q: %v", events[0].data, err)
+	}
+	if events[2].event != "heartbeat" {
+		t.Fatalf("expected heartbeat on an idle stream, got %+v", events[2])
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for session, _ := registry.Session(created.ID); session.Attached(); session, _ = registry.Session(created.ID) {
+		if time.Now().After(deadline) {
+			t.Fatal("expected session to detach")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	resumed := readSSEEvents(t, eventsURL, http.Header{"Last-Event-Id": []string{"1"}}, 1)
+	if len(resumed) != 1 || resumed[0].event != "envelope" || resumed[0].id != "2" {
+		t.Fatalf("expected resume from envelope 2, got %+v", resumed)
+	}
+
+	missing, err := http.Get(server.URL + "/v1/sessions/unknown/events")
+	if err != nil {
+		t.Fatalf("sse request failed: %v", err)
+	}
+	missing.Body.Close()
+	if missing.StatusCode != http.StatusNotFound || missing.Header.Get("Content-Type") == "text/event-stream" {
+		t.Fatalf("expected a plain 404 for an unknown session, got %d %q", missing.StatusCode, missing.Header.Get("Content-Type"))
+	}
+}
+
+func TestHandleStreamServesServerSentEvents(t *testing.T) {
+	registry := capture.NewRegistry(4)
+	h := NewHandler(registry, zap.NewNop())
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	eventsCh := make(chan []sseEvent, 1)
+	go func() {
+		req, _ := http.NewRequest(http.MethodPost, server.URL+"/v1/capture/stream",
+			bytes.NewBufferString(`{"signals":["metrics"],"max_batches":1,"timeout_seconds":5}`))
+		req.Header.Set("Accept", "text/event-stream")
+		eventsCh <- readSSEResponse(t, req, 2)
+	}()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !registry.HasActiveSessions() && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	registry.PublishMetrics(newMetricsBatch("A"))
+
+	events := <-eventsCh
+	if len(events) != 2 || events[0].event != "envelope" || events[0].id != "1" || events[1].event != "end" {
+		t.Fatalf("expected an envelope followed by the end event, got %+v", events)
+	}
+
+	body := bytes.NewBufferString(`{"signals":["metrics"],"max_batches":1}`)
+	req := httptest.NewRequest(http.MethodPost, "/v1/capture/stream", body)
+	req.Header.Set("Accept", protobufStreamMediaType+", text/event-stream")
+	res := httptest.NewRecorder()
+	h.handleStream(res, req)
+	if res.Code != http.StatusNotAcceptable {
+		t.Fatalf("expected 406 for a binary session over SSE, got %d", res.Code)
+	}
+}
+
+func TestSSEStreamReportsEncodingFailures(t *testing.T) {
+	registry := capture.NewRegistry(4)
+	h := NewHandler(registry, zap.NewNop())
+	session, err := registry.Register(context.Background(), capture.RegisterRequest{MaxBatches: 1, BufferSize: 1})
+	if err != nil {
+		t.Fatalf("register failed: %v", err)
+	}
+	session.Emit(model.Envelope{Signal: model.SignalMetrics, Payload: math.Inf(1)})
+
+	res := httptest.NewRecorder()
+	if h.pump(context.Background(), newSessionStreamWriter(res, session, true), res, session, 0) {
+		t.Fatal("expected the stream to stop on the encoding failure")
+	}
+	if got := res.Body.String(); !strings.HasPrefix(got, "event: error\ndata: {\"error\":\"json: unsupported value: +Inf\"}") {
+		t.Fatalf("expected an error event, got %q", got)
+	}
+}
+
 func newMetricsBatch(name string) pmetric.Metrics {
 	md := pmetric.NewMetrics()
 	rm := md.ResourceMetrics().AppendEmpty()
@@ -0,0 +1,336 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/utrack/otellens/internal/capture"
+	"github.com/utrack/otellens/internal/model"
+	"github.com/utrack/otellens/internal/request"
+)
+
+const (
+	sessionsPath = "/v1/sessions"
+
+	defaultDetachGrace = 60 * time.Second
+)
+
+func (h *Handler) handleSessions(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		sessions := h.registry.Sessions()
+		out := SessionList{Sessions: make([]SessionView, 0, len(sessions))}
+		for _, session := range sessions {
+			out.Sessions = append(out.Sessions, sessionToView(session))
+		}
+		h.writeJSON(w, http.StatusOK, out)
+	case http.MethodPost:
+		h.handleCreateSession(w, r)
+	default:
+		h.writeErr(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// handleCreateSession registers a detachable session that is not bound to this request.
+// Its lifetime is bounded by timeout_seconds, max_batches and the detach grace period.
+func (h *Handler) handleCreateSession(w http.ResponseWriter, r *http.Request) {
+	req, registerReq, ok := h.decodeStreamRequest(w, r)
+	if !ok {
+		return
+	}
+
+	timeout := defaultSessionTimeout
+	if req.TimeoutSeconds > 0 {
+		timeout = time.Duration(req.TimeoutSeconds) * time.Second
+	}
+	grace := defaultDetachGrace
+	if req.DetachGraceSeconds > 0 {
+		grace = time.Duration(req.DetachGraceSeconds) * time.Second
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	registerReq.DetachGrace = grace
+	session, err := h.registry.Register(ctx, registerReq)
+	if err != nil {
+		cancel()
+		h.writeRegisterErr(w, err)
+		return
+	}
+	go func() {
+		<-session.Done()
+		cancel()
+	}()
+
+	w.Header().Set("Location", sessionsPath+"/"+session.ID()+"/stream")
+	h.writeJSON(w, http.StatusCreated, sessionToView(session))
+}
+
+func (h *Handler) handleSession(w http.ResponseWriter, r *http.Request) {
+	rest := strings.TrimPrefix(r.URL.Path, sessionsPath+"/")
+	sessionID, action, _ := strings.Cut(rest, "/")
+	if sessionID == "" || strings.Contains(action, "/") {
+		h.writeErr(w, http.StatusNotFound, "session not found")
+		return
+	}
+
+	switch {
+	case action == "stream" && r.Method == http.MethodGet:
+		h.handleAttachStream(w, r, sessionID)
+	case action == "events" && r.Method == http.MethodGet:
+		h.handleAttachEvents(w, r, sessionID)
+	case action == "filter" && r.Method == http.MethodPut:
+		h.handleUpdateFilter(w, r, sessionID)
+	case (action == "pause" || action == "resume") && r.Method == http.MethodPost:
+		h.handlePause(w, sessionID, action == "pause")
+	case action == "stream", action == "events", action == "filter", action == "pause", action == "resume":
+		h.writeErr(w, http.StatusMethodNotAllowed, "method not allowed")
+	case action != "":
+		h.writeErr(w, http.StatusNotFound, "not found")
+	case r.Method == http.MethodGet:
+		session, ok := h.registry.Session(sessionID)
+		if !ok {
+			h.writeErr(w, http.StatusNotFound, "session not found")
+			return
+		}
+		h.writeJSON(w, http.StatusOK, sessionToView(session))
+	case r.Method == http.MethodDelete:
+		if _, ok := h.registry.Session(sessionID); !ok {
+			h.writeErr(w, http.StatusNotFound, "session not found")
+			return
+		}
+		h.registry.End(sessionID, model.EndReasonCancelled)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		h.writeErr(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// handleUpdateFilter replaces the filter and verbosity of a running session in place.
+func (h *Handler) handleUpdateFilter(w http.ResponseWriter, r *http.Request, sessionID string) {
+	session, ok := h.registry.Session(sessionID)
+	if !ok {
+		h.writeErr(w, http.StatusNotFound, "session not found")
+		return
+	}
+	var req request.StreamRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeErr(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	update, err := sessionUpdate(session, req)
+	if err != nil {
+		h.writeFilterErr(w, err)
+		return
+	}
+	if err := session.Update(update); err != nil {
+		h.writeErr(w, http.StatusNotFound, err.Error())
+		return
+	}
+	h.writeJSON(w, http.StatusOK, sessionToView(session))
+}
+
+// handlePause pauses or resumes a session. Repeating the current state is a no-op.
+func (h *Handler) handlePause(w http.ResponseWriter, sessionID string, pause bool) {
+	session, ok := h.registry.Session(sessionID)
+	if !ok {
+		h.writeErr(w, http.StatusNotFound, "session not found")
+		return
+	}
+	if pause {
+		session.Pause()
+	} else {
+		session.Resume()
+	}
+	h.writeJSON(w, http.StatusOK, sessionToView(session))
+}
+
+// sessionUpdate builds the replacement rules of a running session from next. Fields fixed at
+// creation (format, budgets, timeouts, label, history, trigger, rate limits) are kept, so next may carry only filter fields.
+func sessionUpdate(session *capture.Session, next request.StreamRequest) (capture.SessionUpdate, error) {
+	current, _ := session.Info().Request.(request.StreamRequest)
+	next.Format = current.Format
+	next.MaxBatches = current.MaxBatches
+	next.MaxRecords = current.MaxRecords
+	next.MaxBytes = current.MaxBytes
+	next.TimeoutSeconds = current.TimeoutSeconds
+	next.DetachGraceSeconds = current.DetachGraceSeconds
+	next.IncludeHistorySeconds = current.IncludeHistorySeconds
+	next.Trigger = current.Trigger
+	next.MaxBatchesPerSecond = current.MaxBatchesPerSecond
+	next.MaxRecordsPerSecond = current.MaxRecordsPerSecond
+	next.MaxBytesPerSecond = current.MaxBytesPerSecond
+	next.Label = current.Label
+
+	// The format was validated at creation and may be the negotiated otlp_proto.
+	check := next
+	check.Format = ""
+	filter, err := request.Parse(check)
+	if err != nil {
+		return capture.SessionUpdate{}, err
+	}
+	return capture.SessionUpdate{
+		Filter:         filter,
+		VerboseMetrics: next.VerboseMetrics,
+		VerboseTraces:  next.VerboseTraces,
+		VerboseLogs:    next.VerboseLogs,
+		MaxLogRecords:  next.MaxLogRecords,
+		SampleRate:     next.SampleRate,
+		SampleEveryN:   next.SampleEveryN,
+		Request:        next,
+	}, nil
+}
+
+// handleAttachStream streams a detachable session. With ?after=N the stream first replays
+// buffered envelopes with BatchIndex > N; disconnecting detaches instead of ending the session.
+func (h *Handler) handleAttachStream(w http.ResponseWriter, r *http.Request, sessionID string) {
+	after, err := parseResumePoint(r.URL.Query().Get("after"))
+	if err != nil {
+		h.writeErr(w, http.StatusBadRequest, "after must be a non-negative batch index")
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		h.writeErr(w, http.StatusInternalServerError, "streaming is not supported")
+		return
+	}
+
+	session, replay, err := h.attach(sessionID, after)
+	if err != nil {
+		h.writeAttachErr(w, err)
+		return
+	}
+	defer h.registry.Detach(session)
+
+	stream := newSessionStreamWriter(w, session, false)
+	h.streamAttached(w, r, flusher, stream, session, replay, after)
+}
+
+// handleAttachEvents is the Server-Sent Events variant of handleAttachStream. EventSource reconnects
+// resume from the Last-Event-ID header. Failures are plain HTTP errors sent before the event stream
+// starts: EventSource gives up on them, while it would reconnect forever after a 200 response.
+func (h *Handler) handleAttachEvents(w http.ResponseWriter, r *http.Request, sessionID string) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		h.writeErr(w, http.StatusInternalServerError, "streaming is not supported")
+		return
+	}
+
+	resumeFrom := r.Header.Get("Last-Event-ID")
+	if resumeFrom == "" {
+		resumeFrom = r.URL.Query().Get("after")
+	}
+	after, err := parseResumePoint(resumeFrom)
+	if err != nil {
+		h.writeErr(w, http.StatusBadRequest, "Last-Event-ID must be a non-negative batch index")
+		return
+	}
+
+	session, replay, err := h.attach(sessionID, after)
+	if err != nil {
+		h.writeAttachErr(w, err)
+		return
+	}
+	defer h.registry.Detach(session)
+
+	if session.Format() == model.FormatOTLPProto {
+		h.writeErr(w, http.StatusNotAcceptable, "binary sessions cannot be streamed as server-sent events")
+		return
+	}
+	h.streamAttached(w, r, flusher, newSessionStreamWriter(w, session, true), session, replay, after)
+}
+
+// attach claims a detachable session and collects the envelopes to replay after the resume point.
+func (h *Handler) attach(sessionID string, after uint64) (*capture.Session, []model.Envelope, error) {
+	session, err := h.registry.Attach(sessionID)
+	if err != nil {
+		return nil, nil, err
+	}
+	replay, err := session.Replay(after)
+	if err != nil {
+		h.registry.Detach(session)
+		return nil, nil, err
+	}
+	return session, replay, nil
+}
+
+func (h *Handler) streamAttached(w http.ResponseWriter, r *http.Request, flusher http.Flusher, stream streamWriter, session *capture.Session, replay []model.Envelope, after uint64) {
+	w.Header().Set("Content-Type", stream.ContentType())
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+
+	lastSent := after
+	for _, event := range replay {
+		if err := stream.WriteEvent(event); err != nil {
+			h.writeStreamErr(stream, flusher, session, err)
+			return
+		}
+		lastSent = event.BatchIndex
+	}
+	flusher.Flush()
+
+	h.pump(r.Context(), stream, flusher, session, lastSent)
+}
+
+func parseResumePoint(raw string) (uint64, error) {
+	if raw == "" {
+		return 0, nil
+	}
+	return strconv.ParseUint(raw, 10, 64)
+}
+
+func (h *Handler) writeAttachErr(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, capture.ErrSessionNotFound):
+		h.writeErr(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, capture.ErrResumeUnavailable):
+		h.writeErr(w, http.StatusGone, err.Error())
+	case errors.Is(err, capture.ErrSessionAttached), errors.Is(err, capture.ErrSessionNotDetachable):
+		h.writeErr(w, http.StatusConflict, err.Error())
+	default:
+		h.writeErr(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+func sessionToView(session *capture.Session) SessionView {
+	info := session.Info()
+	view := SessionView{
+		ID:             session.ID(),
+		Label:          info.Label,
+		RemoteAddr:     info.RemoteAddr,
+		StartedAt:      session.StartedAt(),
+		SentBatches:    session.SentBatches(),
+		DroppedBatches: session.DroppedBatches(),
+		SentRecords:    session.SentRecords(),
+		SentBytes:      session.SentBytes(),
+		QueueDepth:     session.QueueDepth(),
+		QueueCapacity:  session.QueueCapacity(),
+		Detachable:     session.Detachable(),
+		Attached:       session.Attached(),
+		Paused:         session.Paused(),
+		PausedSeconds:  session.PausedDuration().Seconds(),
+		Armed:          session.Armed(),
+	}
+	if !info.Deadline.IsZero() {
+		deadline := info.Deadline
+		view.Deadline = &deadline
+	}
+	if triggeredAt := session.TriggeredAt(); !triggeredAt.IsZero() {
+		view.TriggeredAt = &triggeredAt
+	}
+	if req, ok := info.Request.(request.StreamRequest); ok {
+		view.Filter = req
+	}
+	return view
+}
+
+func (h *Handler) writeJSON(w http.ResponseWriter, code int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(body)
+}
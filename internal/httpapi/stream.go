@@ -0,0 +1,204 @@
+package httpapi
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/utrack/otellens/internal/capture"
+	"github.com/utrack/otellens/internal/model"
+)
+
+const (
+	// protobufStreamMediaType selects the binary length-delimited OTLP stream.
+	protobufStreamMediaType = "application/x-protobuf-stream"
+	// sseMediaType selects Server-Sent Events.
+	sseMediaType = "text/event-stream"
+)
+
+// Frame types of the binary stream. Data frames carry a serialized OTLP Export*ServiceRequest,
+// control frames carry a JSON-encoded control event such as model.StreamEnd or a marker envelope.
+const (
+	frameControl byte = 0
+	frameMetrics byte = 1
+	frameTraces  byte = 2
+	frameLogs    byte = 3
+)
+
+// streamWriter encodes capture events for one streaming response.
+type streamWriter interface {
+	ContentType() string
+	WriteEvent(event model.Envelope) error
+	WriteEnd(end model.StreamEnd) error
+}
+
+// heartbeatWriter is implemented by stream encodings that send keep-alive events while idle.
+type heartbeatWriter interface {
+	WriteHeartbeat(heartbeat model.Heartbeat) error
+}
+
+// errorWriter is implemented by stream encodings that can report a failure once the response started.
+type errorWriter interface {
+	WriteError(streamErr StreamError) error
+}
+
+// ndjsonStreamWriter writes one JSON document per line; this is the default encoding.
+type ndjsonStreamWriter struct {
+	enc *json.Encoder
+}
+
+func newNDJSONStreamWriter(w io.Writer) *ndjsonStreamWriter {
+	return &ndjsonStreamWriter{enc: json.NewEncoder(w)}
+}
+
+func (s *ndjsonStreamWriter) ContentType() string { return "application/x-ndjson" }
+
+func (s *ndjsonStreamWriter) WriteEvent(event model.Envelope) error { return s.enc.Encode(event) }
+
+func (s *ndjsonStreamWriter) WriteEnd(end model.StreamEnd) error { return s.enc.Encode(end) }
+
+// protoStreamWriter writes frames of `type byte | uvarint length | body`.
+type protoStreamWriter struct {
+	w      io.Writer
+	header [1 + binary.MaxVarintLen64]byte
+}
+
+func newProtoStreamWriter(w io.Writer) *protoStreamWriter {
+	return &protoStreamWriter{w: w}
+}
+
+func (s *protoStreamWriter) ContentType() string { return protobufStreamMediaType }
+
+func (s *protoStreamWriter) WriteEvent(event model.Envelope) error {
+	if event.Type != "" {
+		body, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		return s.writeFrame(frameControl, body)
+	}
+	body, ok := event.Payload.([]byte)
+	if !ok {
+		return fmt.Errorf("unexpected payload type %T for binary stream", event.Payload)
+	}
+	var frameType byte
+	switch event.Signal {
+	case model.SignalMetrics:
+		frameType = frameMetrics
+	case model.SignalTraces:
+		frameType = frameTraces
+	case model.SignalLogs:
+		frameType = frameLogs
+	default:
+		return fmt.Errorf("unknown signal %q", event.Signal)
+	}
+	return s.writeFrame(frameType, body)
+}
+
+func (s *protoStreamWriter) WriteEnd(end model.StreamEnd) error {
+	body, err := json.Marshal(end)
+	if err != nil {
+		return err
+	}
+	return s.writeFrame(frameControl, body)
+}
+
+func (s *protoStreamWriter) writeFrame(frameType byte, body []byte) error {
+	s.header[0] = frameType
+	n := binary.PutUvarint(s.header[1:], uint64(len(body)))
+	if _, err := s.w.Write(s.header[:1+n]); err != nil {
+		return err
+	}
+	_, err := s.w.Write(body)
+	return err
+}
+
+// sseStreamWriter writes Server-Sent Events typed as envelope, a marker type, end, heartbeat or error.
+// Envelopes carry their BatchIndex as the event ID, so EventSource reconnects send it back as Last-Event-ID.
+type sseStreamWriter struct {
+	w io.Writer
+}
+
+func newSSEStreamWriter(w io.Writer) *sseStreamWriter {
+	return &sseStreamWriter{w: w}
+}
+
+func (s *sseStreamWriter) ContentType() string { return sseMediaType }
+
+func (s *sseStreamWriter) WriteEvent(event model.Envelope) error {
+	return s.write(strconv.FormatUint(event.BatchIndex, 10), envelopeEventType(event), event)
+}
+
+func (s *sseStreamWriter) WriteEnd(end model.StreamEnd) error { return s.write("", "end", end) }
+
+func (s *sseStreamWriter) WriteHeartbeat(heartbeat model.Heartbeat) error {
+	return s.write("", "heartbeat", heartbeat)
+}
+
+func (s *sseStreamWriter) WriteError(streamErr StreamError) error {
+	return s.write("", "error", streamErr)
+}
+
+func (s *sseStreamWriter) write(id string, event string, body interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	if id != "" {
+		if _, err := fmt.Fprintf(s.w, "id: %s\n", id); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(s.w, "event: %s\ndata: %s\n\n", event, data)
+	return err
+}
+
+// envelopeEventType names an envelope in typed streams (SSE, WebSocket): telemetry or a marker type.
+func envelopeEventType(event model.Envelope) string {
+	if event.Type != "" {
+		return event.Type
+	}
+	return "envelope"
+}
+
+// newSessionStreamWriter picks the stream encoding matching the session's payload format, or SSE.
+func newSessionStreamWriter(w io.Writer, session *capture.Session, sse bool) streamWriter {
+	w = countingWriter{w: w, session: session}
+	if sse {
+		return newSSEStreamWriter(w)
+	}
+	if session.Format() == model.FormatOTLPProto {
+		return newProtoStreamWriter(w)
+	}
+	return newNDJSONStreamWriter(w)
+}
+
+// countingWriter counts the bytes written to a session's stream for its StreamEnd.
+type countingWriter struct {
+	w       io.Writer
+	session *capture.Session
+}
+
+func (c countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.session.AddBytesWritten(n)
+	return n, err
+}
+
+// accepts reports whether the client listed mediaType in its Accept header.
+func accepts(r *http.Request, mediaType string) bool {
+	for _, value := range r.Header.Values("Accept") {
+		for _, part := range strings.Split(value, ",") {
+			parsed, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+			if err == nil && parsed == mediaType {
+				return true
+			}
+		}
+	}
+	return false
+}
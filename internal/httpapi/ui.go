@@ -121,20 +121,95 @@ const uiPage = `<!doctype html>
           </div>
 
           <div class="row">
-            <label for="metric_names">metric_names (comma-separated, prefix with ! for NOT)</label>
-            <input id="metric_names" placeholder="http.server.request.duration,!foo,!bar" />
+            <label for="metric_names">metric_names (comma-separated, glob:pattern or ~regex, prefix with ! for NOT)</label>
+            <input id="metric_names" placeholder="glob:http.server.*,!foo,~^kafka\..*lag$" />
           </div>
 
           <div class="row">
-            <label for="span_names">span_names (comma-separated, prefix with ! for NOT)</label>
+            <label for="span_names">span_names (comma-separated, glob:pattern or ~regex, prefix with ! for NOT)</label>
             <input id="span_names" placeholder="GET /v1/orders,!POST /health" />
           </div>
 
           <div class="row">
-            <label for="attribute_names">attribute_names (comma-separated keys, prefix with ! for NOT)</label>
+            <label for="span_kinds">span_kinds (comma-separated: server, client, internal, producer, consumer)</label>
+            <input id="span_kinds" placeholder="server" />
+          </div>
+
+          <div class="row">
+            <label for="span_status_codes">span_status_codes (comma-separated: unset, ok, error)</label>
+            <input id="span_status_codes" placeholder="error" />
+          </div>
+
+          <div class="row">
+            <label for="span_status_message_contains">span_status_message_contains</label>
+            <input id="span_status_message_contains" placeholder="deadline" />
+          </div>
+
+          <div class="row">
+            <label for="min_span_duration_ms">min_span_duration_ms / max_span_duration_ms</label>
+            <div class="btns">
+              <input id="min_span_duration_ms" type="number" min="0" step="any" placeholder="min" />
+              <input id="max_span_duration_ms" type="number" min="0" step="any" placeholder="max" />
+            </div>
+          </div>
+
+          <div class="row">
+            <label class="chip"><input id="root_spans_only" type="checkbox" /> root_spans_only</label>
+          </div>
+
+          <div class="row">
+            <label for="attribute_names">attribute_names (comma-separated keys, glob:pattern or ~regex, prefix with ! for NOT)</label>
             <input id="attribute_names" placeholder="client_name,!blocked" />
           </div>
 
+          <div class="row">
+            <label for="attribute_filters">attribute_filters (JSON array of {key, level, op, value})</label>
+            <textarea id="attribute_filters" placeholder='[{"key":"http.response.status_code","level":"span","op":"gte","value":500}]'></textarea>
+          </div>
+
+          <div class="row">
+            <label for="where">where (boolean expression)</label>
+            <textarea id="where" placeholder="resource.service.name == 'checkout' and (status == 'error' or duration_ms > 500)"></textarea>
+          </div>
+
+          <div class="row">
+            <label for="trigger_where">trigger (where expression; leave empty to capture right away)</label>
+            <textarea id="trigger_where" placeholder="resource.service.name == 'checkout' and severity_number >= 17"></textarea>
+          </div>
+
+          <div class="row">
+            <label>trigger signals</label>
+            <div class="signals">
+              <label class="chip"><input type="checkbox" name="trigger_signals" value="metrics" /> metrics</label>
+              <label class="chip"><input type="checkbox" name="trigger_signals" value="traces" /> traces</label>
+              <label class="chip"><input type="checkbox" name="trigger_signals" value="logs" checked /> logs</label>
+            </div>
+          </div>
+
+          <div class="row">
+            <label for="trigger_capture_seconds">capture_seconds after the trigger fired (0 = until max_batches)</label>
+            <input id="trigger_capture_seconds" type="number" min="0" placeholder="0" />
+          </div>
+
+          <div class="row">
+            <label for="trace_ids">trace_ids (comma-separated hex)</label>
+            <input id="trace_ids" placeholder="4bf92f3577b34da6a3ce929d0e0e4736" />
+          </div>
+
+          <div class="row">
+            <label for="span_ids">span_ids (comma-separated hex)</label>
+            <input id="span_ids" placeholder="00f067aa0ba902b7" />
+          </div>
+
+          <div class="row">
+            <label for="exemplar_trace_ids">exemplar_trace_ids (metrics with an exemplar for these traces, comma-separated hex)</label>
+            <input id="exemplar_trace_ids" placeholder="4bf92f3577b34da6a3ce929d0e0e4736" />
+          </div>
+
+          <div class="row">
+            <label class="chip"><input id="has_exemplars" type="checkbox" /> has_exemplars (only metrics with exemplars)</label>
+          </div>
+
           <div class="row">
             <label for="resource_attributes">resource_attributes (key=value per line)</label>
             <textarea id="resource_attributes" placeholder="service.name=checkout\ndeployment.environment.name=prod"></textarea>
@@ -160,22 +235,100 @@ const uiPage = `<!doctype html>
             <input id="explicit_bounds_count" type="number" min="0" placeholder="29" />
           </div>
 
+          <div class="row">
+            <label for="exponential_scale">exponential_scale (exponential histogram datapoint scale)</label>
+            <input id="exponential_scale" type="number" min="-10" max="20" placeholder="3" />
+          </div>
+
+          <div class="row">
+            <label for="positive_bucket_counts_count">positive_bucket_counts_count (exponential histogram positive bucket_counts length)</label>
+            <input id="positive_bucket_counts_count" type="number" min="0" placeholder="160" />
+          </div>
+
+          <div class="row">
+            <label for="negative_bucket_counts_count">negative_bucket_counts_count (exponential histogram negative bucket_counts length)</label>
+            <input id="negative_bucket_counts_count" type="number" min="0" placeholder="0" />
+          </div>
+
+          <div class="row">
+            <label for="max_log_records">max_log_records (log records per envelope, 0 = default 10)</label>
+            <input id="max_log_records" type="number" min="0" placeholder="10" />
+          </div>
+
+          <div class="row">
+            <label for="sample_rate">sample_rate (fraction of records, by trace ID / metric name and resource; empty = all)</label>
+            <input id="sample_rate" type="number" min="0" max="1" step="0.01" placeholder="1" />
+          </div>
+
+          <div class="row">
+            <label for="sample_every_n">sample_every_n (keep one of every N matching batches)</label>
+            <input id="sample_every_n" type="number" min="0" placeholder="1" />
+          </div>
+
+          <div class="row">
+            <label for="max_batches_per_second">max_batches_per_second (rate limit, empty = unlimited)</label>
+            <input id="max_batches_per_second" type="number" min="0" step="any" placeholder="0" />
+          </div>
+
+          <div class="row">
+            <label for="max_records_per_second">max_records_per_second (rate limit, empty = unlimited)</label>
+            <input id="max_records_per_second" type="number" min="0" step="any" placeholder="0" />
+          </div>
+
+          <div class="row">
+            <label for="max_bytes_per_second">max_bytes_per_second (rate limit on estimated payload size, empty = unlimited)</label>
+            <input id="max_bytes_per_second" type="number" min="0" step="any" placeholder="0" />
+          </div>
+
+          <div class="row">
+            <label for="label">label (shown in /v1/sessions)</label>
+            <input id="label" placeholder="oncall-1234" />
+          </div>
+
           <div class="row">
             <label for="max_batches">max_batches</label>
             <input id="max_batches" type="number" min="1" value="15" required />
           </div>
 
+          <div class="row">
+            <label for="max_records">max_records (end after this many records, empty = unlimited)</label>
+            <input id="max_records" type="number" min="0" placeholder="0" />
+          </div>
+
+          <div class="row">
+            <label for="max_bytes">max_bytes (end after this much estimated payload, empty = unlimited)</label>
+            <input id="max_bytes" type="number" min="0" placeholder="0" />
+          </div>
+
           <div class="row">
             <label for="timeout_seconds">timeout_seconds</label>
             <input id="timeout_seconds" type="number" min="0" value="30" required />
           </div>
 
           <div class="row">
-            <label class="chip"><input id="verbose_metrics" type="checkbox" /> verbose_metrics (include histogram bucket_counts/explicit_bounds)</label>
+            <label class="chip"><input id="verbose_metrics" type="checkbox" /> verbose_metrics (include histogram buckets and exemplars)</label>
+          </div>
+
+          <div class="row">
+            <label class="chip"><input id="verbose_traces" type="checkbox" /> verbose_traces (include full span details)</label>
+          </div>
+
+          <div class="row">
+            <label class="chip"><input id="verbose_logs" type="checkbox" /> verbose_logs (include full log records)</label>
+          </div>
+
+          <div class="row">
+            <label>format</label>
+            <div class="signals">
+              <label class="chip"><input type="radio" name="format" value="otellens" checked /> otellens</label>
+              <label class="chip"><input type="radio" name="format" value="otlp_json" /> otlp_json</label>
+            </div>
           </div>
 
           <div class="btns">
             <button class="primary" id="start" type="submit">start stream</button>
+            <button id="apply" type="button" disabled>apply filter</button>
+            <button id="pause" type="button" disabled>pause</button>
             <button class="danger" id="stop" type="button" disabled>stop</button>
             <button id="clear" type="button">clear</button>
           </div>
@@ -195,9 +348,13 @@ const uiPage = `<!doctype html>
     const statusEl = document.getElementById('status');
     const eventsEl = document.getElementById('events');
     const startBtn = document.getElementById('start');
+    const applyBtn = document.getElementById('apply');
+    const pauseBtn = document.getElementById('pause');
     const stopBtn = document.getElementById('stop');
     const clearBtn = document.getElementById('clear');
-    let controller = null;
+    let source = null;
+    let sessionId = null;
+    let paused = false;
 
     function parseCSV(value) {
       return value
@@ -222,6 +379,14 @@ const uiPage = `<!doctype html>
       return out;
     }
 
+    function parseJSONArray(id) {
+      const raw = document.getElementById(id).value.trim();
+      if (!raw) return [];
+      const parsed = JSON.parse(raw);
+      if (!Array.isArray(parsed)) throw new Error(id + ' must be a JSON array');
+      return parsed;
+    }
+
     function setStatus(text, cls) {
       statusEl.textContent = text;
       statusEl.className = 'status ' + (cls || '');
@@ -238,28 +403,48 @@ const uiPage = `<!doctype html>
 
     function setRunning(running) {
       startBtn.disabled = running;
+      applyBtn.disabled = !running;
+      pauseBtn.disabled = !running;
       stopBtn.disabled = !running;
+      if (!running) {
+        paused = false;
+        pauseBtn.textContent = 'pause';
+      }
     }
 
-    function parseOptionalInt(id) {
+    function parseOptionalInt(id, allowNegative) {
       const raw = document.getElementById(id).value.trim();
       if (!raw) return null;
       const n = Number(raw);
-      if (!Number.isFinite(n) || n < 0) return null;
+      if (!Number.isFinite(n) || (n < 0 && !allowNegative)) return null;
       return Math.trunc(n);
     }
 
-    async function streamCapture(payload) {
-      controller = new AbortController();
+    function parseEventData(ev) {
+      try {
+        return JSON.parse(ev.data);
+      } catch (e) {
+        return { type: 'parse_error', line: ev.data };
+      }
+    }
+
+    function finishCapture(text, cls) {
+      if (source) source.close();
+      source = null;
+      sessionId = null;
+      setStatus(text, cls);
+      setRunning(false);
+    }
+
+    async function startCapture(payload) {
       setRunning(true);
-      setStatus('connecting...', '');
+      setStatus('creating session...', '');
 
       try {
-        const response = await fetch('/v1/capture/stream', {
+        const response = await fetch('/v1/sessions', {
           method: 'POST',
           headers: { 'content-type': 'application/json' },
           body: JSON.stringify(payload),
-          signal: controller.signal,
         });
 
         if (!response.ok) {
@@ -267,81 +452,151 @@ const uiPage = `<!doctype html>
           throw new Error('HTTP ' + response.status + ': ' + text);
         }
 
-        setStatus('streaming', 'ok');
-
-        const reader = response.body.getReader();
-        const decoder = new TextDecoder();
-        let buf = '';
-
-        while (true) {
-          const { done, value } = await reader.read();
-          if (done) break;
-
-          buf += decoder.decode(value, { stream: true });
-          let nl = buf.indexOf('\n');
-          while (nl >= 0) {
-            const line = buf.slice(0, nl).trim();
-            buf = buf.slice(nl + 1);
-            if (line) {
-              try {
-                addEvent(JSON.parse(line));
-              } catch (e) {
-                addEvent({ type: 'parse_error', line });
-              }
-            }
-            nl = buf.indexOf('\n');
-          }
-        }
-
-        if (buf.trim()) {
-          try {
-            addEvent(JSON.parse(buf.trim()));
-          } catch (e) {
-            addEvent({ type: 'parse_error', line: buf.trim() });
-          }
-        }
-
-        setStatus('stream ended', 'ok');
+        sessionId = (await response.json()).id;
       } catch (err) {
-        if (err.name === 'AbortError') {
-          setStatus('stopped by user', 'warn');
-        } else {
-          setStatus(err.message || String(err), 'err');
-        }
-      } finally {
-        controller = null;
-        setRunning(false);
+        finishCapture(err.message || String(err), 'err');
+        return;
       }
+
+      // EventSource reconnects on its own and resumes from the last envelope id via Last-Event-ID.
+      source = new EventSource('/v1/sessions/' + encodeURIComponent(sessionId) + '/events');
+      source.addEventListener('open', () => setStatus('streaming', 'ok'));
+      source.addEventListener('envelope', (ev) => addEvent(parseEventData(ev)));
+      source.addEventListener('filter_updated', (ev) => addEvent(parseEventData(ev)));
+      source.addEventListener('triggered', (ev) => addEvent(parseEventData(ev)));
+      source.addEventListener('rate_limited', (ev) => addEvent(parseEventData(ev)));
+      source.addEventListener('heartbeat', () => setStatus((paused ? 'paused' : 'streaming') + ' (heartbeat ' + new Date().toLocaleTimeString() + ')', paused ? 'warn' : 'ok'));
+      source.addEventListener('end', (ev) => {
+        addEvent(parseEventData(ev));
+        finishCapture('stream ended', 'ok');
+      });
+      source.addEventListener('error', () => {
+        // EventSource closes for good when the server answers with an HTTP error, e.g. a gone session.
+        if (source.readyState === EventSource.CLOSED) {
+          finishCapture('stream closed by the server', 'err');
+          return;
+        }
+        setStatus('connection lost, reconnecting...', 'warn');
+      });
     }
 
-    form.addEventListener('submit', (ev) => {
-      ev.preventDefault();
-      if (controller) return;
+    function deleteSession(keepalive) {
+      if (!sessionId) return;
+      fetch('/v1/sessions/' + encodeURIComponent(sessionId), { method: 'DELETE', keepalive }).catch(() => {});
+    }
 
+    // buildPayload reads the form into a session definition; it returns null after reporting invalid input.
+    function buildPayload() {
       const signals = Array.from(document.querySelectorAll('input[name="signals"]:checked')).map((x) => x.value);
 
-      const payload = {
+      let attributeFilters;
+      try {
+        attributeFilters = parseJSONArray('attribute_filters');
+      } catch (err) {
+        setStatus('attribute_filters: ' + err.message, 'err');
+        return null;
+      }
+
+      const triggerWhere = document.getElementById('trigger_where').value.trim();
+      const trigger = triggerWhere
+        ? {
+            signals: Array.from(document.querySelectorAll('input[name="trigger_signals"]:checked')).map((x) => x.value),
+            where: triggerWhere,
+            capture_seconds: Number(document.getElementById('trigger_capture_seconds').value || 0),
+          }
+        : undefined;
+
+      return {
         signals,
+        trigger,
         metric_names: parseCSV(document.getElementById('metric_names').value),
         span_names: parseCSV(document.getElementById('span_names').value),
+        span_kinds: parseCSV(document.getElementById('span_kinds').value),
+        span_status_codes: parseCSV(document.getElementById('span_status_codes').value),
+        span_status_message_contains: document.getElementById('span_status_message_contains').value.trim(),
+        min_span_duration_ms: Number(document.getElementById('min_span_duration_ms').value || 0),
+        max_span_duration_ms: Number(document.getElementById('max_span_duration_ms').value || 0),
+        root_spans_only: document.getElementById('root_spans_only').checked,
         attribute_names: parseCSV(document.getElementById('attribute_names').value),
+        attribute_filters: attributeFilters,
+        where: document.getElementById('where').value.trim(),
+        trace_ids: parseCSV(document.getElementById('trace_ids').value),
+        span_ids: parseCSV(document.getElementById('span_ids').value),
+        has_exemplars: document.getElementById('has_exemplars').checked,
+        exemplar_trace_ids: parseCSV(document.getElementById('exemplar_trace_ids').value),
         resource_attributes: parseResourceAttributes(document.getElementById('resource_attributes').value),
         log_body_contains: document.getElementById('log_body_contains').value.trim(),
         min_severity_number: Number(document.getElementById('min_severity_number').value || 0),
         bucket_counts_count: parseOptionalInt('bucket_counts_count'),
         explicit_bounds_count: parseOptionalInt('explicit_bounds_count'),
+        exponential_scale: parseOptionalInt('exponential_scale', true),
+        positive_bucket_counts_count: parseOptionalInt('positive_bucket_counts_count'),
+        negative_bucket_counts_count: parseOptionalInt('negative_bucket_counts_count'),
         verbose_metrics: document.getElementById('verbose_metrics').checked,
+        verbose_traces: document.getElementById('verbose_traces').checked,
+        verbose_logs: document.getElementById('verbose_logs').checked,
+        format: document.querySelector('input[name="format"]:checked').value,
+        max_log_records: Number(document.getElementById('max_log_records').value || 0),
+        sample_rate: Number(document.getElementById('sample_rate').value || 0),
+        sample_every_n: Number(document.getElementById('sample_every_n').value || 0),
+        max_batches_per_second: Number(document.getElementById('max_batches_per_second').value || 0),
+        max_records_per_second: Number(document.getElementById('max_records_per_second').value || 0),
+        max_bytes_per_second: Number(document.getElementById('max_bytes_per_second').value || 0),
         max_batches: Number(document.getElementById('max_batches').value || 15),
+        max_records: Number(document.getElementById('max_records').value || 0),
+        max_bytes: Number(document.getElementById('max_bytes').value || 0),
         timeout_seconds: Number(document.getElementById('timeout_seconds').value || 30),
+        label: document.getElementById('label').value.trim(),
       };
+    }
+
+    form.addEventListener('submit', (ev) => {
+      ev.preventDefault();
+      if (source || sessionId) return;
+
+      const payload = buildPayload();
+      if (payload) startCapture(payload);
+    });
+
+    // Replacing the filter keeps the session, its counters and stream position; format and limits stay as started.
+    applyBtn.addEventListener('click', async () => {
+      if (!sessionId) return;
+      const payload = buildPayload();
+      if (!payload) return;
+
+      const response = await fetch('/v1/sessions/' + encodeURIComponent(sessionId) + '/filter', {
+        method: 'PUT',
+        headers: { 'content-type': 'application/json' },
+        body: JSON.stringify(payload),
+      });
+      if (!response.ok) {
+        setStatus('filter update failed: HTTP ' + response.status + ': ' + (await response.text()), 'err');
+        return;
+      }
+      setStatus('filter updated', 'ok');
+    });
 
-      streamCapture(payload);
+    // A paused session stays registered but skips batches until resumed.
+    pauseBtn.addEventListener('click', async () => {
+      if (!sessionId) return;
+      const action = paused ? 'resume' : 'pause';
+      const response = await fetch('/v1/sessions/' + encodeURIComponent(sessionId) + '/' + action, { method: 'POST' });
+      if (!response.ok) {
+        setStatus(action + ' failed: HTTP ' + response.status + ': ' + (await response.text()), 'err');
+        return;
+      }
+      paused = (await response.json()).paused;
+      pauseBtn.textContent = paused ? 'resume' : 'pause';
+      setStatus(paused ? 'paused' : 'streaming', paused ? 'warn' : 'ok');
     });
 
     stopBtn.addEventListener('click', () => {
-      if (controller) controller.abort();
+      deleteSession(false);
+      finishCapture('stopped by user', 'warn');
     });
 
+    window.addEventListener('pagehide', () => deleteSession(true));
+
     clearBtn.addEventListener('click', () => {
       eventsEl.innerHTML = '';
     });
@@ -0,0 +1,362 @@
+package httpapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gobwas/ws"
+	"github.com/gobwas/ws/wsutil"
+	"github.com/utrack/otellens/internal/capture"
+	"github.com/utrack/otellens/internal/model"
+	"github.com/utrack/otellens/internal/request"
+	"go.uber.org/zap"
+)
+
+const websocketPath = "/v1/ws"
+
+// maxWSMessageSize caps one client message; a larger message closes the socket with status 1009.
+const maxWSMessageSize = 1 << 20
+
+var errWSMessageTooBig = errors.New("websocket message is too big")
+
+// wsUpgrader upgrades requests that passed checkOrigin.
+var wsUpgrader = ws.HTTPUpgrader{Timeout: 5 * time.Second}
+
+// handleWebSocket serves an interactive capture connection. The client drives one session at a time
+// with start/update/pause/resume/stop control messages and receives envelopes on the same socket.
+// update replaces the running session's filter in place, like PUT /v1/sessions/{id}/filter.
+func (h *Handler) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.writeErr(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	// Browsers send cookies and credentials with cross-origin WebSocket handshakes, so other sites
+	// must not be able to drive captures from a visitor's browser.
+	if !h.checkOrigin(r) {
+		h.writeErr(w, http.StatusForbidden, "cross-origin websocket requests are not allowed")
+		return
+	}
+	conn, _, _, err := wsUpgrader.Upgrade(r, w)
+	if err != nil {
+		h.logger.Debug("websocket upgrade failed", zap.Error(err))
+		return
+	}
+	defer conn.Close()
+
+	done := make(chan struct{})
+	defer close(done)
+
+	socket := &wsSocket{conn: conn}
+	c := &wsCapture{handler: h, socket: socket, r: r}
+	defer c.stop(model.EndReasonClientDisconnect)
+
+	controls := socket.readControls(done)
+
+	var heartbeat <-chan time.Time
+	if h.heartbeatInterval > 0 {
+		ticker := time.NewTicker(h.heartbeatInterval)
+		defer ticker.Stop()
+		heartbeat = ticker.C
+	}
+
+	for {
+		var err error
+		select {
+		case msg, ok := <-controls:
+			if !ok {
+				return
+			}
+			err = c.control(msg)
+		case event, ok := <-c.events():
+			if !ok {
+				// The session ended on its own and already has its reason.
+				err = c.finish(model.EndReasonStopped)
+				break
+			}
+			err = c.writeEvent(c.session, event)
+		case now := <-heartbeat:
+			if c.session != nil {
+				err = socket.write("heartbeat", model.Heartbeat{Type: "heartbeat", SessionID: c.session.ID(), Time: now.UTC()})
+			}
+		}
+		if err != nil {
+			h.logger.Debug("websocket write failed", zap.Error(err))
+			return
+		}
+	}
+}
+
+// checkOrigin accepts requests without an Origin header (non-browser clients), same-origin requests
+// and the origins allowed via WithAllowedOrigins.
+func (h *Handler) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	if _, ok := h.allowedOrigins[origin]; ok {
+		return true
+	}
+	if _, ok := h.allowedOrigins["*"]; ok {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, r.Host)
+}
+
+// wsCapture is the session currently bound to one WebSocket connection.
+type wsCapture struct {
+	handler *Handler
+	socket  *wsSocket
+	r       *http.Request
+
+	session *capture.Session
+	cancel  context.CancelFunc
+}
+
+// events returns the bound session's queue, or nil so that the select blocks while no session runs.
+func (c *wsCapture) events() <-chan model.Envelope {
+	if c.session == nil {
+		return nil
+	}
+	return c.session.Events()
+}
+
+// control applies one client message. Invalid messages are answered with an error message;
+// the returned error is reserved for a broken socket.
+func (c *wsCapture) control(msg wsControl) error {
+	if msg.err != nil {
+		return c.socket.write("error", StreamError{Error: msg.err.Error()})
+	}
+	switch msg.Type {
+	case "start":
+		if c.session != nil {
+			return c.socket.write("error", StreamError{Error: "a session is already running; send update or stop"})
+		}
+		req, registerReq, streamErr := parseWSRequest(msg.Request, c.r)
+		if streamErr != nil {
+			return c.socket.write("error", *streamErr)
+		}
+		if req.IncludeHistorySeconds > 0 && !c.handler.registry.FlightRecorderEnabled() {
+			return c.socket.write("error", StreamError{Error: request.ErrFlightRecorderDisabled.Error()})
+		}
+		return c.register(req, registerReq)
+	case "update":
+		if c.session == nil {
+			return c.socket.write("error", StreamError{Error: "no session is running"})
+		}
+		if msg.Request == nil {
+			return c.socket.write("error", StreamError{Error: "request is required"})
+		}
+		update, err := sessionUpdate(c.session, *msg.Request)
+		if err != nil {
+			return c.socket.write("error", filterStreamError(err))
+		}
+		if err := c.session.Update(update); err != nil {
+			return c.socket.write("error", StreamError{Error: err.Error()})
+		}
+		return c.socket.write("updated", sessionToView(c.session))
+	case "pause", "resume":
+		if c.session == nil {
+			return c.socket.write("error", StreamError{Error: "no session is running"})
+		}
+		if msg.Type == "pause" {
+			c.session.Pause()
+			return c.socket.write("paused", sessionToView(c.session))
+		}
+		c.session.Resume()
+		return c.socket.write("resumed", sessionToView(c.session))
+	case "stop":
+		if c.session == nil {
+			return c.socket.write("error", StreamError{Error: "no session is running"})
+		}
+		return c.finish(model.EndReasonStopped)
+	default:
+		return c.socket.write("error", StreamError{Error: fmt.Sprintf("unknown message type %q", msg.Type)})
+	}
+}
+
+func (c *wsCapture) register(req request.StreamRequest, registerReq capture.RegisterRequest) error {
+	timeout := defaultSessionTimeout
+	if req.TimeoutSeconds > 0 {
+		timeout = time.Duration(req.TimeoutSeconds) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(c.r.Context(), timeout)
+	session, err := c.handler.registry.Register(ctx, registerReq)
+	if err != nil {
+		cancel()
+		return c.socket.write("error", StreamError{Error: err.Error()})
+	}
+	c.session = session
+	c.cancel = cancel
+	return c.socket.write("started", sessionToView(session))
+}
+
+// finish ends the bound session for reason, flushes its remaining queued envelopes and writes the StreamEnd.
+func (c *wsCapture) finish(reason string) error {
+	session := c.session
+	c.stop(reason)
+	for event := range session.Events() {
+		if err := c.writeEvent(session, event); err != nil {
+			return err
+		}
+	}
+	return c.socket.write("end", session.StreamEnd())
+}
+
+// writeEvent writes one envelope of session and counts it toward the session's bytes written.
+func (c *wsCapture) writeEvent(session *capture.Session, event model.Envelope) error {
+	n, err := c.socket.writeCounted(envelopeEventType(event), event)
+	session.AddBytesWritten(n)
+	return err
+}
+
+// stop ends the bound session for reason without writing anything.
+func (c *wsCapture) stop(reason string) {
+	if c.session == nil {
+		return
+	}
+	c.handler.registry.End(c.session.ID(), reason)
+	c.cancel()
+	c.session = nil
+	c.cancel = nil
+}
+
+// parseWSRequest validates and compiles the session definition of a start message.
+func parseWSRequest(raw *request.StreamRequest, r *http.Request) (request.StreamRequest, capture.RegisterRequest, *StreamError) {
+	if raw == nil {
+		return request.StreamRequest{}, capture.RegisterRequest{}, &StreamError{Error: "request is required"}
+	}
+	err := request.Validate(*raw)
+	var registerReq capture.RegisterRequest
+	if err == nil {
+		registerReq, err = request.RegisterRequest(*raw, r.RemoteAddr)
+	}
+	if err != nil {
+		streamErr := filterStreamError(err)
+		return request.StreamRequest{}, capture.RegisterRequest{}, &streamErr
+	}
+	return *raw, registerReq, nil
+}
+
+// wsControl is a decoded client message, or the reason it could not be decoded.
+type wsControl struct {
+	WSClientMessage
+	err error
+}
+
+// wsSocket serializes frame writes: the read loop answers pings and close frames concurrently
+// with the session loop writing envelopes.
+type wsSocket struct {
+	conn net.Conn
+	mu   sync.Mutex
+}
+
+func (s *wsSocket) write(messageType string, data interface{}) error {
+	_, err := s.writeCounted(messageType, data)
+	return err
+}
+
+// writeCounted writes a message like write and returns the size of its payload.
+func (s *wsSocket) writeCounted(messageType string, data interface{}) (int, error) {
+	body, err := json.Marshal(WSServerMessage{Type: messageType, Data: data})
+	if err != nil {
+		return 0, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := wsutil.WriteServerMessage(s.conn, ws.OpText, body); err != nil {
+		return 0, err
+	}
+	return len(body), nil
+}
+
+// writeRaw writes pre-encoded frames such as control frame replies in one piece.
+func (s *wsSocket) writeRaw(frames []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err := s.conn.Write(frames)
+	return err
+}
+
+// readControls reads client messages until the connection fails or closes, then closes the channel.
+func (s *wsSocket) readControls(done <-chan struct{}) <-chan wsControl {
+	out := make(chan wsControl)
+	go func() {
+		defer close(out)
+		for {
+			data, err := s.readMessage()
+			if err != nil {
+				return
+			}
+			var msg wsControl
+			if err := json.Unmarshal(data, &msg.WSClientMessage); err != nil {
+				msg.err = errors.New("invalid JSON message")
+			}
+			select {
+			case out <- msg:
+			case <-done:
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// readMessage returns the next text message, answering control frames on the way. A message over
+// maxWSMessageSize is answered with a close frame and fails with errWSMessageTooBig.
+func (s *wsSocket) readMessage() ([]byte, error) {
+	handleControl := func(hdr ws.Header, r io.Reader) error {
+		var reply bytes.Buffer
+		err := wsutil.ControlFrameHandler(&reply, ws.StateServerSide)(hdr, r)
+		if reply.Len() > 0 {
+			if writeErr := s.writeRaw(reply.Bytes()); writeErr != nil && err == nil {
+				err = writeErr
+			}
+		}
+		return err
+	}
+	rd := wsutil.Reader{
+		Source:         s.conn,
+		State:          ws.StateServerSide,
+		CheckUTF8:      true,
+		OnIntermediate: handleControl,
+	}
+	for {
+		hdr, err := rd.NextFrame()
+		if err != nil {
+			return nil, err
+		}
+		if hdr.OpCode.IsControl() {
+			if err := handleControl(hdr, &rd); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if hdr.OpCode != ws.OpText {
+			if err := rd.Discard(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		data, err := io.ReadAll(io.LimitReader(&rd, maxWSMessageSize+1))
+		if err != nil {
+			return nil, err
+		}
+		if len(data) > maxWSMessageSize {
+			closeFrame := ws.NewCloseFrame(ws.NewCloseFrameBody(ws.StatusMessageTooBig, errWSMessageTooBig.Error()))
+			_ = s.writeRaw(ws.MustCompileFrame(closeFrame))
+			return nil, errWSMessageTooBig
+		}
+		return data, nil
+	}
+}
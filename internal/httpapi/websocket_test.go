@@ -0,0 +1,229 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gobwas/ws"
+	"github.com/gobwas/ws/wsutil"
+	"github.com/utrack/otellens/internal/capture"
+	"github.com/utrack/otellens/internal/model"
+	"go.uber.org/zap"
+)
+
+type wsTestMessage struct {
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+func dialWS(t *testing.T, serverURL string) net.Conn {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	conn, _, _, err := ws.Dial(ctx, "ws"+strings.TrimPrefix(serverURL, "http")+websocketPath)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	return conn
+}
+
+func sendWS(t *testing.T, conn net.Conn, msg string) {
+	t.Helper()
+	if err := wsutil.WriteClientMessage(conn, ws.OpText, []byte(msg)); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+}
+
+func readWS(t *testing.T, conn net.Conn) wsTestMessage {
+	t.Helper()
+	_ = conn.SetReadDeadline(time.Now().Add(3 * time.Second))
+	data, _, err := wsutil.ReadServerData(conn)
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	var msg wsTestMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		t.Fatalf("invalid message %q: %v", data, err)
+	}
+	return msg
+}
+
+func TestWebSocketStartUpdateStop(t *testing.T) {
+	registry := capture.NewRegistry(4)
+	h := NewHandler(registry, zap.NewNop())
+	h.heartbeatInterval = 0
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	conn := dialWS(t, server.URL)
+	defer conn.Close()
+
+	sendWS(t, conn, `{"type":"stop"}`)
+	if msg := readWS(t, conn); msg.Type != "error" {
+		t.Fatalf("expected error for stop without a session, got %+v", msg)
+	}
+
+	sendWS(t, conn, `{"type":"start","request":{"signals":["metrics"],"metric_names":["A"],"max_batches":5}}`)
+	msg := readWS(t, conn)
+	if msg.Type != "started" {
+		t.Fatalf("expected started, got %+v", msg)
+	}
+	var first SessionView
+	if err := json.Unmarshal(msg.Data, &first); err != nil || first.ID == "" {
+		t.Fatalf("invalid started payload %s: %v", msg.Data, err)
+	}
+
+	registry.PublishMetrics(newMetricsBatch("A"))
+	msg = readWS(t, conn)
+	var envelope model.Envelope
+	if msg.Type != "envelope" || json.Unmarshal(msg.Data, &envelope) != nil || envelope.SessionID != first.ID {
+		t.Fatalf("expected envelope of the first session, got %+v", msg)
+	}
+
+	sendWS(t, conn, `{"type":"update","request":{"signals":["metrics"],"where":"name ==","max_batches":5}}`)
+	msg = readWS(t, conn)
+	var streamErr StreamError
+	if msg.Type != "error" || json.Unmarshal(msg.Data, &streamErr) != nil || streamErr.Position == 0 {
+		t.Fatalf("expected error with position for an invalid update, got %+v", msg)
+	}
+
+	var end model.StreamEnd
+	sendWS(t, conn, `{"type":"update","request":{"signals":["metrics"],"metric_names":["B"]}}`)
+	msg = readWS(t, conn)
+	var updated SessionView
+	if msg.Type != "updated" || json.Unmarshal(msg.Data, &updated) != nil || updated.ID != first.ID || updated.Filter.MaxBatches != 5 {
+		t.Fatalf("expected the same session with kept limits after update, got %+v", msg)
+	}
+	msg = readWS(t, conn)
+	var marker model.Envelope
+	if msg.Type != model.EnvelopeTypeFilterUpdated || json.Unmarshal(msg.Data, &marker) != nil || marker.BatchIndex != 2 {
+		t.Fatalf("expected filter_updated marker at batch 2, got %+v", msg)
+	}
+
+	registry.PublishMetrics(newMetricsBatch("A"))
+	registry.PublishMetrics(newMetricsBatch("B"))
+	msg = readWS(t, conn)
+	if msg.Type != "envelope" || json.Unmarshal(msg.Data, &envelope) != nil || envelope.SessionID != first.ID || envelope.BatchIndex != 3 {
+		t.Fatalf("expected envelope 3 of the updated session, got %+v", msg)
+	}
+
+	sendWS(t, conn, `{"type":"pause"}`)
+	var paused SessionView
+	if msg = readWS(t, conn); msg.Type != "paused" || json.Unmarshal(msg.Data, &paused) != nil || !paused.Paused {
+		t.Fatalf("expected paused session, got %+v", msg)
+	}
+	registry.PublishMetrics(newMetricsBatch("B"))
+	sendWS(t, conn, `{"type":"resume"}`)
+	if msg = readWS(t, conn); msg.Type != "resumed" {
+		t.Fatalf("expected resumed without envelopes published while paused, got %+v", msg)
+	}
+
+	sendWS(t, conn, `{"type":"stop"}`)
+	msg = readWS(t, conn)
+	if msg.Type != "end" || json.Unmarshal(msg.Data, &end) != nil || end.Sent != 2 || end.PausedSeconds <= 0 || end.Reason != model.EndReasonStopped {
+		t.Fatalf("expected end with 2 sent batches and paused time after stop, got %+v", msg)
+	}
+	if end.BytesWritten == 0 || end.FirstCapturedAt == nil || end.LastCapturedAt.Before(*end.FirstCapturedAt) {
+		t.Fatalf("expected bytes written and capture times in the end, got %+v", end)
+	}
+	if _, ok := registry.Session(first.ID); ok {
+		t.Fatal("expected stopped session to be deregistered")
+	}
+
+	sendWS(t, conn, `{"type":"start","request":{"signals":["metrics"],"max_batches":1}}`)
+	if msg = readWS(t, conn); msg.Type != "started" {
+		t.Fatalf("expected the socket to accept a new session, got %+v", msg)
+	}
+	registry.PublishMetrics(newMetricsBatch("C"))
+	if msg = readWS(t, conn); msg.Type != "envelope" {
+		t.Fatalf("expected envelope, got %+v", msg)
+	}
+	if msg = readWS(t, conn); msg.Type != "end" {
+		t.Fatalf("expected end once max_batches is reached, got %+v", msg)
+	}
+}
+
+func TestWebSocketCloseReleasesSession(t *testing.T) {
+	registry := capture.NewRegistry(4)
+	h := NewHandler(registry, zap.NewNop())
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	conn := dialWS(t, server.URL)
+	sendWS(t, conn, `{"type":"start","request":{"signals":["metrics"],"max_batches":5}}`)
+	if msg := readWS(t, conn); msg.Type != "started" {
+		t.Fatalf("expected started, got %+v", msg)
+	}
+	conn.Close()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(registry.Sessions()) > 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("expected session to end when the socket closes")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestWebSocketClosesOnOversizedMessage(t *testing.T) {
+	h := NewHandler(capture.NewRegistry(4), zap.NewNop())
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	conn := dialWS(t, server.URL)
+	defer conn.Close()
+	// One byte over the limit, so the server reads the whole frame before it closes the socket.
+	sendWS(t, conn, strings.Repeat(" ", maxWSMessageSize)+"{")
+
+	_ = conn.SetReadDeadline(time.Now().Add(3 * time.Second))
+	_, _, err := wsutil.ReadServerData(conn)
+	var closed wsutil.ClosedError
+	if !errors.As(err, &closed) || closed.Code != ws.StatusMessageTooBig {
+		t.Fatalf("expected close with status 1009, got %v", err)
+	}
+}
+
+func TestWebSocketRejectsCrossOriginRequests(t *testing.T) {
+	h := NewHandler(capture.NewRegistry(4), zap.NewNop(), WithAllowedOrigins("https://ui.example.com"))
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + websocketPath
+
+	for origin, allowed := range map[string]bool{
+		"":                       true,
+		server.URL:               true,
+		"https://ui.example.com": true,
+		"https://evil.example":   false,
+	} {
+		dialer := ws.Dialer{Timeout: 3 * time.Second}
+		if origin != "" {
+			dialer.Header = ws.HandshakeHeaderHTTP(http.Header{"Origin": []string{origin}})
+		}
+		conn, _, _, err := dialer.Dial(context.Background(), wsURL)
+		if conn != nil {
+			conn.Close()
+		}
+		if (err == nil) != allowed {
+			t.Fatalf("origin %q: expected allowed=%v, got err %v", origin, allowed, err)
+		}
+	}
+}
@@ -11,26 +11,137 @@ const (
 	SignalLogs    SignalType = "logs"
 )
 
+// OutputFormat selects how envelope payloads are encoded.
+type OutputFormat string
+
+const (
+	// FormatOtellens emits otellens' own projections (MetricsPayload, TracesPayload, LogsPayload).
+	FormatOtellens OutputFormat = "otellens"
+	// FormatOTLPJSON emits the matching subset of each batch as OTLP/JSON, keeping resource/scope structure.
+	FormatOTLPJSON OutputFormat = "otlp_json"
+	// FormatOTLPProto carries the matching subset as binary OTLP protobuf ([]byte payloads).
+	// It is selected by content negotiation on binary streams, not by the JSON request body.
+	FormatOTLPProto OutputFormat = "otlp_proto"
+)
+
+// EnvelopeTypeFilterUpdated marks an envelope without telemetry: the session's filter was replaced
+// and later envelopes match the new definition, which is carried as Payload.
+const EnvelopeTypeFilterUpdated = "filter_updated"
+
+// EnvelopeTypeTriggered marks where an armed session's trigger fired; Signal names the signal that
+// fired it and Payload carries the trigger definition. Captured envelopes follow.
+const EnvelopeTypeTriggered = "triggered"
+
+// EnvelopeTypeRateLimited reports that the session's rate limits suppressed envelopes since the
+// previous such marker; Payload is a *RateLimited.
+const EnvelopeTypeRateLimited = "rate_limited"
+
 // Envelope is a single NDJSON event streamed to API clients.
+// BatchIndex is the position in the session's stream; markers take a position too.
 type Envelope struct {
-	SessionID  string      `json:"session_id"`
-	Signal     SignalType  `json:"signal"`
-	BatchIndex uint64      `json:"batch_index"`
-	CapturedAt time.Time   `json:"captured_at"`
-	Payload    interface{} `json:"payload"`
+	// Type is empty for telemetry envelopes and names the marker otherwise.
+	Type       string     `json:"type,omitempty"`
+	SessionID  string     `json:"session_id"`
+	Signal     SignalType `json:"signal,omitempty"`
+	BatchIndex uint64     `json:"batch_index"`
+	CapturedAt time.Time  `json:"captured_at"`
+	// History is set on envelopes replayed from the flight recorder; CapturedAt is then the recording time.
+	History bool `json:"history,omitempty"`
+	// Records is the number of matching metrics, spans or log records in the payload.
+	Records int `json:"records,omitempty"`
+	// Sampling is set when the session samples; scale counts by EveryN / Rate to extrapolate.
+	Sampling *Sampling   `json:"sampling,omitempty"`
+	Payload  interface{} `json:"payload"`
+}
+
+// Sampling describes the sampling applied to an envelope.
+type Sampling struct {
+	// Rate is the fraction of matching records kept; 1 when records are not sampled.
+	Rate float64 `json:"rate"`
+	// EveryN means one of every EveryN matching batches was kept; 1 when batches are not sampled.
+	EveryN int `json:"every_n"`
 }
 
+// RateLimited counts what a session's rate limits suppressed since its previous rate_limited marker.
+type RateLimited struct {
+	Batches uint64 `json:"batches"`
+	Records uint64 `json:"records"`
+	// Bytes is the estimated encoded size of the suppressed payloads; 0 unless a byte limit is set.
+	Bytes uint64 `json:"bytes,omitempty"`
+}
+
+// Budgets a session can end on, as reported by StreamEnd.LimitReached. They are end reasons too.
+const (
+	LimitMaxBatches = "max_batches"
+	LimitMaxRecords = "max_records"
+	LimitMaxBytes   = "max_bytes"
+)
+
+// Other reasons a session can end for, as reported by StreamEnd.Reason.
+const (
+	// EndReasonTimeout: timeout_seconds elapsed.
+	EndReasonTimeout = "timeout"
+	// EndReasonCaptureWindow: the capture_seconds of a fired trigger elapsed.
+	EndReasonCaptureWindow = "capture_window"
+	// EndReasonClientDisconnect: the stream that owned the session went away.
+	EndReasonClientDisconnect = "client_disconnect"
+	// EndReasonStopped: the client stopped the session on its WebSocket.
+	EndReasonStopped = "stopped"
+	// EndReasonCancelled: the session was cancelled via DELETE /v1/sessions/{id}.
+	EndReasonCancelled = "cancelled"
+	// EndReasonDetachExpired: no stream attached to a detachable session within its grace period.
+	EndReasonDetachExpired = "detach_expired"
+	// EndReasonShutdown: the collector shut the exporter down.
+	EndReasonShutdown = "shutdown"
+)
+
 // StreamEnd is emitted when a capture session ends.
 type StreamEnd struct {
 	Type      string `json:"type"`
 	SessionID string `json:"session_id"`
-	Sent      uint64 `json:"sent"`
-	Dropped   uint64 `json:"dropped"`
+	// Reason tells why the session ended: one of the Limit* budgets or EndReason* values.
+	Reason  string `json:"reason"`
+	Sent    uint64 `json:"sent"`
+	Dropped uint64 `json:"dropped"`
+	// Signals breaks the telemetry batches down per signal; signals that matched nothing are left out.
+	Signals map[SignalType]SignalStats `json:"signals,omitempty"`
+	// DurationSeconds is the time from registration to the end of the session.
+	DurationSeconds float64 `json:"duration_seconds"`
+	// BytesWritten counts the bytes written to the session's streams before this event.
+	BytesWritten uint64 `json:"bytes_written"`
+	// FirstCapturedAt and LastCapturedAt are the CapturedAt of the first and last streamed telemetry envelopes.
+	FirstCapturedAt *time.Time `json:"first_captured_at,omitempty"`
+	LastCapturedAt  *time.Time `json:"last_captured_at,omitempty"`
+	// PausedSeconds is the total time the session spent paused.
+	PausedSeconds float64 `json:"paused_seconds,omitempty"`
+	// RateLimited and RateLimitedRecords count what the session's rate limits suppressed;
+	// unlike Dropped, these batches were never offered to the queue.
+	RateLimited        uint64 `json:"rate_limited,omitempty"`
+	RateLimitedRecords uint64 `json:"rate_limited_records,omitempty"`
+	// LimitReached names the budget that ended the session (LimitMaxBatches, LimitMaxRecords or
+	// LimitMaxBytes); empty if it ended otherwise.
+	LimitReached string `json:"limit_reached,omitempty"`
+}
+
+// SignalStats counts one signal's batches of a session. Matched batches matched the filter while the
+// session was capturing; those neither sent nor dropped were skipped by sampling or rate limits.
+type SignalStats struct {
+	Matched uint64 `json:"matched"`
+	Sent    uint64 `json:"sent"`
+	Dropped uint64 `json:"dropped"`
+}
+
+// Heartbeat keeps idle streams alive through proxies that drop silent connections.
+type Heartbeat struct {
+	Type      string    `json:"type"`
+	SessionID string    `json:"session_id"`
+	Time      time.Time `json:"time"`
 }
 
 // MetricsPayload is a detailed metrics batch projection.
 type MetricsPayload struct {
 	ResourceMetrics int      `json:"resource_metrics"`
+	ScopeMetrics    int      `json:"scope_metrics"`
 	MetricCount     int      `json:"metric_count"`
 	Metrics         []Metric `json:"metrics"`
 }
@@ -61,10 +172,34 @@ type MetricDataPoint struct {
 	Value             interface{}            `json:"value,omitempty"`
 	Count             uint64                 `json:"count,omitempty"`
 	Sum               float64                `json:"sum,omitempty"`
+	Min               *float64               `json:"min,omitempty"`
+	Max               *float64               `json:"max,omitempty"`
 	BucketCounts      []uint64               `json:"bucket_counts,omitempty"`
 	ExplicitBounds    []float64              `json:"explicit_bounds,omitempty"`
 	QuantileValues    []QuantileValue        `json:"quantile_values,omitempty"`
-	Flags             uint32                 `json:"flags,omitempty"`
+	// Scale is set for exponential histogram datapoints only; zero is a valid scale.
+	Scale         *int32              `json:"scale,omitempty"`
+	ZeroCount     uint64              `json:"zero_count,omitempty"`
+	ZeroThreshold float64             `json:"zero_threshold,omitempty"`
+	Positive      *ExponentialBuckets `json:"positive,omitempty"`
+	Negative      *ExponentialBuckets `json:"negative,omitempty"`
+	Exemplars     []Exemplar          `json:"exemplars,omitempty"`
+	Flags         uint32              `json:"flags,omitempty"`
+}
+
+// Exemplar is one datapoint exemplar with its trace context.
+type Exemplar struct {
+	TimeUnixNano       uint64                 `json:"time_unix_nano,omitempty"`
+	Value              interface{}            `json:"value,omitempty"`
+	FilteredAttributes map[string]interface{} `json:"filtered_attributes,omitempty"`
+	TraceID            string                 `json:"trace_id,omitempty"`
+	SpanID             string                 `json:"span_id,omitempty"`
+}
+
+// ExponentialBuckets is one side (positive or negative) of an exponential histogram datapoint.
+type ExponentialBuckets struct {
+	Offset       int32    `json:"offset"`
+	BucketCounts []uint64 `json:"bucket_counts"`
 }
 
 // QuantileValue represents one summary quantile value pair.
@@ -76,13 +211,84 @@ type QuantileValue struct {
 // TracesPayload is a concise traces batch projection.
 type TracesPayload struct {
 	ResourceSpans int      `json:"resource_spans"`
+	ScopeSpans    int      `json:"scope_spans"`
 	SpanCount     int      `json:"span_count"`
 	SpanNames     []string `json:"span_names"`
+	Spans         []Span   `json:"spans,omitempty"`
+}
+
+// Span is a detailed span projection including resource, scope, events and links.
+// Kind and Status.Code are lowercase ("server", "error"), as accepted by the span filters and `where`.
+type Span struct {
+	TraceID                string                 `json:"trace_id"`
+	SpanID                 string                 `json:"span_id"`
+	ParentSpanID           string                 `json:"parent_span_id,omitempty"`
+	TraceState             string                 `json:"trace_state,omitempty"`
+	Name                   string                 `json:"name"`
+	Kind                   string                 `json:"kind"`
+	StartTimeUnixNano      uint64                 `json:"start_time_unix_nano,omitempty"`
+	EndTimeUnixNano        uint64                 `json:"end_time_unix_nano,omitempty"`
+	DurationNano           uint64                 `json:"duration_nano,omitempty"`
+	Status                 SpanStatus             `json:"status"`
+	Attributes             map[string]interface{} `json:"attributes,omitempty"`
+	DroppedAttributesCount uint32                 `json:"dropped_attributes_count,omitempty"`
+	Events                 []SpanEvent            `json:"events,omitempty"`
+	DroppedEventsCount     uint32                 `json:"dropped_events_count,omitempty"`
+	Links                  []SpanLink             `json:"links,omitempty"`
+	DroppedLinksCount      uint32                 `json:"dropped_links_count,omitempty"`
+	Flags                  uint32                 `json:"flags,omitempty"`
+	ResourceAttributes     map[string]interface{} `json:"resource_attributes,omitempty"`
+	Scope                  Scope                  `json:"scope"`
+}
+
+// SpanStatus captures span status code and message.
+type SpanStatus struct {
+	Code    string `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+// SpanEvent is one timestamped span event.
+type SpanEvent struct {
+	Name                   string                 `json:"name"`
+	TimeUnixNano           uint64                 `json:"time_unix_nano,omitempty"`
+	Attributes             map[string]interface{} `json:"attributes,omitempty"`
+	DroppedAttributesCount uint32                 `json:"dropped_attributes_count,omitempty"`
+}
+
+// SpanLink is a reference from a span to another span context.
+type SpanLink struct {
+	TraceID                string                 `json:"trace_id"`
+	SpanID                 string                 `json:"span_id"`
+	TraceState             string                 `json:"trace_state,omitempty"`
+	Attributes             map[string]interface{} `json:"attributes,omitempty"`
+	DroppedAttributesCount uint32                 `json:"dropped_attributes_count,omitempty"`
+	Flags                  uint32                 `json:"flags,omitempty"`
 }
 
 // LogsPayload is a concise logs batch projection.
 type LogsPayload struct {
-	ResourceLogs int      `json:"resource_logs"`
-	LogCount     int      `json:"log_count"`
-	Bodies       []string `json:"bodies"`
+	ResourceLogs int         `json:"resource_logs"`
+	ScopeLogs    int         `json:"scope_logs"`
+	LogCount     int         `json:"log_count"`
+	Bodies       []string    `json:"bodies"`
+	Records      []LogRecord `json:"records,omitempty"`
+	// Truncated is set when more records matched than the per-envelope limit allowed to project.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// LogRecord is a detailed log record projection including resource, scope and trace context.
+type LogRecord struct {
+	TimeUnixNano           uint64                 `json:"time_unix_nano,omitempty"`
+	ObservedTimeUnixNano   uint64                 `json:"observed_time_unix_nano,omitempty"`
+	SeverityNumber         int32                  `json:"severity_number,omitempty"`
+	SeverityText           string                 `json:"severity_text,omitempty"`
+	EventName              string                 `json:"event_name,omitempty"`
+	Body                   interface{}            `json:"body,omitempty"`
+	Attributes             map[string]interface{} `json:"attributes,omitempty"`
+	DroppedAttributesCount uint32                 `json:"dropped_attributes_count,omitempty"`
+	Flags                  uint32                 `json:"flags,omitempty"`
+	TraceID                string                 `json:"trace_id,omitempty"`
+	SpanID                 string                 `json:"span_id,omitempty"`
+	ResourceAttributes     map[string]interface{} `json:"resource_attributes,omitempty"`
+	Scope                  Scope                  `json:"scope"`
 }
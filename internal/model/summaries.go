@@ -1,7 +1,7 @@
 package model
 
 import (
-	"fmt"
+	"strings"
 
 	"go.opentelemetry.io/collector/pdata/pcommon"
 	"go.opentelemetry.io/collector/pdata/plog"
@@ -9,29 +9,8 @@ import (
 	"go.opentelemetry.io/collector/pdata/ptrace"
 )
 
-// BuildMetricsPayload creates a detailed metrics projection for streaming clients.
-func BuildMetricsPayload(md pmetric.Metrics) MetricsPayload {
-	metricsOut := make([]Metric, 0, md.MetricCount())
-
-	rms := md.ResourceMetrics()
-	for i := 0; i < rms.Len(); i++ {
-		sms := rms.At(i).ScopeMetrics()
-		for j := 0; j < sms.Len(); j++ {
-			scope := sms.At(j).Scope()
-
-			metrics := sms.At(j).Metrics()
-			for k := 0; k < metrics.Len(); k++ {
-				metricsOut = append(metricsOut, BuildMetric(rms.At(i).Resource().Attributes(), scope, metrics.At(k), false))
-			}
-		}
-	}
-
-	return MetricsPayload{
-		ResourceMetrics: rms.Len(),
-		MetricCount:     md.MetricCount(),
-		Metrics:         metricsOut,
-	}
-}
+// DefaultMaxLogRecords caps how many log records one LogsPayload projects unless a session overrides it.
+const DefaultMaxLogRecords = 10
 
 // BuildMetric creates a detailed projection for one metric candidate with its resource/scope context.
 // If verboseMetrics is true, histogram datapoints include bucket_counts and explicit_bounds.
@@ -60,14 +39,14 @@ func buildMetricDataPoints(metric pmetric.Metric, verboseMetrics bool) []MetricD
 		points = make([]MetricDataPoint, 0, dps.Len())
 		for i := 0; i < dps.Len(); i++ {
 			dp := dps.At(i)
-			points = append(points, numberDataPointToModel(dp))
+			points = append(points, numberDataPointToModel(dp, verboseMetrics))
 		}
 	case pmetric.MetricTypeSum:
 		dps := metric.Sum().DataPoints()
 		points = make([]MetricDataPoint, 0, dps.Len())
 		for i := 0; i < dps.Len(); i++ {
 			dp := dps.At(i)
-			points = append(points, numberDataPointToModel(dp))
+			points = append(points, numberDataPointToModel(dp, verboseMetrics))
 		}
 	case pmetric.MetricTypeHistogram:
 		dps := metric.Histogram().DataPoints()
@@ -82,9 +61,16 @@ func buildMetricDataPoints(metric pmetric.Metric, verboseMetrics bool) []MetricD
 				Sum:               dp.Sum(),
 				Flags:             uint32(dp.Flags()),
 			}
+			if dp.HasMin() {
+				entry.Min = float64Ptr(dp.Min())
+			}
+			if dp.HasMax() {
+				entry.Max = float64Ptr(dp.Max())
+			}
 			if verboseMetrics {
 				entry.BucketCounts = uint64Slice(dp.BucketCounts())
 				entry.ExplicitBounds = float64Slice(dp.ExplicitBounds())
+				entry.Exemplars = exemplarsToModel(dp.Exemplars())
 			}
 			points = append(points, entry)
 		}
@@ -114,21 +100,37 @@ func buildMetricDataPoints(metric pmetric.Metric, verboseMetrics bool) []MetricD
 		points = make([]MetricDataPoint, 0, dps.Len())
 		for i := 0; i < dps.Len(); i++ {
 			dp := dps.At(i)
-			points = append(points, MetricDataPoint{
+			scale := dp.Scale()
+			entry := MetricDataPoint{
 				StartTimeUnixNano: uint64(dp.StartTimestamp()),
 				TimeUnixNano:      uint64(dp.Timestamp()),
 				Attributes:        mapFromAttrs(dp.Attributes()),
 				Count:             dp.Count(),
 				Sum:               dp.Sum(),
+				Scale:             &scale,
+				ZeroCount:         dp.ZeroCount(),
+				ZeroThreshold:     dp.ZeroThreshold(),
 				Flags:             uint32(dp.Flags()),
-			})
+			}
+			if dp.HasMin() {
+				entry.Min = float64Ptr(dp.Min())
+			}
+			if dp.HasMax() {
+				entry.Max = float64Ptr(dp.Max())
+			}
+			if verboseMetrics {
+				entry.Positive = exponentialBucketsToModel(dp.Positive())
+				entry.Negative = exponentialBucketsToModel(dp.Negative())
+				entry.Exemplars = exemplarsToModel(dp.Exemplars())
+			}
+			points = append(points, entry)
 		}
 	}
 
 	return points
 }
 
-func numberDataPointToModel(dp pmetric.NumberDataPoint) MetricDataPoint {
+func numberDataPointToModel(dp pmetric.NumberDataPoint, verboseMetrics bool) MetricDataPoint {
 	out := MetricDataPoint{
 		StartTimeUnixNano: uint64(dp.StartTimestamp()),
 		TimeUnixNano:      uint64(dp.Timestamp()),
@@ -142,10 +144,53 @@ func numberDataPointToModel(dp pmetric.NumberDataPoint) MetricDataPoint {
 	case pmetric.NumberDataPointValueTypeDouble:
 		out.Value = dp.DoubleValue()
 	}
+	if verboseMetrics {
+		out.Exemplars = exemplarsToModel(dp.Exemplars())
+	}
+
+	return out
+}
 
+func exemplarsToModel(exemplars pmetric.ExemplarSlice) []Exemplar {
+	if exemplars.Len() == 0 {
+		return nil
+	}
+	out := make([]Exemplar, 0, exemplars.Len())
+	for i := 0; i < exemplars.Len(); i++ {
+		ex := exemplars.At(i)
+		entry := Exemplar{
+			TimeUnixNano:       uint64(ex.Timestamp()),
+			FilteredAttributes: mapFromAttrs(ex.FilteredAttributes()),
+		}
+		switch ex.ValueType() {
+		case pmetric.ExemplarValueTypeInt:
+			entry.Value = ex.IntValue()
+		case pmetric.ExemplarValueTypeDouble:
+			entry.Value = ex.DoubleValue()
+		}
+		if !ex.TraceID().IsEmpty() {
+			entry.TraceID = ex.TraceID().String()
+		}
+		if !ex.SpanID().IsEmpty() {
+			entry.SpanID = ex.SpanID().String()
+		}
+		out = append(out, entry)
+	}
 	return out
 }
 
+func exponentialBucketsToModel(buckets pmetric.ExponentialHistogramDataPointBuckets) *ExponentialBuckets {
+	if buckets.BucketCounts().Len() == 0 {
+		return nil
+	}
+	return &ExponentialBuckets{
+		Offset:       buckets.Offset(),
+		BucketCounts: uint64Slice(buckets.BucketCounts()),
+	}
+}
+
+func float64Ptr(v float64) *float64 { return &v }
+
 func uint64Slice(src pcommon.UInt64Slice) []uint64 {
 	if src.Len() == 0 {
 		return nil
@@ -204,59 +249,122 @@ func valueToAny(value pcommon.Value) interface{} {
 	}
 }
 
-// BuildTracesPayload creates a lightweight summary for traces batches.
-func BuildTracesPayload(td ptrace.Traces) TracesPayload {
-	names := make([]string, 0)
-	seen := make(map[string]struct{})
+// BuildSpan creates a detailed projection for one span candidate with its resource/scope context.
+func BuildSpan(resourceAttrs pcommon.Map, scope pcommon.InstrumentationScope, span ptrace.Span) Span {
+	out := Span{
+		TraceID:                span.TraceID().String(),
+		SpanID:                 span.SpanID().String(),
+		TraceState:             span.TraceState().AsRaw(),
+		Name:                   span.Name(),
+		Kind:                   strings.ToLower(span.Kind().String()),
+		StartTimeUnixNano:      uint64(span.StartTimestamp()),
+		EndTimeUnixNano:        uint64(span.EndTimestamp()),
+		Status:                 SpanStatus{Code: strings.ToLower(span.Status().Code().String()), Message: span.Status().Message()},
+		Attributes:             mapFromAttrs(span.Attributes()),
+		DroppedAttributesCount: span.DroppedAttributesCount(),
+		DroppedEventsCount:     span.DroppedEventsCount(),
+		DroppedLinksCount:      span.DroppedLinksCount(),
+		Flags:                  span.Flags(),
+		ResourceAttributes:     mapFromAttrs(resourceAttrs),
+		Scope: Scope{
+			Name:       scope.Name(),
+			Version:    scope.Version(),
+			Attributes: mapFromAttrs(scope.Attributes()),
+		},
+	}
+	if !span.ParentSpanID().IsEmpty() {
+		out.ParentSpanID = span.ParentSpanID().String()
+	}
+	if span.EndTimestamp() >= span.StartTimestamp() {
+		out.DurationNano = uint64(span.EndTimestamp() - span.StartTimestamp())
+	}
 
-	rss := td.ResourceSpans()
-	for i := 0; i < rss.Len(); i++ {
-		ilss := rss.At(i).ScopeSpans()
-		for j := 0; j < ilss.Len(); j++ {
-			spans := ilss.At(j).Spans()
-			for k := 0; k < spans.Len(); k++ {
-				name := spans.At(k).Name()
-				if _, ok := seen[name]; ok {
-					continue
-				}
-				seen[name] = struct{}{}
-				names = append(names, name)
-			}
+	events := span.Events()
+	if events.Len() > 0 {
+		out.Events = make([]SpanEvent, 0, events.Len())
+		for i := 0; i < events.Len(); i++ {
+			event := events.At(i)
+			out.Events = append(out.Events, SpanEvent{
+				Name:                   event.Name(),
+				TimeUnixNano:           uint64(event.Timestamp()),
+				Attributes:             mapFromAttrs(event.Attributes()),
+				DroppedAttributesCount: event.DroppedAttributesCount(),
+			})
 		}
 	}
 
-	return TracesPayload{
-		ResourceSpans: rss.Len(),
-		SpanCount:     td.SpanCount(),
-		SpanNames:     names,
+	links := span.Links()
+	if links.Len() > 0 {
+		out.Links = make([]SpanLink, 0, links.Len())
+		for i := 0; i < links.Len(); i++ {
+			link := links.At(i)
+			out.Links = append(out.Links, SpanLink{
+				TraceID:                link.TraceID().String(),
+				SpanID:                 link.SpanID().String(),
+				TraceState:             link.TraceState().AsRaw(),
+				Attributes:             mapFromAttrs(link.Attributes()),
+				DroppedAttributesCount: link.DroppedAttributesCount(),
+				Flags:                  link.Flags(),
+			})
+		}
 	}
+
+	return out
 }
 
-// BuildLogsPayload creates a lightweight summary for logs batches.
-func BuildLogsPayload(ld plog.Logs) LogsPayload {
-	bodies := make([]string, 0)
+// AddSpanName appends name to SpanNames unless seen already holds it.
+func (p *TracesPayload) AddSpanName(name string, seen map[string]struct{}) {
+	if _, ok := seen[name]; ok {
+		return
+	}
+	seen[name] = struct{}{}
+	p.SpanNames = append(p.SpanNames, name)
+}
 
-	rls := ld.ResourceLogs()
-	for i := 0; i < rls.Len(); i++ {
-		sls := rls.At(i).ScopeLogs()
-		for j := 0; j < sls.Len(); j++ {
-			logs := sls.At(j).LogRecords()
-			for k := 0; k < logs.Len(); k++ {
-				if len(bodies) >= 10 {
-					break
-				}
-				body := logs.At(k).Body()
-				if body.Type() == 0 {
-					continue
-				}
-				bodies = append(bodies, fmt.Sprintf("%v", body.AsString()))
-			}
-		}
+// AddRecord counts one log record and adds its body while the payload holds fewer than limit records;
+// with verbose it also adds the record's full projection. Records past the limit only bump LogCount
+// and mark the payload as truncated.
+func (p *LogsPayload) AddRecord(resourceAttrs pcommon.Map, scope pcommon.InstrumentationScope, record plog.LogRecord, limit int, verbose bool) {
+	p.LogCount++
+	if p.LogCount > limit {
+		p.Truncated = true
+		return
 	}
+	if record.Body().Type() != pcommon.ValueTypeEmpty {
+		p.Bodies = append(p.Bodies, record.Body().AsString())
+	}
+	if verbose {
+		p.Records = append(p.Records, BuildLogRecord(resourceAttrs, scope, record))
+	}
+}
 
-	return LogsPayload{
-		ResourceLogs: rls.Len(),
-		LogCount:     ld.LogRecordCount(),
-		Bodies:       bodies,
+// BuildLogRecord creates a detailed projection for one log record with its resource/scope context.
+// Map and slice bodies are kept as JSON structures.
+func BuildLogRecord(resourceAttrs pcommon.Map, scope pcommon.InstrumentationScope, record plog.LogRecord) LogRecord {
+	out := LogRecord{
+		TimeUnixNano:           uint64(record.Timestamp()),
+		ObservedTimeUnixNano:   uint64(record.ObservedTimestamp()),
+		SeverityNumber:         int32(record.SeverityNumber()),
+		SeverityText:           record.SeverityText(),
+		EventName:              record.EventName(),
+		Attributes:             mapFromAttrs(record.Attributes()),
+		DroppedAttributesCount: record.DroppedAttributesCount(),
+		Flags:                  uint32(record.Flags()),
+		ResourceAttributes:     mapFromAttrs(resourceAttrs),
+		Scope: Scope{
+			Name:       scope.Name(),
+			Version:    scope.Version(),
+			Attributes: mapFromAttrs(scope.Attributes()),
+		},
 	}
+	if record.Body().Type() != pcommon.ValueTypeEmpty {
+		out.Body = valueToAny(record.Body())
+	}
+	if !record.TraceID().IsEmpty() {
+		out.TraceID = record.TraceID().String()
+	}
+	if !record.SpanID().IsEmpty() {
+		out.SpanID = record.SpanID().String()
+	}
+	return out
 }
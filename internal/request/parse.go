@@ -0,0 +1,442 @@
+package request
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/utrack/otellens/internal/capture"
+	"github.com/utrack/otellens/internal/model"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// Parse validates a session definition and compiles its filter.
+func Parse(req StreamRequest) (capture.Filter, error) {
+	if err := Validate(req); err != nil {
+		return capture.Filter{}, err
+	}
+	return compileFilter(req)
+}
+
+// RegisterRequest compiles a session definition accepted by Validate into a registry request.
+// The APIs set the negotiated format on req beforehand.
+func RegisterRequest(req StreamRequest, remoteAddr string) (capture.RegisterRequest, error) {
+	filter, err := compileFilter(req)
+	if err != nil {
+		return capture.RegisterRequest{}, err
+	}
+	trigger, err := parseTrigger(req)
+	if err != nil {
+		return capture.RegisterRequest{}, err
+	}
+	return capture.RegisterRequest{
+		Filter:         filter,
+		Trigger:        trigger,
+		VerboseMetrics: req.VerboseMetrics,
+		VerboseTraces:  req.VerboseTraces,
+		VerboseLogs:    req.VerboseLogs,
+		Format:         req.Format,
+		MaxLogRecords:  req.MaxLogRecords,
+		SampleRate:     req.SampleRate,
+		SampleEveryN:   req.SampleEveryN,
+		MaxBatches:     req.MaxBatches,
+		MaxRecords:     req.MaxRecords,
+		MaxBytes:       req.MaxBytes,
+		BufferSize:     req.MaxBatches,
+		IncludeHistory: time.Duration(req.IncludeHistorySeconds) * time.Second,
+		RateLimits: capture.RateLimits{
+			BatchesPerSecond: req.MaxBatchesPerSecond,
+			RecordsPerSecond: req.MaxRecordsPerSecond,
+			BytesPerSecond:   req.MaxBytesPerSecond,
+		},
+		Info: capture.SessionInfo{
+			Label:      req.Label,
+			RemoteAddr: remoteAddr,
+			Request:    req,
+		},
+	}, nil
+}
+
+// parseTrigger compiles the trigger of a session definition; it returns nil for sessions that are not armed.
+func parseTrigger(req StreamRequest) (*capture.Trigger, error) {
+	if req.Trigger == nil {
+		return nil, nil
+	}
+	filter, err := compileFilter(req.Trigger.StreamRequest)
+	if err != nil {
+		return nil, fmt.Errorf("trigger: %w", err)
+	}
+	return &capture.Trigger{
+		Filter:     filter,
+		CaptureFor: time.Duration(req.Trigger.CaptureSeconds) * time.Second,
+		Request:    *req.Trigger,
+	}, nil
+}
+
+// ErrFlightRecorderDisabled rejects include_history_seconds when the exporter keeps no history.
+// Validate cannot tell, so the APIs check it against their registry.
+var ErrFlightRecorderDisabled = errors.New("include_history_seconds requires the flight_recorder exporter setting")
+
+// Validate checks the fields of a session definition that do not need compiling.
+func Validate(req StreamRequest) error {
+	if req.MaxBatches <= 0 {
+		return errors.New("max_batches must be > 0")
+	}
+	if req.MaxRecords < 0 {
+		return errors.New("max_records must be >= 0")
+	}
+	if req.MaxBytes < 0 {
+		return errors.New("max_bytes must be >= 0")
+	}
+	if req.TimeoutSeconds < 0 {
+		return errors.New("timeout_seconds must be >= 0")
+	}
+	if req.BucketCountsCount != nil && *req.BucketCountsCount < 0 {
+		return errors.New("bucket_counts_count must be >= 0")
+	}
+	if req.ExplicitBoundsCount != nil && *req.ExplicitBoundsCount < 0 {
+		return errors.New("explicit_bounds_count must be >= 0")
+	}
+	if req.ExponentialScale != nil && (*req.ExponentialScale < -10 || *req.ExponentialScale > 20) {
+		return errors.New("exponential_scale must be between -10 and 20")
+	}
+	if req.PositiveBucketCountsCount != nil && *req.PositiveBucketCountsCount < 0 {
+		return errors.New("positive_bucket_counts_count must be >= 0")
+	}
+	if req.NegativeBucketCountsCount != nil && *req.NegativeBucketCountsCount < 0 {
+		return errors.New("negative_bucket_counts_count must be >= 0")
+	}
+	switch req.Format {
+	case "", model.FormatOtellens, model.FormatOTLPJSON:
+	default:
+		return fmt.Errorf("format must be %q or %q", model.FormatOtellens, model.FormatOTLPJSON)
+	}
+	if req.DetachGraceSeconds < 0 {
+		return errors.New("detach_grace_seconds must be >= 0")
+	}
+	if req.IncludeHistorySeconds < 0 {
+		return errors.New("include_history_seconds must be >= 0")
+	}
+	if req.Trigger != nil {
+		if err := validateTrigger(*req.Trigger); err != nil {
+			return err
+		}
+		if req.IncludeHistorySeconds > 0 {
+			return errors.New("include_history_seconds cannot be combined with trigger")
+		}
+	}
+	if req.MaxLogRecords < 0 {
+		return errors.New("max_log_records must be >= 0")
+	}
+	if req.SampleRate < 0 || req.SampleRate > 1 {
+		return errors.New("sample_rate must be between 0 (unset) and 1")
+	}
+	if req.SampleEveryN < 0 {
+		return errors.New("sample_every_n must be >= 0")
+	}
+	if req.MaxBatchesPerSecond < 0 {
+		return errors.New("max_batches_per_second must be >= 0")
+	}
+	if req.MaxRecordsPerSecond < 0 {
+		return errors.New("max_records_per_second must be >= 0")
+	}
+	if req.MaxBytesPerSecond < 0 {
+		return errors.New("max_bytes_per_second must be >= 0")
+	}
+	if req.MinSpanDurationMs < 0 {
+		return errors.New("min_span_duration_ms must be >= 0")
+	}
+	if req.MaxSpanDurationMs < 0 {
+		return errors.New("max_span_duration_ms must be >= 0")
+	}
+	if req.MaxSpanDurationMs > 0 && req.MinSpanDurationMs > req.MaxSpanDurationMs {
+		return errors.New("min_span_duration_ms must be <= max_span_duration_ms")
+	}
+	return nil
+}
+
+// validateTrigger checks the filter fields of a trigger; the limits of the session apply to it.
+func validateTrigger(trigger TriggerRequest) error {
+	if trigger.CaptureSeconds < 0 {
+		return errors.New("trigger.capture_seconds must be >= 0")
+	}
+	if trigger.Trigger != nil {
+		return errors.New("trigger cannot have a trigger")
+	}
+	// Criteria of one signal, such as min_severity_number, match every batch of the other signals.
+	if len(trigger.Signals) == 0 {
+		return errors.New("trigger.signals is required")
+	}
+	check := trigger.StreamRequest
+	check.MaxBatches = 1
+	if err := Validate(check); err != nil {
+		return fmt.Errorf("trigger: %w", err)
+	}
+	return nil
+}
+
+// compileFilter compiles the filter fields of a session definition.
+func compileFilter(req StreamRequest) (capture.Filter, error) {
+	signals := make(map[model.SignalType]struct{}, len(req.Signals))
+	for _, signal := range req.Signals {
+		signals[signal] = struct{}{}
+	}
+
+	metricNames, err := parseNameFilterValues(req.MetricNames)
+	if err != nil {
+		return capture.Filter{}, fmt.Errorf("metric_names: %w", err)
+	}
+	spanNames, err := parseNameFilterValues(req.SpanNames)
+	if err != nil {
+		return capture.Filter{}, fmt.Errorf("span_names: %w", err)
+	}
+	attributeNames, err := parseNameFilterValues(req.AttributeNames)
+	if err != nil {
+		return capture.Filter{}, fmt.Errorf("attribute_names: %w", err)
+	}
+	predicates, err := parseAttributeFilters(req.AttributeFilters)
+	if err != nil {
+		return capture.Filter{}, err
+	}
+	spanKinds, err := parseSpanKinds(req.SpanKinds)
+	if err != nil {
+		return capture.Filter{}, err
+	}
+	spanStatusCodes, err := parseSpanStatusCodes(req.SpanStatusCodes)
+	if err != nil {
+		return capture.Filter{}, err
+	}
+	traceIDs, err := parseTraceIDs("trace_ids", req.TraceIDs)
+	if err != nil {
+		return capture.Filter{}, err
+	}
+	exemplarTraceIDs, err := parseTraceIDs("exemplar_trace_ids", req.ExemplarTraceIDs)
+	if err != nil {
+		return capture.Filter{}, err
+	}
+	spanIDs, err := parseSpanIDs(req.SpanIDs)
+	if err != nil {
+		return capture.Filter{}, err
+	}
+	var where *capture.Expr
+	if strings.TrimSpace(req.Where) != "" {
+		where, err = capture.ParseExpr(req.Where)
+		if err != nil {
+			return capture.Filter{}, fmt.Errorf("where: %w", err)
+		}
+	}
+
+	return capture.Filter{
+		Signals:                   signals,
+		MetricNames:               metricNames.include,
+		MetricNamesExclude:        metricNames.exclude,
+		MetricNamePatterns:        metricNames.includePatterns,
+		MetricNameExcludePatterns: metricNames.excludePatterns,
+		SpanNames:                 spanNames.include,
+		SpanNamesExclude:          spanNames.exclude,
+		SpanNamePatterns:          spanNames.includePatterns,
+		SpanNameExcludePatterns:   spanNames.excludePatterns,
+		AttributeNames:            attributeNames.include,
+		AttributeExclude:          attributeNames.exclude,
+		AttributePatterns:         attributeNames.includePatterns,
+		AttributeExcludePatterns:  attributeNames.excludePatterns,
+		BucketCountsCount:         req.BucketCountsCount,
+		ExplicitBoundsCount:       req.ExplicitBoundsCount,
+		ExponentialScale:          req.ExponentialScale,
+		PositiveBucketCountsCount: req.PositiveBucketCountsCount,
+		NegativeBucketCountsCount: req.NegativeBucketCountsCount,
+		LogBodyContains:           req.LogBodyContains,
+		MinSeverityNumber:         plog.SeverityNumber(req.MinSeverityNumber),
+		ResourceAttributes:        req.ResourceAttributes,
+		AttributePredicates:       predicates,
+		SpanKinds:                 spanKinds,
+		SpanStatusCodes:           spanStatusCodes,
+		SpanStatusMessageContains: req.SpanStatusMessageContains,
+		MinSpanDuration:           msToDuration(req.MinSpanDurationMs),
+		MaxSpanDuration:           msToDuration(req.MaxSpanDurationMs),
+		RootSpansOnly:             req.RootSpansOnly,
+		TraceIDs:                  traceIDs,
+		SpanIDs:                   spanIDs,
+		HasExemplars:              req.HasExemplars,
+		ExemplarTraceIDs:          exemplarTraceIDs,
+		Where:                     where,
+	}, nil
+}
+
+var spanKindNames = map[string]ptrace.SpanKind{
+	"unspecified": ptrace.SpanKindUnspecified,
+	"internal":    ptrace.SpanKindInternal,
+	"server":      ptrace.SpanKindServer,
+	"client":      ptrace.SpanKindClient,
+	"producer":    ptrace.SpanKindProducer,
+	"consumer":    ptrace.SpanKindConsumer,
+}
+
+// parseSpanKinds accepts kind names case-insensitively, with or without the OTLP `SPAN_KIND_` prefix.
+func parseSpanKinds(values []string) (map[ptrace.SpanKind]struct{}, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	out := make(map[ptrace.SpanKind]struct{}, len(values))
+	for i, raw := range values {
+		name := strings.TrimPrefix(strings.ToLower(strings.TrimSpace(raw)), "span_kind_")
+		kind, ok := spanKindNames[name]
+		if !ok {
+			return nil, fmt.Errorf("span_kinds[%d]: unknown span kind %q", i, raw)
+		}
+		out[kind] = struct{}{}
+	}
+	return out, nil
+}
+
+var spanStatusCodeNames = map[string]ptrace.StatusCode{
+	"unset": ptrace.StatusCodeUnset,
+	"ok":    ptrace.StatusCodeOk,
+	"error": ptrace.StatusCodeError,
+}
+
+// parseSpanStatusCodes accepts status names case-insensitively, with or without the OTLP `STATUS_CODE_` prefix.
+func parseSpanStatusCodes(values []string) (map[ptrace.StatusCode]struct{}, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	out := make(map[ptrace.StatusCode]struct{}, len(values))
+	for i, raw := range values {
+		name := strings.TrimPrefix(strings.ToLower(strings.TrimSpace(raw)), "status_code_")
+		code, ok := spanStatusCodeNames[name]
+		if !ok {
+			return nil, fmt.Errorf("span_status_codes[%d]: unknown status code %q", i, raw)
+		}
+		out[code] = struct{}{}
+	}
+	return out, nil
+}
+
+func msToDuration(ms float64) time.Duration {
+	return time.Duration(ms * float64(time.Millisecond))
+}
+
+func parseTraceIDs(field string, values []string) (map[pcommon.TraceID]struct{}, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	out := make(map[pcommon.TraceID]struct{}, len(values))
+	for i, raw := range values {
+		var id pcommon.TraceID
+		if err := decodeHexID(strings.TrimSpace(raw), id[:]); err != nil {
+			return nil, fmt.Errorf("%s[%d]: %w", field, i, err)
+		}
+		out[id] = struct{}{}
+	}
+	return out, nil
+}
+
+func parseSpanIDs(values []string) (map[pcommon.SpanID]struct{}, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	out := make(map[pcommon.SpanID]struct{}, len(values))
+	for i, raw := range values {
+		var id pcommon.SpanID
+		if err := decodeHexID(strings.TrimSpace(raw), id[:]); err != nil {
+			return nil, fmt.Errorf("span_ids[%d]: %w", i, err)
+		}
+		out[id] = struct{}{}
+	}
+	return out, nil
+}
+
+// decodeHexID decodes a hex-encoded trace or span ID into dst, which fixes the expected length.
+func decodeHexID(value string, dst []byte) error {
+	if len(value) != hex.EncodedLen(len(dst)) {
+		return fmt.Errorf("expected %d hex characters, got %d", hex.EncodedLen(len(dst)), len(value))
+	}
+	if _, err := hex.Decode(dst, []byte(value)); err != nil {
+		return fmt.Errorf("invalid hex: %w", err)
+	}
+	return nil
+}
+
+func parseAttributeFilters(filters []AttributeFilter) ([]capture.AttributePredicate, error) {
+	if len(filters) == 0 {
+		return nil, nil
+	}
+	out := make([]capture.AttributePredicate, 0, len(filters))
+	for i, item := range filters {
+		op := capture.AttributeOperator(strings.ToLower(strings.TrimSpace(item.Op)))
+		if op == "" {
+			op = capture.AttributeOpEq
+		}
+		predicate, err := capture.NewAttributePredicate(
+			strings.TrimSpace(item.Key),
+			capture.AttributeLevel(strings.ToLower(strings.TrimSpace(item.Level))),
+			op,
+			item.Value,
+			item.Values,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("attribute_filters[%d]: %w", i, err)
+		}
+		out = append(out, predicate)
+	}
+	return out, nil
+}
+
+// nameFilterValues holds parsed include/exclude rules for one name filter field.
+type nameFilterValues struct {
+	include         map[string]struct{}
+	exclude         map[string]struct{}
+	includePatterns []*regexp.Regexp
+	excludePatterns []*regexp.Regexp
+}
+
+// parseNameFilterValues splits raw values into exact and pattern rules.
+// A `!` prefix negates a value; `~` marks a regular expression and `glob:` a glob.
+func parseNameFilterValues(values []string) (nameFilterValues, error) {
+	out := nameFilterValues{
+		include: make(map[string]struct{}, len(values)),
+		exclude: make(map[string]struct{}, len(values)),
+	}
+
+	for _, raw := range values {
+		value := strings.TrimSpace(raw)
+		if value == "" {
+			continue
+		}
+
+		negated := false
+		if strings.HasPrefix(value, "!") {
+			negated = true
+			value = strings.TrimSpace(strings.TrimPrefix(value, "!"))
+			if value == "" {
+				continue
+			}
+		}
+
+		if !capture.IsPattern(value) {
+			if negated {
+				out.exclude[value] = struct{}{}
+			} else {
+				out.include[value] = struct{}{}
+			}
+			continue
+		}
+
+		pattern, err := capture.CompilePattern(value)
+		if err != nil {
+			return nameFilterValues{}, err
+		}
+		if negated {
+			out.excludePatterns = append(out.excludePatterns, pattern)
+		} else {
+			out.includePatterns = append(out.includePatterns, pattern)
+		}
+	}
+
+	return out, nil
+}
@@ -0,0 +1,235 @@
+package request
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/utrack/otellens/internal/capture"
+	"github.com/utrack/otellens/internal/model"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+func TestCompileFilterSupportsNotValues(t *testing.T) {
+	bucketCountsCount := 28
+	explicitBoundsCount := 29
+
+	f, err := compileFilter(StreamRequest{
+		Signals:             []model.SignalType{"metrics", "traces"},
+		MetricNames:         []string{"http.server.request.duration", "!foo", " !bar "},
+		SpanNames:           []string{"GET /", "!POST /"},
+		AttributeNames:      []string{"client_name", "!blocked"},
+		BucketCountsCount:   &bucketCountsCount,
+		ExplicitBoundsCount: &explicitBoundsCount,
+		MaxBatches:          1,
+	})
+	if err != nil {
+		t.Fatalf("compileFilter failed: %v", err)
+	}
+
+	if _, ok := f.MetricNames["http.server.request.duration"]; !ok {
+		t.Fatal("expected metric include value")
+	}
+	if _, ok := f.MetricNamesExclude["foo"]; !ok {
+		t.Fatal("expected metric exclude value foo")
+	}
+	if _, ok := f.MetricNamesExclude["bar"]; !ok {
+		t.Fatal("expected metric exclude value bar")
+	}
+
+	if _, ok := f.SpanNames["GET /"]; !ok {
+		t.Fatal("expected span include value")
+	}
+	if _, ok := f.SpanNamesExclude["POST /"]; !ok {
+		t.Fatal("expected span exclude value")
+	}
+
+	if _, ok := f.AttributeNames["client_name"]; !ok {
+		t.Fatal("expected attribute include value")
+	}
+	if _, ok := f.AttributeExclude["blocked"]; !ok {
+		t.Fatal("expected attribute exclude value")
+	}
+	if f.BucketCountsCount == nil || *f.BucketCountsCount != bucketCountsCount {
+		t.Fatalf("expected bucket_counts_count=%d", bucketCountsCount)
+	}
+	if f.ExplicitBoundsCount == nil || *f.ExplicitBoundsCount != explicitBoundsCount {
+		t.Fatalf("expected explicit_bounds_count=%d", explicitBoundsCount)
+	}
+}
+
+func TestCompileFilterCompilesNamePatterns(t *testing.T) {
+	f, err := compileFilter(StreamRequest{
+		MetricNames:    []string{"glob:http.server.*", "!~^kafka\\..*lag$", "exact", "db.*"},
+		AttributeNames: []string{"!glob:http.request.header.*"},
+		MaxBatches:     1,
+	})
+	if err != nil {
+		t.Fatalf("compileFilter failed: %v", err)
+	}
+
+	if _, ok := f.MetricNames["exact"]; !ok {
+		t.Fatal("expected exact metric include value")
+	}
+	if _, ok := f.MetricNames["db.*"]; !ok || len(f.MetricNames) != 2 {
+		t.Fatalf("expected patterns to stay out of the exact set and unprefixed globs in it, got %v", f.MetricNames)
+	}
+	if len(f.MetricNamePatterns) != 1 || !f.MetricNamePatterns[0].MatchString("http.server.request.duration") {
+		t.Fatalf("expected metric glob include pattern, got %v", f.MetricNamePatterns)
+	}
+	if len(f.MetricNameExcludePatterns) != 1 || !f.MetricNameExcludePatterns[0].MatchString("kafka.consumer.lag") {
+		t.Fatalf("expected metric regex exclude pattern, got %v", f.MetricNameExcludePatterns)
+	}
+	if len(f.AttributeExcludePatterns) != 1 {
+		t.Fatalf("expected attribute exclude pattern, got %v", f.AttributeExcludePatterns)
+	}
+}
+
+func TestCompileFilterParsesAttributeFilters(t *testing.T) {
+	var req StreamRequest
+	body := `{"max_batches":1,"attribute_filters":[
+		{"key":"http.response.status_code","level":"span","op":"gte","value":500},
+		{"key":"http.route","level":"span","value":"/v1/orders"}
+	]}`
+	if err := json.Unmarshal([]byte(body), &req); err != nil {
+		t.Fatalf("decode request: %v", err)
+	}
+
+	f, err := compileFilter(req)
+	if err != nil {
+		t.Fatalf("compileFilter failed: %v", err)
+	}
+	if len(f.AttributePredicates) != 2 {
+		t.Fatalf("expected 2 predicates, got %d", len(f.AttributePredicates))
+	}
+	if f.AttributePredicates[1].Op != capture.AttributeOpEq {
+		t.Fatalf("expected default op eq, got %q", f.AttributePredicates[1].Op)
+	}
+
+	_, err = compileFilter(StreamRequest{
+		MaxBatches:       1,
+		AttributeFilters: []AttributeFilter{{Key: "k", Op: "gt", Value: "abc"}},
+	})
+	if err == nil || !strings.Contains(err.Error(), "attribute_filters[0]") {
+		t.Fatalf("expected indexed attribute_filters error, got %v", err)
+	}
+}
+
+func TestCompileFilterParsesTraceContextIDs(t *testing.T) {
+	f, err := compileFilter(StreamRequest{
+		TraceIDs:   []string{"0102030405060708090A0B0C0D0E0F10"},
+		SpanIDs:    []string{"0102030405060708"},
+		MaxBatches: 1,
+	})
+	if err != nil {
+		t.Fatalf("compileFilter failed: %v", err)
+	}
+	if len(f.TraceIDs) != 1 || len(f.SpanIDs) != 1 {
+		t.Fatalf("expected one trace and one span id, got %v %v", f.TraceIDs, f.SpanIDs)
+	}
+
+	if _, err := compileFilter(StreamRequest{TraceIDs: []string{"abc"}, MaxBatches: 1}); err == nil {
+		t.Fatal("expected error for short trace id")
+	}
+	if _, err := compileFilter(StreamRequest{SpanIDs: []string{"zz02030405060708"}, MaxBatches: 1}); err == nil {
+		t.Fatal("expected error for non-hex span id")
+	}
+}
+
+func TestCompileFilterParsesSpanShapeFilters(t *testing.T) {
+	f, err := compileFilter(StreamRequest{
+		SpanKinds:         []string{"server", "SPAN_KIND_CLIENT"},
+		SpanStatusCodes:   []string{"Error"},
+		MinSpanDurationMs: 250,
+		MaxBatches:        1,
+	})
+	if err != nil {
+		t.Fatalf("compileFilter failed: %v", err)
+	}
+	if _, ok := f.SpanKinds[ptrace.SpanKindClient]; !ok || len(f.SpanKinds) != 2 {
+		t.Fatalf("expected server and client kinds, got %v", f.SpanKinds)
+	}
+	if _, ok := f.SpanStatusCodes[ptrace.StatusCodeError]; !ok {
+		t.Fatalf("expected error status code, got %v", f.SpanStatusCodes)
+	}
+	if f.MinSpanDuration != 250*time.Millisecond {
+		t.Fatalf("expected 250ms min duration, got %s", f.MinSpanDuration)
+	}
+
+	if _, err := compileFilter(StreamRequest{SpanKinds: []string{"gateway"}, MaxBatches: 1}); err == nil {
+		t.Fatal("expected error for unknown span kind")
+	}
+	if err := Validate(StreamRequest{MaxBatches: 1, MinSpanDurationMs: 10, MaxSpanDurationMs: 5}); err == nil {
+		t.Fatal("expected validation error for min > max duration")
+	}
+}
+
+func TestValidateRejectsNegativeHistogramCountFilters(t *testing.T) {
+	negOne := -1
+	if err := Validate(StreamRequest{MaxBatches: 1, BucketCountsCount: &negOne}); err == nil {
+		t.Fatal("expected validation error for negative bucket_counts_count")
+	}
+	if err := Validate(StreamRequest{MaxBatches: 1, ExplicitBoundsCount: &negOne}); err == nil {
+		t.Fatal("expected validation error for negative explicit_bounds_count")
+	}
+}
+
+func TestValidateFormat(t *testing.T) {
+	for _, format := range []model.OutputFormat{"", model.FormatOtellens, model.FormatOTLPJSON} {
+		if err := Validate(StreamRequest{MaxBatches: 1, Format: format}); err != nil {
+			t.Fatalf("format %q: unexpected error %v", format, err)
+		}
+	}
+	if err := Validate(StreamRequest{MaxBatches: 1, Format: "otlp_proto"}); err == nil {
+		t.Fatal("expected validation error for unknown format")
+	}
+}
+
+func TestValidateSampling(t *testing.T) {
+	if err := Validate(StreamRequest{MaxBatches: 1, SampleRate: 0.25, SampleEveryN: 10}); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	for _, req := range []StreamRequest{
+		{MaxBatches: 1, SampleRate: 1.5},
+		{MaxBatches: 1, SampleRate: -0.1},
+		{MaxBatches: 1, SampleEveryN: -1},
+	} {
+		if err := Validate(req); err == nil {
+			t.Fatalf("expected validation error for %+v", req)
+		}
+	}
+	registerReq, err := RegisterRequest(StreamRequest{MaxBatches: 1, SampleRate: 0.25}, "")
+	if err != nil || registerReq.SampleRate != 0.25 {
+		t.Fatalf("expected sample_rate in the register request, got %v / %v", registerReq.SampleRate, err)
+	}
+}
+
+func TestValidateRateLimitsAndBudgets(t *testing.T) {
+	req := StreamRequest{MaxBatches: 1, MaxBatchesPerSecond: 0.5, MaxRecordsPerSecond: 100, MaxBytesPerSecond: 1 << 20, MaxRecords: 1000, MaxBytes: 1 << 20}
+	if err := Validate(req); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	for _, req := range []StreamRequest{
+		{MaxBatches: 1, MaxBatchesPerSecond: -1},
+		{MaxBatches: 1, MaxRecordsPerSecond: -1},
+		{MaxBatches: 1, MaxBytesPerSecond: -1},
+		{MaxBatches: 1, MaxRecords: -1},
+		{MaxBatches: 1, MaxBytes: -1},
+	} {
+		if err := Validate(req); err == nil {
+			t.Fatalf("expected validation error for %+v", req)
+		}
+	}
+}
+
+func TestValidateTriggerRequiresSignals(t *testing.T) {
+	trigger := &TriggerRequest{StreamRequest: StreamRequest{MinSeverityNumber: 17}}
+	if err := Validate(StreamRequest{MaxBatches: 1, Trigger: trigger}); err == nil || !strings.Contains(err.Error(), "trigger.signals") {
+		t.Fatalf("expected a trigger without signals to be rejected, got %v", err)
+	}
+	trigger.Signals = []model.SignalType{model.SignalLogs}
+	if err := Validate(StreamRequest{MaxBatches: 1, Trigger: trigger}); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
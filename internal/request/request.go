@@ -0,0 +1,85 @@
+// Package request holds the session definition shared by the capture APIs, with its validation
+// and compilation into capture registry requests.
+package request
+
+import "github.com/utrack/otellens/internal/model"
+
+// StreamRequest defines one capture session: its filters, limits and output. The HTTP, WebSocket
+// and gRPC APIs all accept it.
+//
+// MetricNames, SpanNames and AttributeNames values are exact unless prefixed: `glob:` for a glob
+// (`*`, `?`, `[...]`, `\` escapes) and `~` for an RE2 expression. A `!` prefix negates a value.
+type StreamRequest struct {
+	Signals                   []model.SignalType `json:"signals"`
+	MetricNames               []string           `json:"metric_names"`
+	SpanNames                 []string           `json:"span_names"`
+	AttributeNames            []string           `json:"attribute_names"`
+	LogBodyContains           string             `json:"log_body_contains"`
+	MinSeverityNumber         int32              `json:"min_severity_number"`
+	ResourceAttributes        map[string]string  `json:"resource_attributes"`
+	AttributeFilters          []AttributeFilter  `json:"attribute_filters"`
+	Where                     string             `json:"where"`
+	SpanKinds                 []string           `json:"span_kinds"`
+	SpanStatusCodes           []string           `json:"span_status_codes"`
+	SpanStatusMessageContains string             `json:"span_status_message_contains"`
+	MinSpanDurationMs         float64            `json:"min_span_duration_ms"`
+	MaxSpanDurationMs         float64            `json:"max_span_duration_ms"`
+	RootSpansOnly             bool               `json:"root_spans_only"`
+	TraceIDs                  []string           `json:"trace_ids"`
+	SpanIDs                   []string           `json:"span_ids"`
+	HasExemplars              bool               `json:"has_exemplars"`
+	ExemplarTraceIDs          []string           `json:"exemplar_trace_ids"`
+	BucketCountsCount         *int               `json:"bucket_counts_count"`
+	ExplicitBoundsCount       *int               `json:"explicit_bounds_count"`
+	ExponentialScale          *int               `json:"exponential_scale"`
+	PositiveBucketCountsCount *int               `json:"positive_bucket_counts_count"`
+	NegativeBucketCountsCount *int               `json:"negative_bucket_counts_count"`
+	Format                    model.OutputFormat `json:"format"`
+	VerboseMetrics            bool               `json:"verbose_metrics"`
+	VerboseTraces             bool               `json:"verbose_traces"`
+	VerboseLogs               bool               `json:"verbose_logs"`
+	MaxLogRecords             int                `json:"max_log_records"`
+	// SampleRate keeps this fraction of matching records (spans and logs by trace ID, metrics by name
+	// and resource attributes); 0 means unset and keeps every record. Triggers are not sampled.
+	SampleRate float64 `json:"sample_rate"`
+	// SampleEveryN keeps one of every N matching batches.
+	SampleEveryN int `json:"sample_every_n"`
+	MaxBatches   int `json:"max_batches"`
+	// MaxRecords and MaxBytes end the session once the streamed envelopes hold this many records or
+	// this much estimated payload size; 0 is unlimited.
+	MaxRecords     int   `json:"max_records"`
+	MaxBytes       int64 `json:"max_bytes"`
+	TimeoutSeconds int   `json:"timeout_seconds"`
+	// Label is an optional free-form tag shown in session listings, e.g. an owner or ticket.
+	Label string `json:"label"`
+	// DetachGraceSeconds is how long a session created via POST /v1/sessions survives without an attached stream.
+	DetachGraceSeconds int `json:"detach_grace_seconds"`
+	// IncludeHistorySeconds replays matching batches kept by the flight recorder from this far back.
+	IncludeHistorySeconds int `json:"include_history_seconds"`
+	// Trigger arms the session: nothing is captured until a record matches it.
+	Trigger *TriggerRequest `json:"trigger,omitempty"`
+	// MaxBatchesPerSecond, MaxRecordsPerSecond and MaxBytesPerSecond rate-limit the stream; 0 is unlimited.
+	// Bytes are the estimated encoded size of the payloads.
+	MaxBatchesPerSecond float64 `json:"max_batches_per_second"`
+	MaxRecordsPerSecond float64 `json:"max_records_per_second"`
+	MaxBytesPerSecond   float64 `json:"max_bytes_per_second"`
+}
+
+// TriggerRequest is the condition an armed session waits for. It takes the filter fields of
+// StreamRequest; limits, format and verbosity are ignored.
+type TriggerRequest struct {
+	StreamRequest
+	// CaptureSeconds ends the session this long after the trigger fired; 0 leaves it to max_batches and timeout_seconds.
+	CaptureSeconds int `json:"capture_seconds"`
+}
+
+// AttributeFilter is one attribute value predicate.
+// Level is one of resource, scope, datapoint, span, event, log; empty checks all levels of the record.
+// Op is one of eq, neq, regex, in, exists, gt, gte, lt, lte, contains.
+type AttributeFilter struct {
+	Key    string        `json:"key"`
+	Level  string        `json:"level"`
+	Op     string        `json:"op"`
+	Value  interface{}   `json:"value"`
+	Values []interface{} `json:"values"`
+}
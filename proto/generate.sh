@@ -0,0 +1,20 @@
+#!/bin/sh
+# Generates the Go code of the otellens protos. Requires protoc, protoc-gen-go and protoc-gen-go-grpc on PATH.
+# The OTLP imports are read from OTLP_PROTO_DIR, or from a checkout of opentelemetry-proto
+# matching the go.opentelemetry.io/proto/otlp version in go.mod.
+set -eu
+
+cd "$(dirname "$0")"
+
+OTLP_PROTO_VERSION=v1.9.0
+if [ -z "${OTLP_PROTO_DIR:-}" ]; then
+	OTLP_PROTO_DIR=$(mktemp -d)
+	trap 'rm -rf "$OTLP_PROTO_DIR"' EXIT
+	git clone --quiet --depth 1 --branch "$OTLP_PROTO_VERSION" \
+		https://github.com/open-telemetry/opentelemetry-proto.git "$OTLP_PROTO_DIR"
+fi
+
+protoc -I . -I "$OTLP_PROTO_DIR" \
+	--go_out=. --go_opt=paths=source_relative \
+	--go-grpc_out=. --go-grpc_opt=paths=source_relative \
+	otellens/v1/capture.proto
@@ -0,0 +1,1490 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: otellens/v1/capture.proto
+
+// Capture service of the otellens exporter. Messages mirror the JSON contracts of the HTTP API
+// (StreamRequest, Envelope, StreamEnd); payloads are the matching subset of each batch as OTLP.
+
+package otellensv1
+
+import (
+	v1 "go.opentelemetry.io/proto/otlp/common/v1"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// StreamRequest mirrors the JSON body of POST /v1/capture/stream; see the README for field semantics.
+type StreamRequest struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Signals []string               `protobuf:"bytes,1,rep,name=signals,proto3" json:"signals,omitempty"`
+	// metric_names, span_names and attribute_names values are exact unless prefixed with `glob:` or
+	// `~` (regex); `!` negates a value.
+	MetricNames               []string           `protobuf:"bytes,2,rep,name=metric_names,json=metricNames,proto3" json:"metric_names,omitempty"`
+	SpanNames                 []string           `protobuf:"bytes,3,rep,name=span_names,json=spanNames,proto3" json:"span_names,omitempty"`
+	AttributeNames            []string           `protobuf:"bytes,4,rep,name=attribute_names,json=attributeNames,proto3" json:"attribute_names,omitempty"`
+	LogBodyContains           string             `protobuf:"bytes,5,opt,name=log_body_contains,json=logBodyContains,proto3" json:"log_body_contains,omitempty"`
+	MinSeverityNumber         int32              `protobuf:"varint,6,opt,name=min_severity_number,json=minSeverityNumber,proto3" json:"min_severity_number,omitempty"`
+	ResourceAttributes        map[string]string  `protobuf:"bytes,7,rep,name=resource_attributes,json=resourceAttributes,proto3" json:"resource_attributes,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	AttributeFilters          []*AttributeFilter `protobuf:"bytes,8,rep,name=attribute_filters,json=attributeFilters,proto3" json:"attribute_filters,omitempty"`
+	Where                     string             `protobuf:"bytes,9,opt,name=where,proto3" json:"where,omitempty"`
+	SpanKinds                 []string           `protobuf:"bytes,10,rep,name=span_kinds,json=spanKinds,proto3" json:"span_kinds,omitempty"`
+	SpanStatusCodes           []string           `protobuf:"bytes,11,rep,name=span_status_codes,json=spanStatusCodes,proto3" json:"span_status_codes,omitempty"`
+	SpanStatusMessageContains string             `protobuf:"bytes,12,opt,name=span_status_message_contains,json=spanStatusMessageContains,proto3" json:"span_status_message_contains,omitempty"`
+	MinSpanDurationMs         float64            `protobuf:"fixed64,13,opt,name=min_span_duration_ms,json=minSpanDurationMs,proto3" json:"min_span_duration_ms,omitempty"`
+	MaxSpanDurationMs         float64            `protobuf:"fixed64,14,opt,name=max_span_duration_ms,json=maxSpanDurationMs,proto3" json:"max_span_duration_ms,omitempty"`
+	RootSpansOnly             bool               `protobuf:"varint,15,opt,name=root_spans_only,json=rootSpansOnly,proto3" json:"root_spans_only,omitempty"`
+	TraceIds                  []string           `protobuf:"bytes,16,rep,name=trace_ids,json=traceIds,proto3" json:"trace_ids,omitempty"`
+	SpanIds                   []string           `protobuf:"bytes,17,rep,name=span_ids,json=spanIds,proto3" json:"span_ids,omitempty"`
+	HasExemplars              bool               `protobuf:"varint,18,opt,name=has_exemplars,json=hasExemplars,proto3" json:"has_exemplars,omitempty"`
+	ExemplarTraceIds          []string           `protobuf:"bytes,19,rep,name=exemplar_trace_ids,json=exemplarTraceIds,proto3" json:"exemplar_trace_ids,omitempty"`
+	BucketCountsCount         *int32             `protobuf:"varint,20,opt,name=bucket_counts_count,json=bucketCountsCount,proto3,oneof" json:"bucket_counts_count,omitempty"`
+	ExplicitBoundsCount       *int32             `protobuf:"varint,21,opt,name=explicit_bounds_count,json=explicitBoundsCount,proto3,oneof" json:"explicit_bounds_count,omitempty"`
+	ExponentialScale          *int32             `protobuf:"varint,22,opt,name=exponential_scale,json=exponentialScale,proto3,oneof" json:"exponential_scale,omitempty"`
+	PositiveBucketCountsCount *int32             `protobuf:"varint,23,opt,name=positive_bucket_counts_count,json=positiveBucketCountsCount,proto3,oneof" json:"positive_bucket_counts_count,omitempty"`
+	NegativeBucketCountsCount *int32             `protobuf:"varint,24,opt,name=negative_bucket_counts_count,json=negativeBucketCountsCount,proto3,oneof" json:"negative_bucket_counts_count,omitempty"`
+	MaxBatches                int32              `protobuf:"varint,25,opt,name=max_batches,json=maxBatches,proto3" json:"max_batches,omitempty"`
+	TimeoutSeconds            int32              `protobuf:"varint,26,opt,name=timeout_seconds,json=timeoutSeconds,proto3" json:"timeout_seconds,omitempty"`
+	Label                     string             `protobuf:"bytes,27,opt,name=label,proto3" json:"label,omitempty"`
+	// Replays matching batches kept by the flight recorder from this far back; requires it to be enabled.
+	IncludeHistorySeconds int32 `protobuf:"varint,28,opt,name=include_history_seconds,json=includeHistorySeconds,proto3" json:"include_history_seconds,omitempty"`
+	// Arms the session: nothing is captured until a record matches the trigger.
+	Trigger *Trigger `protobuf:"bytes,29,opt,name=trigger,proto3" json:"trigger,omitempty"`
+	// Keeps this fraction of matching records: spans and logs by trace ID, metrics by name and resource
+	// attributes. 0 is unset and keeps every record. Triggers are not sampled.
+	SampleRate float64 `protobuf:"fixed64,30,opt,name=sample_rate,json=sampleRate,proto3" json:"sample_rate,omitempty"`
+	// Keeps one of every sample_every_n matching batches.
+	SampleEveryN int32 `protobuf:"varint,31,opt,name=sample_every_n,json=sampleEveryN,proto3" json:"sample_every_n,omitempty"`
+	// Rate-limit the stream per second; 0 is unlimited. Bytes are the estimated payload size.
+	MaxBatchesPerSecond float64 `protobuf:"fixed64,32,opt,name=max_batches_per_second,json=maxBatchesPerSecond,proto3" json:"max_batches_per_second,omitempty"`
+	MaxRecordsPerSecond float64 `protobuf:"fixed64,33,opt,name=max_records_per_second,json=maxRecordsPerSecond,proto3" json:"max_records_per_second,omitempty"`
+	MaxBytesPerSecond   float64 `protobuf:"fixed64,34,opt,name=max_bytes_per_second,json=maxBytesPerSecond,proto3" json:"max_bytes_per_second,omitempty"`
+	// End the session once the streamed envelopes hold this many records or this much estimated payload size.
+	MaxRecords    int32 `protobuf:"varint,35,opt,name=max_records,json=maxRecords,proto3" json:"max_records,omitempty"`
+	MaxBytes      int64 `protobuf:"varint,36,opt,name=max_bytes,json=maxBytes,proto3" json:"max_bytes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamRequest) Reset() {
+	*x = StreamRequest{}
+	mi := &file_otellens_v1_capture_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamRequest) ProtoMessage() {}
+
+func (x *StreamRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_otellens_v1_capture_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamRequest.ProtoReflect.Descriptor instead.
+func (*StreamRequest) Descriptor() ([]byte, []int) {
+	return file_otellens_v1_capture_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *StreamRequest) GetSignals() []string {
+	if x != nil {
+		return x.Signals
+	}
+	return nil
+}
+
+func (x *StreamRequest) GetMetricNames() []string {
+	if x != nil {
+		return x.MetricNames
+	}
+	return nil
+}
+
+func (x *StreamRequest) GetSpanNames() []string {
+	if x != nil {
+		return x.SpanNames
+	}
+	return nil
+}
+
+func (x *StreamRequest) GetAttributeNames() []string {
+	if x != nil {
+		return x.AttributeNames
+	}
+	return nil
+}
+
+func (x *StreamRequest) GetLogBodyContains() string {
+	if x != nil {
+		return x.LogBodyContains
+	}
+	return ""
+}
+
+func (x *StreamRequest) GetMinSeverityNumber() int32 {
+	if x != nil {
+		return x.MinSeverityNumber
+	}
+	return 0
+}
+
+func (x *StreamRequest) GetResourceAttributes() map[string]string {
+	if x != nil {
+		return x.ResourceAttributes
+	}
+	return nil
+}
+
+func (x *StreamRequest) GetAttributeFilters() []*AttributeFilter {
+	if x != nil {
+		return x.AttributeFilters
+	}
+	return nil
+}
+
+func (x *StreamRequest) GetWhere() string {
+	if x != nil {
+		return x.Where
+	}
+	return ""
+}
+
+func (x *StreamRequest) GetSpanKinds() []string {
+	if x != nil {
+		return x.SpanKinds
+	}
+	return nil
+}
+
+func (x *StreamRequest) GetSpanStatusCodes() []string {
+	if x != nil {
+		return x.SpanStatusCodes
+	}
+	return nil
+}
+
+func (x *StreamRequest) GetSpanStatusMessageContains() string {
+	if x != nil {
+		return x.SpanStatusMessageContains
+	}
+	return ""
+}
+
+func (x *StreamRequest) GetMinSpanDurationMs() float64 {
+	if x != nil {
+		return x.MinSpanDurationMs
+	}
+	return 0
+}
+
+func (x *StreamRequest) GetMaxSpanDurationMs() float64 {
+	if x != nil {
+		return x.MaxSpanDurationMs
+	}
+	return 0
+}
+
+func (x *StreamRequest) GetRootSpansOnly() bool {
+	if x != nil {
+		return x.RootSpansOnly
+	}
+	return false
+}
+
+func (x *StreamRequest) GetTraceIds() []string {
+	if x != nil {
+		return x.TraceIds
+	}
+	return nil
+}
+
+func (x *StreamRequest) GetSpanIds() []string {
+	if x != nil {
+		return x.SpanIds
+	}
+	return nil
+}
+
+func (x *StreamRequest) GetHasExemplars() bool {
+	if x != nil {
+		return x.HasExemplars
+	}
+	return false
+}
+
+func (x *StreamRequest) GetExemplarTraceIds() []string {
+	if x != nil {
+		return x.ExemplarTraceIds
+	}
+	return nil
+}
+
+func (x *StreamRequest) GetBucketCountsCount() int32 {
+	if x != nil && x.BucketCountsCount != nil {
+		return *x.BucketCountsCount
+	}
+	return 0
+}
+
+func (x *StreamRequest) GetExplicitBoundsCount() int32 {
+	if x != nil && x.ExplicitBoundsCount != nil {
+		return *x.ExplicitBoundsCount
+	}
+	return 0
+}
+
+func (x *StreamRequest) GetExponentialScale() int32 {
+	if x != nil && x.ExponentialScale != nil {
+		return *x.ExponentialScale
+	}
+	return 0
+}
+
+func (x *StreamRequest) GetPositiveBucketCountsCount() int32 {
+	if x != nil && x.PositiveBucketCountsCount != nil {
+		return *x.PositiveBucketCountsCount
+	}
+	return 0
+}
+
+func (x *StreamRequest) GetNegativeBucketCountsCount() int32 {
+	if x != nil && x.NegativeBucketCountsCount != nil {
+		return *x.NegativeBucketCountsCount
+	}
+	return 0
+}
+
+func (x *StreamRequest) GetMaxBatches() int32 {
+	if x != nil {
+		return x.MaxBatches
+	}
+	return 0
+}
+
+func (x *StreamRequest) GetTimeoutSeconds() int32 {
+	if x != nil {
+		return x.TimeoutSeconds
+	}
+	return 0
+}
+
+func (x *StreamRequest) GetLabel() string {
+	if x != nil {
+		return x.Label
+	}
+	return ""
+}
+
+func (x *StreamRequest) GetIncludeHistorySeconds() int32 {
+	if x != nil {
+		return x.IncludeHistorySeconds
+	}
+	return 0
+}
+
+func (x *StreamRequest) GetTrigger() *Trigger {
+	if x != nil {
+		return x.Trigger
+	}
+	return nil
+}
+
+func (x *StreamRequest) GetSampleRate() float64 {
+	if x != nil {
+		return x.SampleRate
+	}
+	return 0
+}
+
+func (x *StreamRequest) GetSampleEveryN() int32 {
+	if x != nil {
+		return x.SampleEveryN
+	}
+	return 0
+}
+
+func (x *StreamRequest) GetMaxBatchesPerSecond() float64 {
+	if x != nil {
+		return x.MaxBatchesPerSecond
+	}
+	return 0
+}
+
+func (x *StreamRequest) GetMaxRecordsPerSecond() float64 {
+	if x != nil {
+		return x.MaxRecordsPerSecond
+	}
+	return 0
+}
+
+func (x *StreamRequest) GetMaxBytesPerSecond() float64 {
+	if x != nil {
+		return x.MaxBytesPerSecond
+	}
+	return 0
+}
+
+func (x *StreamRequest) GetMaxRecords() int32 {
+	if x != nil {
+		return x.MaxRecords
+	}
+	return 0
+}
+
+func (x *StreamRequest) GetMaxBytes() int64 {
+	if x != nil {
+		return x.MaxBytes
+	}
+	return 0
+}
+
+// Trigger is the condition an armed session waits for. Only the filter fields of filter apply, and
+// filter.signals is required.
+type Trigger struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Filter *StreamRequest         `protobuf:"bytes,1,opt,name=filter,proto3" json:"filter,omitempty"`
+	// Ends the session this long after the trigger fired; 0 leaves it to max_batches and timeout_seconds.
+	CaptureSeconds int32 `protobuf:"varint,2,opt,name=capture_seconds,json=captureSeconds,proto3" json:"capture_seconds,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *Trigger) Reset() {
+	*x = Trigger{}
+	mi := &file_otellens_v1_capture_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Trigger) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Trigger) ProtoMessage() {}
+
+func (x *Trigger) ProtoReflect() protoreflect.Message {
+	mi := &file_otellens_v1_capture_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Trigger.ProtoReflect.Descriptor instead.
+func (*Trigger) Descriptor() ([]byte, []int) {
+	return file_otellens_v1_capture_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Trigger) GetFilter() *StreamRequest {
+	if x != nil {
+		return x.Filter
+	}
+	return nil
+}
+
+func (x *Trigger) GetCaptureSeconds() int32 {
+	if x != nil {
+		return x.CaptureSeconds
+	}
+	return 0
+}
+
+// AttributeFilter is one attribute value predicate. Only scalar AnyValue variants are supported.
+type AttributeFilter struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Level         string                 `protobuf:"bytes,2,opt,name=level,proto3" json:"level,omitempty"`
+	Op            string                 `protobuf:"bytes,3,opt,name=op,proto3" json:"op,omitempty"`
+	Value         *v1.AnyValue           `protobuf:"bytes,4,opt,name=value,proto3" json:"value,omitempty"`
+	Values        []*v1.AnyValue         `protobuf:"bytes,5,rep,name=values,proto3" json:"values,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AttributeFilter) Reset() {
+	*x = AttributeFilter{}
+	mi := &file_otellens_v1_capture_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AttributeFilter) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AttributeFilter) ProtoMessage() {}
+
+func (x *AttributeFilter) ProtoReflect() protoreflect.Message {
+	mi := &file_otellens_v1_capture_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AttributeFilter.ProtoReflect.Descriptor instead.
+func (*AttributeFilter) Descriptor() ([]byte, []int) {
+	return file_otellens_v1_capture_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *AttributeFilter) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *AttributeFilter) GetLevel() string {
+	if x != nil {
+		return x.Level
+	}
+	return ""
+}
+
+func (x *AttributeFilter) GetOp() string {
+	if x != nil {
+		return x.Op
+	}
+	return ""
+}
+
+func (x *AttributeFilter) GetValue() *v1.AnyValue {
+	if x != nil {
+		return x.Value
+	}
+	return nil
+}
+
+func (x *AttributeFilter) GetValues() []*v1.AnyValue {
+	if x != nil {
+		return x.Values
+	}
+	return nil
+}
+
+type CaptureEvent struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Event:
+	//
+	//	*CaptureEvent_Envelope
+	//	*CaptureEvent_End
+	//	*CaptureEvent_FilterUpdated
+	//	*CaptureEvent_Triggered
+	//	*CaptureEvent_RateLimited
+	Event         isCaptureEvent_Event `protobuf_oneof:"event"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CaptureEvent) Reset() {
+	*x = CaptureEvent{}
+	mi := &file_otellens_v1_capture_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CaptureEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CaptureEvent) ProtoMessage() {}
+
+func (x *CaptureEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_otellens_v1_capture_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CaptureEvent.ProtoReflect.Descriptor instead.
+func (*CaptureEvent) Descriptor() ([]byte, []int) {
+	return file_otellens_v1_capture_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *CaptureEvent) GetEvent() isCaptureEvent_Event {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+func (x *CaptureEvent) GetEnvelope() *Envelope {
+	if x != nil {
+		if x, ok := x.Event.(*CaptureEvent_Envelope); ok {
+			return x.Envelope
+		}
+	}
+	return nil
+}
+
+func (x *CaptureEvent) GetEnd() *StreamEnd {
+	if x != nil {
+		if x, ok := x.Event.(*CaptureEvent_End); ok {
+			return x.End
+		}
+	}
+	return nil
+}
+
+func (x *CaptureEvent) GetFilterUpdated() *FilterUpdated {
+	if x != nil {
+		if x, ok := x.Event.(*CaptureEvent_FilterUpdated); ok {
+			return x.FilterUpdated
+		}
+	}
+	return nil
+}
+
+func (x *CaptureEvent) GetTriggered() *Triggered {
+	if x != nil {
+		if x, ok := x.Event.(*CaptureEvent_Triggered); ok {
+			return x.Triggered
+		}
+	}
+	return nil
+}
+
+func (x *CaptureEvent) GetRateLimited() *RateLimited {
+	if x != nil {
+		if x, ok := x.Event.(*CaptureEvent_RateLimited); ok {
+			return x.RateLimited
+		}
+	}
+	return nil
+}
+
+type isCaptureEvent_Event interface {
+	isCaptureEvent_Event()
+}
+
+type CaptureEvent_Envelope struct {
+	Envelope *Envelope `protobuf:"bytes,1,opt,name=envelope,proto3,oneof"`
+}
+
+type CaptureEvent_End struct {
+	End *StreamEnd `protobuf:"bytes,2,opt,name=end,proto3,oneof"`
+}
+
+type CaptureEvent_FilterUpdated struct {
+	FilterUpdated *FilterUpdated `protobuf:"bytes,3,opt,name=filter_updated,json=filterUpdated,proto3,oneof"`
+}
+
+type CaptureEvent_Triggered struct {
+	Triggered *Triggered `protobuf:"bytes,4,opt,name=triggered,proto3,oneof"`
+}
+
+type CaptureEvent_RateLimited struct {
+	RateLimited *RateLimited `protobuf:"bytes,5,opt,name=rate_limited,json=rateLimited,proto3,oneof"`
+}
+
+func (*CaptureEvent_Envelope) isCaptureEvent_Event() {}
+
+func (*CaptureEvent_End) isCaptureEvent_Event() {}
+
+func (*CaptureEvent_FilterUpdated) isCaptureEvent_Event() {}
+
+func (*CaptureEvent_Triggered) isCaptureEvent_Event() {}
+
+func (*CaptureEvent_RateLimited) isCaptureEvent_Event() {}
+
+// RateLimited reports what the session's rate limits suppressed since the previous RateLimited event.
+// It is sent on the first suppression and then at most every 5 seconds while suppression continues.
+type RateLimited struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	SessionId          string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	BatchIndex         uint64                 `protobuf:"varint,2,opt,name=batch_index,json=batchIndex,proto3" json:"batch_index,omitempty"`
+	ReportedAtUnixNano uint64                 `protobuf:"fixed64,3,opt,name=reported_at_unix_nano,json=reportedAtUnixNano,proto3" json:"reported_at_unix_nano,omitempty"`
+	Batches            uint64                 `protobuf:"varint,4,opt,name=batches,proto3" json:"batches,omitempty"`
+	Records            uint64                 `protobuf:"varint,5,opt,name=records,proto3" json:"records,omitempty"`
+	Bytes              uint64                 `protobuf:"varint,6,opt,name=bytes,proto3" json:"bytes,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *RateLimited) Reset() {
+	*x = RateLimited{}
+	mi := &file_otellens_v1_capture_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RateLimited) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RateLimited) ProtoMessage() {}
+
+func (x *RateLimited) ProtoReflect() protoreflect.Message {
+	mi := &file_otellens_v1_capture_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RateLimited.ProtoReflect.Descriptor instead.
+func (*RateLimited) Descriptor() ([]byte, []int) {
+	return file_otellens_v1_capture_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *RateLimited) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *RateLimited) GetBatchIndex() uint64 {
+	if x != nil {
+		return x.BatchIndex
+	}
+	return 0
+}
+
+func (x *RateLimited) GetReportedAtUnixNano() uint64 {
+	if x != nil {
+		return x.ReportedAtUnixNano
+	}
+	return 0
+}
+
+func (x *RateLimited) GetBatches() uint64 {
+	if x != nil {
+		return x.Batches
+	}
+	return 0
+}
+
+func (x *RateLimited) GetRecords() uint64 {
+	if x != nil {
+		return x.Records
+	}
+	return 0
+}
+
+func (x *RateLimited) GetBytes() uint64 {
+	if x != nil {
+		return x.Bytes
+	}
+	return 0
+}
+
+// Triggered marks the stream position where an armed session's trigger fired; captured envelopes follow.
+// signal names the signal of the batch that fired it.
+type Triggered struct {
+	state               protoimpl.MessageState `protogen:"open.v1"`
+	SessionId           string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	BatchIndex          uint64                 `protobuf:"varint,2,opt,name=batch_index,json=batchIndex,proto3" json:"batch_index,omitempty"`
+	Signal              string                 `protobuf:"bytes,3,opt,name=signal,proto3" json:"signal,omitempty"`
+	TriggeredAtUnixNano uint64                 `protobuf:"fixed64,4,opt,name=triggered_at_unix_nano,json=triggeredAtUnixNano,proto3" json:"triggered_at_unix_nano,omitempty"`
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
+}
+
+func (x *Triggered) Reset() {
+	*x = Triggered{}
+	mi := &file_otellens_v1_capture_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Triggered) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Triggered) ProtoMessage() {}
+
+func (x *Triggered) ProtoReflect() protoreflect.Message {
+	mi := &file_otellens_v1_capture_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Triggered.ProtoReflect.Descriptor instead.
+func (*Triggered) Descriptor() ([]byte, []int) {
+	return file_otellens_v1_capture_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Triggered) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *Triggered) GetBatchIndex() uint64 {
+	if x != nil {
+		return x.BatchIndex
+	}
+	return 0
+}
+
+func (x *Triggered) GetSignal() string {
+	if x != nil {
+		return x.Signal
+	}
+	return ""
+}
+
+func (x *Triggered) GetTriggeredAtUnixNano() uint64 {
+	if x != nil {
+		return x.TriggeredAtUnixNano
+	}
+	return 0
+}
+
+// FilterUpdated marks the stream position from which the session's replaced filter applies.
+// batch_index shares the sequence of Envelope.batch_index.
+type FilterUpdated struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	SessionId         string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	BatchIndex        uint64                 `protobuf:"varint,2,opt,name=batch_index,json=batchIndex,proto3" json:"batch_index,omitempty"`
+	UpdatedAtUnixNano uint64                 `protobuf:"fixed64,3,opt,name=updated_at_unix_nano,json=updatedAtUnixNano,proto3" json:"updated_at_unix_nano,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *FilterUpdated) Reset() {
+	*x = FilterUpdated{}
+	mi := &file_otellens_v1_capture_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FilterUpdated) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FilterUpdated) ProtoMessage() {}
+
+func (x *FilterUpdated) ProtoReflect() protoreflect.Message {
+	mi := &file_otellens_v1_capture_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FilterUpdated.ProtoReflect.Descriptor instead.
+func (*FilterUpdated) Descriptor() ([]byte, []int) {
+	return file_otellens_v1_capture_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *FilterUpdated) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *FilterUpdated) GetBatchIndex() uint64 {
+	if x != nil {
+		return x.BatchIndex
+	}
+	return 0
+}
+
+func (x *FilterUpdated) GetUpdatedAtUnixNano() uint64 {
+	if x != nil {
+		return x.UpdatedAtUnixNano
+	}
+	return 0
+}
+
+type Envelope struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	SessionId          string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	Signal             string                 `protobuf:"bytes,2,opt,name=signal,proto3" json:"signal,omitempty"`
+	BatchIndex         uint64                 `protobuf:"varint,3,opt,name=batch_index,json=batchIndex,proto3" json:"batch_index,omitempty"`
+	CapturedAtUnixNano uint64                 `protobuf:"fixed64,4,opt,name=captured_at_unix_nano,json=capturedAtUnixNano,proto3" json:"captured_at_unix_nano,omitempty"`
+	// A serialized opentelemetry.proto.collector.*.v1.Export*ServiceRequest, passed through as encoded
+	// by the collector. The bytes are wire-compatible with the message type, so clients may declare the
+	// fields as ExportMetricsServiceRequest, ExportTraceServiceRequest and ExportLogsServiceRequest instead.
+	//
+	// Types that are valid to be assigned to Payload:
+	//
+	//	*Envelope_Metrics
+	//	*Envelope_Traces
+	//	*Envelope_Logs
+	Payload isEnvelope_Payload `protobuf_oneof:"payload"`
+	// Set on batches replayed from the flight recorder; captured_at is then the recording time.
+	History bool `protobuf:"varint,8,opt,name=history,proto3" json:"history,omitempty"`
+	// Set when the session samples; scale counts by every_n / rate to extrapolate.
+	Sampling *Sampling `protobuf:"bytes,9,opt,name=sampling,proto3" json:"sampling,omitempty"`
+	// Number of matching metrics, spans or log records in the payload.
+	Records       uint32 `protobuf:"varint,10,opt,name=records,proto3" json:"records,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Envelope) Reset() {
+	*x = Envelope{}
+	mi := &file_otellens_v1_capture_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Envelope) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Envelope) ProtoMessage() {}
+
+func (x *Envelope) ProtoReflect() protoreflect.Message {
+	mi := &file_otellens_v1_capture_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Envelope.ProtoReflect.Descriptor instead.
+func (*Envelope) Descriptor() ([]byte, []int) {
+	return file_otellens_v1_capture_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *Envelope) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *Envelope) GetSignal() string {
+	if x != nil {
+		return x.Signal
+	}
+	return ""
+}
+
+func (x *Envelope) GetBatchIndex() uint64 {
+	if x != nil {
+		return x.BatchIndex
+	}
+	return 0
+}
+
+func (x *Envelope) GetCapturedAtUnixNano() uint64 {
+	if x != nil {
+		return x.CapturedAtUnixNano
+	}
+	return 0
+}
+
+func (x *Envelope) GetPayload() isEnvelope_Payload {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *Envelope) GetMetrics() []byte {
+	if x != nil {
+		if x, ok := x.Payload.(*Envelope_Metrics); ok {
+			return x.Metrics
+		}
+	}
+	return nil
+}
+
+func (x *Envelope) GetTraces() []byte {
+	if x != nil {
+		if x, ok := x.Payload.(*Envelope_Traces); ok {
+			return x.Traces
+		}
+	}
+	return nil
+}
+
+func (x *Envelope) GetLogs() []byte {
+	if x != nil {
+		if x, ok := x.Payload.(*Envelope_Logs); ok {
+			return x.Logs
+		}
+	}
+	return nil
+}
+
+func (x *Envelope) GetHistory() bool {
+	if x != nil {
+		return x.History
+	}
+	return false
+}
+
+func (x *Envelope) GetSampling() *Sampling {
+	if x != nil {
+		return x.Sampling
+	}
+	return nil
+}
+
+func (x *Envelope) GetRecords() uint32 {
+	if x != nil {
+		return x.Records
+	}
+	return 0
+}
+
+type isEnvelope_Payload interface {
+	isEnvelope_Payload()
+}
+
+type Envelope_Metrics struct {
+	Metrics []byte `protobuf:"bytes,5,opt,name=metrics,proto3,oneof"`
+}
+
+type Envelope_Traces struct {
+	Traces []byte `protobuf:"bytes,6,opt,name=traces,proto3,oneof"`
+}
+
+type Envelope_Logs struct {
+	Logs []byte `protobuf:"bytes,7,opt,name=logs,proto3,oneof"`
+}
+
+func (*Envelope_Metrics) isEnvelope_Payload() {}
+
+func (*Envelope_Traces) isEnvelope_Payload() {}
+
+func (*Envelope_Logs) isEnvelope_Payload() {}
+
+type Sampling struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Rate          float64                `protobuf:"fixed64,1,opt,name=rate,proto3" json:"rate,omitempty"`
+	EveryN        uint32                 `protobuf:"varint,2,opt,name=every_n,json=everyN,proto3" json:"every_n,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Sampling) Reset() {
+	*x = Sampling{}
+	mi := &file_otellens_v1_capture_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Sampling) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Sampling) ProtoMessage() {}
+
+func (x *Sampling) ProtoReflect() protoreflect.Message {
+	mi := &file_otellens_v1_capture_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Sampling.ProtoReflect.Descriptor instead.
+func (*Sampling) Descriptor() ([]byte, []int) {
+	return file_otellens_v1_capture_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *Sampling) GetRate() float64 {
+	if x != nil {
+		return x.Rate
+	}
+	return 0
+}
+
+func (x *Sampling) GetEveryN() uint32 {
+	if x != nil {
+		return x.EveryN
+	}
+	return 0
+}
+
+type StreamEnd struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SessionId     string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	Sent          uint64                 `protobuf:"varint,2,opt,name=sent,proto3" json:"sent,omitempty"`
+	Dropped       uint64                 `protobuf:"varint,3,opt,name=dropped,proto3" json:"dropped,omitempty"`
+	PausedSeconds float64                `protobuf:"fixed64,4,opt,name=paused_seconds,json=pausedSeconds,proto3" json:"paused_seconds,omitempty"`
+	// Batches and records suppressed by the rate limits; not included in dropped.
+	RateLimited        uint64 `protobuf:"varint,5,opt,name=rate_limited,json=rateLimited,proto3" json:"rate_limited,omitempty"`
+	RateLimitedRecords uint64 `protobuf:"varint,6,opt,name=rate_limited_records,json=rateLimitedRecords,proto3" json:"rate_limited_records,omitempty"`
+	// The budget that ended the session: max_batches, max_records or max_bytes; empty if it ended otherwise.
+	LimitReached string `protobuf:"bytes,7,opt,name=limit_reached,json=limitReached,proto3" json:"limit_reached,omitempty"`
+	// Why the session ended: a budget (max_batches, max_records, max_bytes), timeout, capture_window,
+	// client_disconnect, stopped, cancelled, detach_expired or shutdown.
+	Reason string `protobuf:"bytes,8,opt,name=reason,proto3" json:"reason,omitempty"`
+	// Batch counts per signal; signals that matched nothing are left out.
+	Signals         map[string]*SignalStats `protobuf:"bytes,9,rep,name=signals,proto3" json:"signals,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	DurationSeconds float64                 `protobuf:"fixed64,10,opt,name=duration_seconds,json=durationSeconds,proto3" json:"duration_seconds,omitempty"`
+	// Bytes of the CaptureEvent messages sent before this one.
+	BytesWritten uint64 `protobuf:"varint,11,opt,name=bytes_written,json=bytesWritten,proto3" json:"bytes_written,omitempty"`
+	// captured_at of the first and last streamed envelopes; unset if none was streamed.
+	FirstCapturedAtUnixNano uint64 `protobuf:"fixed64,12,opt,name=first_captured_at_unix_nano,json=firstCapturedAtUnixNano,proto3" json:"first_captured_at_unix_nano,omitempty"`
+	LastCapturedAtUnixNano  uint64 `protobuf:"fixed64,13,opt,name=last_captured_at_unix_nano,json=lastCapturedAtUnixNano,proto3" json:"last_captured_at_unix_nano,omitempty"`
+	unknownFields           protoimpl.UnknownFields
+	sizeCache               protoimpl.SizeCache
+}
+
+func (x *StreamEnd) Reset() {
+	*x = StreamEnd{}
+	mi := &file_otellens_v1_capture_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamEnd) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamEnd) ProtoMessage() {}
+
+func (x *StreamEnd) ProtoReflect() protoreflect.Message {
+	mi := &file_otellens_v1_capture_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamEnd.ProtoReflect.Descriptor instead.
+func (*StreamEnd) Descriptor() ([]byte, []int) {
+	return file_otellens_v1_capture_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *StreamEnd) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *StreamEnd) GetSent() uint64 {
+	if x != nil {
+		return x.Sent
+	}
+	return 0
+}
+
+func (x *StreamEnd) GetDropped() uint64 {
+	if x != nil {
+		return x.Dropped
+	}
+	return 0
+}
+
+func (x *StreamEnd) GetPausedSeconds() float64 {
+	if x != nil {
+		return x.PausedSeconds
+	}
+	return 0
+}
+
+func (x *StreamEnd) GetRateLimited() uint64 {
+	if x != nil {
+		return x.RateLimited
+	}
+	return 0
+}
+
+func (x *StreamEnd) GetRateLimitedRecords() uint64 {
+	if x != nil {
+		return x.RateLimitedRecords
+	}
+	return 0
+}
+
+func (x *StreamEnd) GetLimitReached() string {
+	if x != nil {
+		return x.LimitReached
+	}
+	return ""
+}
+
+func (x *StreamEnd) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *StreamEnd) GetSignals() map[string]*SignalStats {
+	if x != nil {
+		return x.Signals
+	}
+	return nil
+}
+
+func (x *StreamEnd) GetDurationSeconds() float64 {
+	if x != nil {
+		return x.DurationSeconds
+	}
+	return 0
+}
+
+func (x *StreamEnd) GetBytesWritten() uint64 {
+	if x != nil {
+		return x.BytesWritten
+	}
+	return 0
+}
+
+func (x *StreamEnd) GetFirstCapturedAtUnixNano() uint64 {
+	if x != nil {
+		return x.FirstCapturedAtUnixNano
+	}
+	return 0
+}
+
+func (x *StreamEnd) GetLastCapturedAtUnixNano() uint64 {
+	if x != nil {
+		return x.LastCapturedAtUnixNano
+	}
+	return 0
+}
+
+// SignalStats counts one signal's batches. Matched batches that were neither sent nor dropped were
+// skipped by sampling or rate limits.
+type SignalStats struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Matched       uint64                 `protobuf:"varint,1,opt,name=matched,proto3" json:"matched,omitempty"`
+	Sent          uint64                 `protobuf:"varint,2,opt,name=sent,proto3" json:"sent,omitempty"`
+	Dropped       uint64                 `protobuf:"varint,3,opt,name=dropped,proto3" json:"dropped,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SignalStats) Reset() {
+	*x = SignalStats{}
+	mi := &file_otellens_v1_capture_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SignalStats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SignalStats) ProtoMessage() {}
+
+func (x *SignalStats) ProtoReflect() protoreflect.Message {
+	mi := &file_otellens_v1_capture_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SignalStats.ProtoReflect.Descriptor instead.
+func (*SignalStats) Descriptor() ([]byte, []int) {
+	return file_otellens_v1_capture_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *SignalStats) GetMatched() uint64 {
+	if x != nil {
+		return x.Matched
+	}
+	return 0
+}
+
+func (x *SignalStats) GetSent() uint64 {
+	if x != nil {
+		return x.Sent
+	}
+	return 0
+}
+
+func (x *SignalStats) GetDropped() uint64 {
+	if x != nil {
+		return x.Dropped
+	}
+	return 0
+}
+
+var File_otellens_v1_capture_proto protoreflect.FileDescriptor
+
+const file_otellens_v1_capture_proto_rawDesc = "" +
+	"\n" +
+	"\x19otellens/v1/capture.proto\x12\votellens.v1\x1a*opentelemetry/proto/common/v1/common.proto\"\xbc\x0e\n" +
+	"\rStreamRequest\x12\x18\n" +
+	"\asignals\x18\x01 \x03(\tR\asignals\x12!\n" +
+	"\fmetric_names\x18\x02 \x03(\tR\vmetricNames\x12\x1d\n" +
+	"\n" +
+	"span_names\x18\x03 \x03(\tR\tspanNames\x12'\n" +
+	"\x0fattribute_names\x18\x04 \x03(\tR\x0eattributeNames\x12*\n" +
+	"\x11log_body_contains\x18\x05 \x01(\tR\x0flogBodyContains\x12.\n" +
+	"\x13min_severity_number\x18\x06 \x01(\x05R\x11minSeverityNumber\x12c\n" +
+	"\x13resource_attributes\x18\a \x03(\v22.otellens.v1.StreamRequest.ResourceAttributesEntryR\x12resourceAttributes\x12I\n" +
+	"\x11attribute_filters\x18\b \x03(\v2\x1c.otellens.v1.AttributeFilterR\x10attributeFilters\x12\x14\n" +
+	"\x05where\x18\t \x01(\tR\x05where\x12\x1d\n" +
+	"\n" +
+	"span_kinds\x18\n" +
+	" \x03(\tR\tspanKinds\x12*\n" +
+	"\x11span_status_codes\x18\v \x03(\tR\x0fspanStatusCodes\x12?\n" +
+	"\x1cspan_status_message_contains\x18\f \x01(\tR\x19spanStatusMessageContains\x12/\n" +
+	"\x14min_span_duration_ms\x18\r \x01(\x01R\x11minSpanDurationMs\x12/\n" +
+	"\x14max_span_duration_ms\x18\x0e \x01(\x01R\x11maxSpanDurationMs\x12&\n" +
+	"\x0froot_spans_only\x18\x0f \x01(\bR\rrootSpansOnly\x12\x1b\n" +
+	"\ttrace_ids\x18\x10 \x03(\tR\btraceIds\x12\x19\n" +
+	"\bspan_ids\x18\x11 \x03(\tR\aspanIds\x12#\n" +
+	"\rhas_exemplars\x18\x12 \x01(\bR\fhasExemplars\x12,\n" +
+	"\x12exemplar_trace_ids\x18\x13 \x03(\tR\x10exemplarTraceIds\x123\n" +
+	"\x13bucket_counts_count\x18\x14 \x01(\x05H\x00R\x11bucketCountsCount\x88\x01\x01\x127\n" +
+	"\x15explicit_bounds_count\x18\x15 \x01(\x05H\x01R\x13explicitBoundsCount\x88\x01\x01\x120\n" +
+	"\x11exponential_scale\x18\x16 \x01(\x05H\x02R\x10exponentialScale\x88\x01\x01\x12D\n" +
+	"\x1cpositive_bucket_counts_count\x18\x17 \x01(\x05H\x03R\x19positiveBucketCountsCount\x88\x01\x01\x12D\n" +
+	"\x1cnegative_bucket_counts_count\x18\x18 \x01(\x05H\x04R\x19negativeBucketCountsCount\x88\x01\x01\x12\x1f\n" +
+	"\vmax_batches\x18\x19 \x01(\x05R\n" +
+	"maxBatches\x12'\n" +
+	"\x0ftimeout_seconds\x18\x1a \x01(\x05R\x0etimeoutSeconds\x12\x14\n" +
+	"\x05label\x18\x1b \x01(\tR\x05label\x126\n" +
+	"\x17include_history_seconds\x18\x1c \x01(\x05R\x15includeHistorySeconds\x12.\n" +
+	"\atrigger\x18\x1d \x01(\v2\x14.otellens.v1.TriggerR\atrigger\x12\x1f\n" +
+	"\vsample_rate\x18\x1e \x01(\x01R\n" +
+	"sampleRate\x12$\n" +
+	"\x0esample_every_n\x18\x1f \x01(\x05R\fsampleEveryN\x123\n" +
+	"\x16max_batches_per_second\x18  \x01(\x01R\x13maxBatchesPerSecond\x123\n" +
+	"\x16max_records_per_second\x18! \x01(\x01R\x13maxRecordsPerSecond\x12/\n" +
+	"\x14max_bytes_per_second\x18\" \x01(\x01R\x11maxBytesPerSecond\x12\x1f\n" +
+	"\vmax_records\x18# \x01(\x05R\n" +
+	"maxRecords\x12\x1b\n" +
+	"\tmax_bytes\x18$ \x01(\x03R\bmaxBytes\x1aE\n" +
+	"\x17ResourceAttributesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01B\x16\n" +
+	"\x14_bucket_counts_countB\x18\n" +
+	"\x16_explicit_bounds_countB\x14\n" +
+	"\x12_exponential_scaleB\x1f\n" +
+	"\x1d_positive_bucket_counts_countB\x1f\n" +
+	"\x1d_negative_bucket_counts_count\"f\n" +
+	"\aTrigger\x122\n" +
+	"\x06filter\x18\x01 \x01(\v2\x1a.otellens.v1.StreamRequestR\x06filter\x12'\n" +
+	"\x0fcapture_seconds\x18\x02 \x01(\x05R\x0ecaptureSeconds\"\xc9\x01\n" +
+	"\x0fAttributeFilter\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05level\x18\x02 \x01(\tR\x05level\x12\x0e\n" +
+	"\x02op\x18\x03 \x01(\tR\x02op\x12=\n" +
+	"\x05value\x18\x04 \x01(\v2'.opentelemetry.proto.common.v1.AnyValueR\x05value\x12?\n" +
+	"\x06values\x18\x05 \x03(\v2'.opentelemetry.proto.common.v1.AnyValueR\x06values\"\xb4\x02\n" +
+	"\fCaptureEvent\x123\n" +
+	"\benvelope\x18\x01 \x01(\v2\x15.otellens.v1.EnvelopeH\x00R\benvelope\x12*\n" +
+	"\x03end\x18\x02 \x01(\v2\x16.otellens.v1.StreamEndH\x00R\x03end\x12C\n" +
+	"\x0efilter_updated\x18\x03 \x01(\v2\x1a.otellens.v1.FilterUpdatedH\x00R\rfilterUpdated\x126\n" +
+	"\ttriggered\x18\x04 \x01(\v2\x16.otellens.v1.TriggeredH\x00R\ttriggered\x12=\n" +
+	"\frate_limited\x18\x05 \x01(\v2\x18.otellens.v1.RateLimitedH\x00R\vrateLimitedB\a\n" +
+	"\x05event\"\xca\x01\n" +
+	"\vRateLimited\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tR\tsessionId\x12\x1f\n" +
+	"\vbatch_index\x18\x02 \x01(\x04R\n" +
+	"batchIndex\x121\n" +
+	"\x15reported_at_unix_nano\x18\x03 \x01(\x06R\x12reportedAtUnixNano\x12\x18\n" +
+	"\abatches\x18\x04 \x01(\x04R\abatches\x12\x18\n" +
+	"\arecords\x18\x05 \x01(\x04R\arecords\x12\x14\n" +
+	"\x05bytes\x18\x06 \x01(\x04R\x05bytes\"\x98\x01\n" +
+	"\tTriggered\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tR\tsessionId\x12\x1f\n" +
+	"\vbatch_index\x18\x02 \x01(\x04R\n" +
+	"batchIndex\x12\x16\n" +
+	"\x06signal\x18\x03 \x01(\tR\x06signal\x123\n" +
+	"\x16triggered_at_unix_nano\x18\x04 \x01(\x06R\x13triggeredAtUnixNano\"\x80\x01\n" +
+	"\rFilterUpdated\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tR\tsessionId\x12\x1f\n" +
+	"\vbatch_index\x18\x02 \x01(\x04R\n" +
+	"batchIndex\x12/\n" +
+	"\x14updated_at_unix_nano\x18\x03 \x01(\x06R\x11updatedAtUnixNano\"\xd3\x02\n" +
+	"\bEnvelope\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tR\tsessionId\x12\x16\n" +
+	"\x06signal\x18\x02 \x01(\tR\x06signal\x12\x1f\n" +
+	"\vbatch_index\x18\x03 \x01(\x04R\n" +
+	"batchIndex\x121\n" +
+	"\x15captured_at_unix_nano\x18\x04 \x01(\x06R\x12capturedAtUnixNano\x12\x1a\n" +
+	"\ametrics\x18\x05 \x01(\fH\x00R\ametrics\x12\x18\n" +
+	"\x06traces\x18\x06 \x01(\fH\x00R\x06traces\x12\x14\n" +
+	"\x04logs\x18\a \x01(\fH\x00R\x04logs\x12\x18\n" +
+	"\ahistory\x18\b \x01(\bR\ahistory\x121\n" +
+	"\bsampling\x18\t \x01(\v2\x15.otellens.v1.SamplingR\bsampling\x12\x18\n" +
+	"\arecords\x18\n" +
+	" \x01(\rR\arecordsB\t\n" +
+	"\apayload\"7\n" +
+	"\bSampling\x12\x12\n" +
+	"\x04rate\x18\x01 \x01(\x01R\x04rate\x12\x17\n" +
+	"\aevery_n\x18\x02 \x01(\rR\x06everyN\"\xf0\x04\n" +
+	"\tStreamEnd\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tR\tsessionId\x12\x12\n" +
+	"\x04sent\x18\x02 \x01(\x04R\x04sent\x12\x18\n" +
+	"\adropped\x18\x03 \x01(\x04R\adropped\x12%\n" +
+	"\x0epaused_seconds\x18\x04 \x01(\x01R\rpausedSeconds\x12!\n" +
+	"\frate_limited\x18\x05 \x01(\x04R\vrateLimited\x120\n" +
+	"\x14rate_limited_records\x18\x06 \x01(\x04R\x12rateLimitedRecords\x12#\n" +
+	"\rlimit_reached\x18\a \x01(\tR\flimitReached\x12\x16\n" +
+	"\x06reason\x18\b \x01(\tR\x06reason\x12=\n" +
+	"\asignals\x18\t \x03(\v2#.otellens.v1.StreamEnd.SignalsEntryR\asignals\x12)\n" +
+	"\x10duration_seconds\x18\n" +
+	" \x01(\x01R\x0fdurationSeconds\x12#\n" +
+	"\rbytes_written\x18\v \x01(\x04R\fbytesWritten\x12<\n" +
+	"\x1bfirst_captured_at_unix_nano\x18\f \x01(\x06R\x17firstCapturedAtUnixNano\x12:\n" +
+	"\x1alast_captured_at_unix_nano\x18\r \x01(\x06R\x16lastCapturedAtUnixNano\x1aT\n" +
+	"\fSignalsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12.\n" +
+	"\x05value\x18\x02 \x01(\v2\x18.otellens.v1.SignalStatsR\x05value:\x028\x01\"U\n" +
+	"\vSignalStats\x12\x18\n" +
+	"\amatched\x18\x01 \x01(\x04R\amatched\x12\x12\n" +
+	"\x04sent\x18\x02 \x01(\x04R\x04sent\x12\x18\n" +
+	"\adropped\x18\x03 \x01(\x04R\adropped2L\n" +
+	"\aCapture\x12A\n" +
+	"\x06Stream\x12\x1a.otellens.v1.StreamRequest\x1a\x19.otellens.v1.CaptureEvent0\x01B9Z7github.com/utrack/otellens/proto/otellens/v1;otellensv1b\x06proto3"
+
+var (
+	file_otellens_v1_capture_proto_rawDescOnce sync.Once
+	file_otellens_v1_capture_proto_rawDescData []byte
+)
+
+func file_otellens_v1_capture_proto_rawDescGZIP() []byte {
+	file_otellens_v1_capture_proto_rawDescOnce.Do(func() {
+		file_otellens_v1_capture_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_otellens_v1_capture_proto_rawDesc), len(file_otellens_v1_capture_proto_rawDesc)))
+	})
+	return file_otellens_v1_capture_proto_rawDescData
+}
+
+var file_otellens_v1_capture_proto_msgTypes = make([]protoimpl.MessageInfo, 13)
+var file_otellens_v1_capture_proto_goTypes = []any{
+	(*StreamRequest)(nil),   // 0: otellens.v1.StreamRequest
+	(*Trigger)(nil),         // 1: otellens.v1.Trigger
+	(*AttributeFilter)(nil), // 2: otellens.v1.AttributeFilter
+	(*CaptureEvent)(nil),    // 3: otellens.v1.CaptureEvent
+	(*RateLimited)(nil),     // 4: otellens.v1.RateLimited
+	(*Triggered)(nil),       // 5: otellens.v1.Triggered
+	(*FilterUpdated)(nil),   // 6: otellens.v1.FilterUpdated
+	(*Envelope)(nil),        // 7: otellens.v1.Envelope
+	(*Sampling)(nil),        // 8: otellens.v1.Sampling
+	(*StreamEnd)(nil),       // 9: otellens.v1.StreamEnd
+	(*SignalStats)(nil),     // 10: otellens.v1.SignalStats
+	nil,                     // 11: otellens.v1.StreamRequest.ResourceAttributesEntry
+	nil,                     // 12: otellens.v1.StreamEnd.SignalsEntry
+	(*v1.AnyValue)(nil),     // 13: opentelemetry.proto.common.v1.AnyValue
+}
+var file_otellens_v1_capture_proto_depIdxs = []int32{
+	11, // 0: otellens.v1.StreamRequest.resource_attributes:type_name -> otellens.v1.StreamRequest.ResourceAttributesEntry
+	2,  // 1: otellens.v1.StreamRequest.attribute_filters:type_name -> otellens.v1.AttributeFilter
+	1,  // 2: otellens.v1.StreamRequest.trigger:type_name -> otellens.v1.Trigger
+	0,  // 3: otellens.v1.Trigger.filter:type_name -> otellens.v1.StreamRequest
+	13, // 4: otellens.v1.AttributeFilter.value:type_name -> opentelemetry.proto.common.v1.AnyValue
+	13, // 5: otellens.v1.AttributeFilter.values:type_name -> opentelemetry.proto.common.v1.AnyValue
+	7,  // 6: otellens.v1.CaptureEvent.envelope:type_name -> otellens.v1.Envelope
+	9,  // 7: otellens.v1.CaptureEvent.end:type_name -> otellens.v1.StreamEnd
+	6,  // 8: otellens.v1.CaptureEvent.filter_updated:type_name -> otellens.v1.FilterUpdated
+	5,  // 9: otellens.v1.CaptureEvent.triggered:type_name -> otellens.v1.Triggered
+	4,  // 10: otellens.v1.CaptureEvent.rate_limited:type_name -> otellens.v1.RateLimited
+	8,  // 11: otellens.v1.Envelope.sampling:type_name -> otellens.v1.Sampling
+	12, // 12: otellens.v1.StreamEnd.signals:type_name -> otellens.v1.StreamEnd.SignalsEntry
+	10, // 13: otellens.v1.StreamEnd.SignalsEntry.value:type_name -> otellens.v1.SignalStats
+	0,  // 14: otellens.v1.Capture.Stream:input_type -> otellens.v1.StreamRequest
+	3,  // 15: otellens.v1.Capture.Stream:output_type -> otellens.v1.CaptureEvent
+	15, // [15:16] is the sub-list for method output_type
+	14, // [14:15] is the sub-list for method input_type
+	14, // [14:14] is the sub-list for extension type_name
+	14, // [14:14] is the sub-list for extension extendee
+	0,  // [0:14] is the sub-list for field type_name
+}
+
+func init() { file_otellens_v1_capture_proto_init() }
+func file_otellens_v1_capture_proto_init() {
+	if File_otellens_v1_capture_proto != nil {
+		return
+	}
+	file_otellens_v1_capture_proto_msgTypes[0].OneofWrappers = []any{}
+	file_otellens_v1_capture_proto_msgTypes[3].OneofWrappers = []any{
+		(*CaptureEvent_Envelope)(nil),
+		(*CaptureEvent_End)(nil),
+		(*CaptureEvent_FilterUpdated)(nil),
+		(*CaptureEvent_Triggered)(nil),
+		(*CaptureEvent_RateLimited)(nil),
+	}
+	file_otellens_v1_capture_proto_msgTypes[7].OneofWrappers = []any{
+		(*Envelope_Metrics)(nil),
+		(*Envelope_Traces)(nil),
+		(*Envelope_Logs)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_otellens_v1_capture_proto_rawDesc), len(file_otellens_v1_capture_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   13,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_otellens_v1_capture_proto_goTypes,
+		DependencyIndexes: file_otellens_v1_capture_proto_depIdxs,
+		MessageInfos:      file_otellens_v1_capture_proto_msgTypes,
+	}.Build()
+	File_otellens_v1_capture_proto = out.File
+	file_otellens_v1_capture_proto_goTypes = nil
+	file_otellens_v1_capture_proto_depIdxs = nil
+}
@@ -0,0 +1,131 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.2
+// - protoc             (unknown)
+// source: otellens/v1/capture.proto
+
+// Capture service of the otellens exporter. Messages mirror the JSON contracts of the HTTP API
+// (StreamRequest, Envelope, StreamEnd); payloads are the matching subset of each batch as OTLP.
+
+package otellensv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Capture_Stream_FullMethodName = "/otellens.v1.Capture/Stream"
+)
+
+// CaptureClient is the client API for Capture service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type CaptureClient interface {
+	// Stream registers a capture session and streams its envelopes until the session ends.
+	// The session ID is also sent as the `otellens-session-id` response header.
+	Stream(ctx context.Context, in *StreamRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[CaptureEvent], error)
+}
+
+type captureClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewCaptureClient(cc grpc.ClientConnInterface) CaptureClient {
+	return &captureClient{cc}
+}
+
+func (c *captureClient) Stream(ctx context.Context, in *StreamRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[CaptureEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Capture_ServiceDesc.Streams[0], Capture_Stream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamRequest, CaptureEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Capture_StreamClient = grpc.ServerStreamingClient[CaptureEvent]
+
+// CaptureServer is the server API for Capture service.
+// All implementations must embed UnimplementedCaptureServer
+// for forward compatibility.
+type CaptureServer interface {
+	// Stream registers a capture session and streams its envelopes until the session ends.
+	// The session ID is also sent as the `otellens-session-id` response header.
+	Stream(*StreamRequest, grpc.ServerStreamingServer[CaptureEvent]) error
+	mustEmbedUnimplementedCaptureServer()
+}
+
+// UnimplementedCaptureServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedCaptureServer struct{}
+
+func (UnimplementedCaptureServer) Stream(*StreamRequest, grpc.ServerStreamingServer[CaptureEvent]) error {
+	return status.Error(codes.Unimplemented, "method Stream not implemented")
+}
+func (UnimplementedCaptureServer) mustEmbedUnimplementedCaptureServer() {}
+func (UnimplementedCaptureServer) testEmbeddedByValue()                 {}
+
+// UnsafeCaptureServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to CaptureServer will
+// result in compilation errors.
+type UnsafeCaptureServer interface {
+	mustEmbedUnimplementedCaptureServer()
+}
+
+func RegisterCaptureServer(s grpc.ServiceRegistrar, srv CaptureServer) {
+	// If the following call panics, it indicates UnimplementedCaptureServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Capture_ServiceDesc, srv)
+}
+
+func _Capture_Stream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CaptureServer).Stream(m, &grpc.GenericServerStream[StreamRequest, CaptureEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Capture_StreamServer = grpc.ServerStreamingServer[CaptureEvent]
+
+// Capture_ServiceDesc is the grpc.ServiceDesc for Capture service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Capture_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "otellens.v1.Capture",
+	HandlerType: (*CaptureServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Stream",
+			Handler:       _Capture_Stream_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "otellens/v1/capture.proto",
+}
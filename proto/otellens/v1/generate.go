@@ -0,0 +1,4 @@
+// Package otellensv1 holds the generated code of capture.proto.
+package otellensv1
+
+//go:generate ../../generate.sh